@@ -0,0 +1,149 @@
+// Package cli 服务端二进制的子命令，所有命令共用 config.LoadConfig 加载配置
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"template-backend/config"
+	"template-backend/internal/app"
+	"template-backend/internal/migrations"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type command struct {
+	name    string
+	usage   []string // 各用法的参数说明，显示在命令名之后
+	summary string
+	run     func(args []string) error
+}
+
+// commands 按帮助信息中的顺序排列
+var commands = []command{
+	serveCommand,
+	migrateCommand,
+	migrateMenusCommand,
+	seedCommand,
+	userCommand,
+	roleCommand,
+	configCommand,
+	routesCommand,
+	logCollectorCommand,
+}
+
+// errUsage 参数错误，输出对应命令的用法
+var errUsage = errors.New("参数错误")
+
+// Run 执行 args 指定的子命令，未指定子命令时启动服务
+func Run(args []string) {
+	if len(args) == 0 {
+		args = []string{serveCommand.name}
+	}
+	switch args[0] {
+	case "help", "-h", "--help":
+		printUsage(os.Stdout)
+		return
+	}
+
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+		if err := c.run(args[1:]); err != nil {
+			if errors.Is(err, errUsage) {
+				if err != errUsage {
+					fmt.Fprintln(os.Stderr, err)
+				}
+				for _, usage := range c.usageLines() {
+					fmt.Fprintln(os.Stderr, "用法:", usage)
+				}
+				os.Exit(2)
+			}
+			fmt.Fprintln(os.Stderr, "错误:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", args[0])
+	printUsage(os.Stderr)
+	os.Exit(2)
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: template-backend <命令> [参数]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "命令:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", c.name, c.summary)
+		for _, usage := range c.usageLines() {
+			fmt.Fprintf(w, "  %-14s   %s\n", "", usage)
+		}
+	}
+}
+
+func (c command) usageLines() []string {
+	if len(c.usage) == 0 {
+		return []string{c.name}
+	}
+	lines := make([]string, len(c.usage))
+	for i, usage := range c.usage {
+		lines[i] = strings.TrimSpace(c.name + " " + usage)
+	}
+	return lines
+}
+
+// bootstrap 加载配置、连接数据库并组装应用容器；checkSchema 为 true 时要求数据库已迁移到最新版本。
+// 命令行下只输出警告以上级别的 SQL 日志
+func bootstrap(checkSchema bool) (*app.App, error) {
+	cfg := config.LoadConfig()
+	db, err := config.OpenDB(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	db = db.Session(&gorm.Session{Logger: db.Logger.LogMode(gormlogger.Warn)})
+	if checkSchema {
+		if err := migrations.Check(db); err != nil {
+			return nil, err
+		}
+	}
+	return app.New(cfg, db)
+}
+
+// newFlagSet 子命令参数解析，出错时返回 errUsage 而不是直接退出
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseFlags 解析参数，允许参数出现在位置参数之后
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// splitList 解析逗号分隔的列表
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
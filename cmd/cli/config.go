@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"template-backend/internal/model"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+)
+
+var configCommand = command{
+	name:    "config",
+	usage:   []string{"get [key]", "set <key> <value> [--name 配置名称]"},
+	summary: "查看或修改系统参数（configs 表），get 不指定 key 时列出全部",
+	run:     runConfig,
+}
+
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "get":
+		if len(args) > 2 {
+			return errUsage
+		}
+		return runConfigGet(args[1:])
+	case "set":
+		fs := newFlagSet("config set")
+		name := fs.String("name", "", "配置名称，新建配置时默认为 key")
+		positional, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 2 {
+			return errUsage
+		}
+		return runConfigSet(positional[0], positional[1], *name)
+	}
+	return errUsage
+}
+
+func runConfigGet(args []string) error {
+	a, err := bootstrap(true)
+	if err != nil {
+		return err
+	}
+	repo := a.Repos.Config
+
+	if len(args) == 1 {
+		config, err := repo.GetByKey(context.Background(), args[0])
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("配置 %s 不存在", args[0])
+			}
+			return err
+		}
+		fmt.Println(config.ConfigValue)
+		return nil
+	}
+
+	configs, _, err := repo.GetList(context.Background(), map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tNAME")
+	for _, c := range configs {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.ConfigKey, c.ConfigValue, c.ConfigName)
+	}
+	return w.Flush()
+}
+
+func runConfigSet(key, value, name string) error {
+	a, err := bootstrap(true)
+	if err != nil {
+		return err
+	}
+	repo := a.Repos.Config
+
+	config, err := repo.GetByKey(context.Background(), key)
+	switch {
+	case err == nil:
+		config.ConfigValue = value
+		if name != "" {
+			config.ConfigName = name
+		}
+		err = repo.Update(context.Background(), config)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if name == "" {
+			name = key
+		}
+		config = &model.Config{ConfigKey: key, ConfigName: name, ConfigValue: value, ConfigType: "N"}
+		err = repo.Create(context.Background(), config)
+	}
+	if err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	fmt.Printf("%s = %s\n", key, value)
+	return nil
+}
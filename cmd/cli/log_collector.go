@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"template-backend/internal/model"
+	"time"
+)
+
+var logCollectorCommand = command{
+	name:    "log-collector",
+	usage:   []string{"[--http :9880] [--udp :5514] [--tcp :5514] [--fail N] [--raw]"},
+	summary: "本地接收 http 和 syslog 请求日志输出并打印，用于调试 log_sinks；--fail 让前 N 个 HTTP 请求返回 503 以验证重试",
+	run:     runLogCollector,
+}
+
+func runLogCollector(args []string) error {
+	fs := newFlagSet("log-collector")
+	httpAddr := fs.String("http", ":9880", "HTTP 监听地址，接收 POST 的日志 JSON 数组，为空时不监听")
+	udpAddr := fs.String("udp", "", "syslog UDP 监听地址")
+	tcpAddr := fs.String("tcp", "", "syslog TCP 监听地址，按长度前缀分帧")
+	fail := fs.Int("fail", 0, "前 N 个 HTTP 请求返回 503")
+	raw := fs.Bool("raw", false, "打印完整的日志 JSON")
+	if positional, err := parseFlags(fs, args); err != nil || len(positional) > 0 {
+		return errUsage
+	}
+	if *httpAddr == "" && *udpAddr == "" && *tcpAddr == "" {
+		return fmt.Errorf("%w: 至少指定一个监听地址", errUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c := &logCollector{raw: *raw}
+	c.failures.Store(int64(*fail))
+	errs := make(chan error, 3)
+	var wg sync.WaitGroup
+	start := func(name string, serve func(context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := serve(ctx); err != nil {
+				errs <- fmt.Errorf("%s: %w", name, err)
+			}
+		}()
+	}
+	if *httpAddr != "" {
+		start("http", func(ctx context.Context) error { return c.serveHTTP(ctx, *httpAddr) })
+	}
+	if *udpAddr != "" {
+		start("udp", func(ctx context.Context) error { return c.serveUDP(ctx, *udpAddr) })
+	}
+	if *tcpAddr != "" {
+		start("tcp", func(ctx context.Context) error { return c.serveTCP(ctx, *tcpAddr) })
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+		stop()
+	}
+	wg.Wait()
+	fmt.Printf("共收到 %d 条日志\n", c.received.Load())
+	return err
+}
+
+// logCollector 打印收到的日志，多个监听协程共用
+type logCollector struct {
+	raw      bool
+	mu       sync.Mutex
+	received atomic.Int64
+	failures atomic.Int64
+}
+
+func (c *logCollector) print(source string, log *model.Log, text []byte) {
+	c.received.Add(1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.raw || log == nil {
+		fmt.Printf("[%s] %s\n", source, text)
+		return
+	}
+	fmt.Printf("[%s] %s %s %s %d %dms %s\n", source, log.Timestamp.Format(time.DateTime),
+		log.Method, log.Path, log.Status, log.Latency, log.RequestID)
+}
+
+func (c *logCollector) serveHTTP(ctx context.Context, addr string) error {
+	srv := &http.Server{Addr: addr, ReadHeaderTimeout: 10 * time.Second, Handler: http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "只接受 POST", http.StatusMethodNotAllowed)
+				return
+			}
+			if c.failures.Add(-1) >= 0 {
+				fmt.Printf("[http] 模拟失败，返回 503\n")
+				http.Error(w, "模拟失败", http.StatusServiceUnavailable)
+				return
+			}
+			var logs []json.RawMessage
+			if err := json.NewDecoder(r.Body).Decode(&logs); err != nil {
+				http.Error(w, "请求体不是 JSON 数组: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			for _, text := range logs {
+				var log model.Log
+				if json.Unmarshal(text, &log) != nil {
+					c.print("http", nil, text)
+					continue
+				}
+				c.print("http", &log, text)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	fmt.Printf("HTTP 收集器监听 %s\n", addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (c *logCollector) serveUDP(ctx context.Context, addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+	fmt.Printf("syslog UDP 收集器监听 %s\n", addr)
+	buf := make([]byte, 64<<10)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		c.printSyslog("udp", buf[:n])
+	}
+}
+
+func (c *logCollector) serveTCP(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+	fmt.Printf("syslog TCP 收集器监听 %s\n", addr)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go c.readFrames(ctx, conn)
+	}
+}
+
+// readFrames 读取 "长度 空格 消息" 格式的帧
+func (c *logCollector) readFrames(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+	r := bufio.NewReader(conn)
+	for {
+		prefix, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		size, err := strconv.Atoi(prefix[:len(prefix)-1])
+		if err != nil || size <= 0 {
+			fmt.Printf("[tcp] 无效的帧长度 %q，断开连接\n", prefix)
+			return
+		}
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return
+		}
+		c.printSyslog("tcp", msg)
+	}
+}
+
+// printSyslog 消息体为日志 JSON，从第一个 { 开始解析，解析失败时打印原文
+func (c *logCollector) printSyslog(source string, msg []byte) {
+	if i := bytes.IndexByte(msg, '{'); i >= 0 {
+		var log model.Log
+		if json.Unmarshal(msg[i:], &log) == nil {
+			c.print(source, &log, msg)
+			return
+		}
+	}
+	c.print(source, nil, msg)
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	_ "template-backend/internal/migrations"
+	"template-backend/pkg/migrate"
+
+	"go.uber.org/zap"
+)
+
+var migrateCommand = command{
+	name:    "migrate",
+	usage:   []string{"up", "down [N]", "status", "create <name> [--dir internal/migrations]"},
+	summary: "数据库迁移：执行、回滚最近 N 个、查看状态、生成迁移文件",
+	run:     runMigrate,
+}
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	if args[0] == "create" {
+		fs := newFlagSet("migrate create")
+		dir := fs.String("dir", "internal/migrations", "迁移文件目录")
+		positional, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return errUsage
+		}
+		path, err := migrate.Create(*dir, positional[0])
+		if err != nil {
+			return fmt.Errorf("生成迁移文件失败: %w", err)
+		}
+		fmt.Println("已生成", path)
+		return nil
+	}
+
+	n := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) != 1 {
+			return errUsage
+		}
+	case "down":
+		if len(args) > 2 {
+			return errUsage
+		}
+		if len(args) == 2 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n <= 0 {
+				return errUsage
+			}
+		}
+	default:
+		return errUsage
+	}
+
+	a, err := bootstrap(false)
+	if err != nil {
+		return err
+	}
+	log := a.Logger
+	defer log.Sync()
+	migrator := migrate.New(a.DB)
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up()
+		for _, m := range done {
+			log.Info("migration applied", zap.String("version", m.Version), zap.String("name", m.Name))
+			fmt.Printf("已执行 %s_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("迁移失败: %w", err)
+		}
+		if len(done) == 0 {
+			fmt.Println("没有需要执行的迁移")
+		}
+	case "down":
+		done, err := migrator.Down(n)
+		for _, m := range done {
+			log.Info("migration reverted", zap.String("version", m.Version), zap.String("name", m.Name))
+			fmt.Printf("已回滚 %s_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("回滚失败: %w", err)
+		}
+	case "status":
+		list, err := migrator.Status()
+		if err != nil {
+			return fmt.Errorf("查询迁移状态失败: %w", err)
+		}
+		for _, s := range list {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Unknown {
+				state += " (unknown)"
+			}
+			fmt.Printf("%s  %-40s %s\n", s.Version, s.Name, state)
+		}
+	}
+	return nil
+}
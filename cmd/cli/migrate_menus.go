@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"template-backend/internal/app"
+	"template-backend/internal/service"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// errDryRun 试运行时用于回滚事务
+var errDryRun = errors.New("dry run")
+
+var migrateMenusCommand = command{
+	name:    "migrate-menus",
+	usage:   []string{"[--dry-run]"},
+	summary: "将旧版 menus 表合并到 resources 表，--dry-run 只统计不写入",
+	run:     runMigrateMenus,
+}
+
+func runMigrateMenus(args []string) error {
+	fs := newFlagSet("migrate-menus")
+	dryRun := fs.Bool("dry-run", false, "只统计迁移结果，不写入数据库")
+	if positional, err := parseFlags(fs, args); err != nil || len(positional) > 0 {
+		return errUsage
+	}
+
+	a, err := bootstrap(true)
+	if err != nil {
+		return err
+	}
+	log := a.Logger
+	defer log.Sync()
+
+	var report *service.MenuMigrationReport
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		repos := app.NewRepositories(tx)
+		migration := service.NewMenuMigrationService(repos.Menu, repos.Resource, a.Services.PermissionCache)
+		var err error
+		if report, err = migration.Migrate(context.Background()); err != nil {
+			return err
+		}
+		if *dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		log.Error("migrate menus failed", zap.Error(err))
+		return fmt.Errorf("菜单迁移失败: %w", err)
+	}
+
+	log.Info("migrate menus finished", zap.Bool("dryRun", *dryRun), zap.Int("created", report.Created),
+		zap.Int("merged", report.Merged), zap.Int("skipped", report.Skipped), zap.Int("linked", report.Linked))
+	fmt.Printf("新建 %d，合并 %d，跳过 %d，关联父节点 %d", report.Created, report.Merged, report.Skipped, report.Linked)
+	if *dryRun {
+		fmt.Print("（试运行，未写入）")
+	}
+	fmt.Println()
+	return nil
+}
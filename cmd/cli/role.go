@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var roleCommand = command{
+	name:    "role",
+	usage:   []string{"grant <role-code> <permission-code>...", "grant --user <username> <role-code>..."},
+	summary: "为角色追加资源权限，或为用户追加角色",
+	run:     runRole,
+}
+
+func runRole(args []string) error {
+	if len(args) == 0 || args[0] != "grant" {
+		return errUsage
+	}
+	fs := newFlagSet("role grant")
+	username := fs.String("user", "", "为该用户追加角色")
+	positional, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if *username != "" {
+		if len(positional) == 0 {
+			return errUsage
+		}
+		return grantRolesToUser(*username, positional)
+	}
+	if len(positional) < 2 {
+		return errUsage
+	}
+	return grantResourcesToRole(positional[0], positional[1:])
+}
+
+func grantResourcesToRole(roleCode string, permissionCodes []string) error {
+	a, err := bootstrap(true)
+	if err != nil {
+		return err
+	}
+	roleRepo := a.Repos.Role
+	resourceRepo := a.Repos.Resource
+
+	role, err := roleRepo.GetByCode(context.Background(), roleCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("角色 %s 不存在", roleCode)
+		}
+		return err
+	}
+	granted, err := roleRepo.GetPermissions(context.Background(), role.ID)
+	if err != nil {
+		return err
+	}
+	ids := make([]uint, 0, len(granted)+len(permissionCodes))
+	seen := make(map[uint]bool)
+	for _, resource := range granted {
+		seen[uint(resource.ID)] = true
+		ids = append(ids, uint(resource.ID))
+	}
+	added := 0
+	for _, code := range permissionCodes {
+		resource, err := resourceRepo.GetByPermissionCode(context.Background(), code)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("权限标识 %s 不存在", code)
+			}
+			return err
+		}
+		if !seen[uint(resource.ID)] {
+			seen[uint(resource.ID)] = true
+			ids = append(ids, uint(resource.ID))
+			added++
+		}
+	}
+
+	if err := a.Services.Role.UpdatePermissions(context.Background(), role.ID, ids); err != nil {
+		return fmt.Errorf("授权失败: %w", err)
+	}
+	fmt.Printf("已为角色 %s 新增 %d 个权限\n", roleCode, added)
+	return nil
+}
+
+func grantRolesToUser(username string, roleCodes []string) error {
+	a, err := bootstrap(true)
+	if err != nil {
+		return err
+	}
+	userRepo := a.Repos.User
+	roleRepo := a.Repos.Role
+
+	user, err := userRepo.GetByUsername(context.Background(), username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("用户 %s 不存在", username)
+		}
+		return err
+	}
+	current, err := userRepo.GetUserRoles(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	newIDs, err := roleIDsByCode(roleRepo, roleCodes)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]uint, 0, len(current)+len(newIDs))
+	seen := make(map[uint]bool)
+	for _, role := range current {
+		seen[role.ID] = true
+		ids = append(ids, role.ID)
+	}
+	added := 0
+	for _, id := range newIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+			added++
+		}
+	}
+
+	if err := a.Services.User.AssignRoles(context.Background(), user.ID, ids); err != nil {
+		return fmt.Errorf("分配角色失败: %w", err)
+	}
+	fmt.Printf("已为用户 %s 新增 %d 个角色\n", username, added)
+	return nil
+}
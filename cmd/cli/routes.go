@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"template-backend/cmd/server"
+	"template-backend/internal/app"
+	"template-backend/internal/service"
+	"text/tabwriter"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var routesCommand = command{
+	name:    "routes",
+	usage:   []string{"", "sync [--dry-run]"},
+	summary: "列出已注册的 HTTP 路由；sync 为缺少的路由创建接口资源并列出失效的资源",
+	run:     runRoutes,
+}
+
+func runRoutes(args []string) error {
+	if len(args) > 0 && args[0] == "sync" {
+		fs := newFlagSet("routes sync")
+		dryRun := fs.Bool("dry-run", false, "只显示差异，不写入数据库")
+		if positional, err := parseFlags(fs, args[1:]); err != nil || len(positional) > 0 {
+			return errUsage
+		}
+		return runRoutesSync(*dryRun)
+	}
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	a, err := bootstrap(false)
+	if err != nil {
+		return err
+	}
+	routes := buildRoutes(a)
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tHANDLER")
+	for _, r := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Method, r.Path, r.Handler)
+	}
+	return w.Flush()
+}
+
+func runRoutesSync(dryRun bool) error {
+	a, err := bootstrap(true)
+	if err != nil {
+		return err
+	}
+	routes := buildRoutes(a)
+
+	var report *service.APISyncReport
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		apiSync := a.WithDB(tx).Services.APISync
+		if dryRun {
+			report, err = apiSync.Diff(context.Background(), routes)
+		} else {
+			report, err = apiSync.Sync(context.Background(), routes, a.Config.RBAC.PublicAPIs)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("同步接口资源失败: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, route := range report.Missing {
+		fmt.Fprintf(w, "+\t%s\t%s\t%s\t%s\n", route.Method, route.Path, route.PermissionCode, route.Handler)
+	}
+	for _, resource := range report.Orphaned {
+		fmt.Fprintf(w, "!\t%s\t%s\t%s\t路由已不存在（ID %d）\n", *resource.HTTPMethod, *resource.ResourcePath,
+			resource.PermissionCode, resource.ID)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("已匹配 %d，缺少 %d，失效 %d（试运行，未写入）\n", report.Matched, len(report.Missing), len(report.Orphaned))
+	} else {
+		fmt.Printf("已匹配 %d，新建 %d，失效 %d\n", report.Matched, len(report.Created), len(report.Orphaned))
+	}
+	if len(report.Orphaned) > 0 {
+		fmt.Println("失效的接口资源不会自动删除，请确认后在资源管理中处理")
+	}
+	return nil
+}
+
+// buildRoutes 构建与服务启动时相同的路由
+func buildRoutes(a *app.App) gin.RoutesInfo {
+	// 避免 gin 调试模式在注册路由时重复输出
+	gin.SetMode(gin.ReleaseMode)
+	return server.NewRouter(a).Routes()
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"template-backend/internal/service"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var seedCommand = command{
+	name:    "seed",
+	usage:   []string{"[--file seeds/seed.yaml]"},
+	summary: "写入初始化数据：超级管理员角色、管理员用户、菜单和接口资源，可重复执行",
+	run:     runSeed,
+}
+
+func runSeed(args []string) error {
+	fs := newFlagSet("seed")
+	file := fs.String("file", "seeds/seed.yaml", "初始化数据文件（YAML 或 JSON）")
+	if positional, err := parseFlags(fs, args); err != nil || len(positional) > 0 {
+		return errUsage
+	}
+
+	data, err := service.LoadSeedFile(*file)
+	if err != nil {
+		return err
+	}
+	a, err := bootstrap(true)
+	if err != nil {
+		return err
+	}
+	log := a.Logger
+	defer log.Sync()
+
+	routes := buildRoutes(a)
+	data.APIs.Public = append(data.APIs.Public, a.Config.RBAC.PublicAPIs...)
+
+	var report *service.SeedReport
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		txApp := a.WithDB(tx)
+		seeder := service.NewSeedService(txApp.Repos.Role, txApp.Repos.Resource, txApp.Repos.User,
+			txApp.Services.User, txApp.Services.APISync, txApp.Services.PermissionCache)
+		report, err = seeder.Seed(context.Background(), data, routes)
+		return err
+	})
+	if err != nil {
+		log.Error("seed failed", zap.Error(err))
+		return err
+	}
+
+	log.Info("seed finished", zap.Int("roles", report.Roles), zap.Int("users", report.Users),
+		zap.Int("resources", report.Resources), zap.Int("apis", report.APIs), zap.Int("grants", report.Grants))
+	fmt.Printf("新建角色 %d，用户 %d，菜单资源 %d，接口资源 %d，授权 %d\n",
+		report.Roles, report.Users, report.Resources, report.APIs, report.Grants)
+	usernames := make([]string, 0, len(report.TempPassword))
+	for username := range report.TempPassword {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	for _, username := range usernames {
+		fmt.Printf("用户 %s 的临时密码: %s（首次登录后必须修改）\n", username, report.TempPassword[username])
+	}
+	return nil
+}
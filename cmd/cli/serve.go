@@ -0,0 +1,15 @@
+package cli
+
+import "template-backend/cmd/server"
+
+var serveCommand = command{
+	name:    "serve",
+	summary: "启动 HTTP 服务（默认命令）",
+	run: func(args []string) error {
+		if len(args) > 0 {
+			return errUsage
+		}
+		server.ServerMain()
+		return nil
+	},
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const defaultSuperRole = "super_admin"
+
+var userCommand = command{
+	name:    "user",
+	usage:   []string{"create <username> [--password P] [--nickname N] [--roles code1,code2] [--admin]", "reset-password <username>"},
+	summary: "创建用户（--admin 授予超级管理员角色）、重置密码",
+	run:     runUser,
+}
+
+func runUser(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "create":
+		return runUserCreate(args[1:])
+	case "reset-password":
+		if len(args) != 2 {
+			return errUsage
+		}
+		return runUserResetPassword(args[1])
+	}
+	return errUsage
+}
+
+func runUserCreate(args []string) error {
+	fs := newFlagSet("user create")
+	password := fs.String("password", "", "初始密码，不指定时生成临时密码并要求首次登录修改")
+	nickname := fs.String("nickname", "", "昵称")
+	roles := fs.String("roles", "", "角色编码，多个用逗号分隔")
+	admin := fs.Bool("admin", false, "授予超级管理员角色，角色不存在时自动创建")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errUsage
+	}
+
+	a, err := bootstrap(true)
+	if err != nil {
+		return err
+	}
+	roleRepo := a.Repos.Role
+	userService := a.Services.User
+
+	roleCodes := splitList(*roles)
+	if *admin {
+		code, err := ensureSuperRole(a.Config, roleRepo)
+		if err != nil {
+			return err
+		}
+		roleCodes = append(roleCodes, code)
+	}
+	roleIDs, err := roleIDsByCode(roleRepo, roleCodes)
+	if err != nil {
+		return err
+	}
+
+	user := &model.User{Username: positional[0], Nickname: *nickname, Status: 1}
+	if user.Nickname == "" {
+		user.Nickname = user.Username
+	}
+	tempPassword := ""
+	if *password == "" {
+		if tempPassword, err = userService.CreateWithTempPassword(context.Background(), user); err != nil {
+			return fmt.Errorf("创建用户失败: %w", err)
+		}
+	} else {
+		user.Password = *password
+		if err := userService.Create(context.Background(), user); err != nil {
+			return fmt.Errorf("创建用户失败: %w", err)
+		}
+	}
+	if len(roleIDs) > 0 {
+		if err := userService.AssignRoles(context.Background(), user.ID, roleIDs); err != nil {
+			return fmt.Errorf("分配角色失败: %w", err)
+		}
+	}
+
+	fmt.Printf("已创建用户 %s（ID %d）\n", user.Username, user.ID)
+	if tempPassword != "" {
+		fmt.Printf("临时密码: %s（首次登录后必须修改）\n", tempPassword)
+	}
+	return nil
+}
+
+func runUserResetPassword(username string) error {
+	a, err := bootstrap(true)
+	if err != nil {
+		return err
+	}
+	user, err := a.Repos.User.GetByUsername(context.Background(), username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("用户 %s 不存在", username)
+		}
+		return err
+	}
+	password, err := a.Services.User.ResetPassword(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("重置密码失败: %w", err)
+	}
+	fmt.Printf("已重置用户 %s 的密码，已有会话全部失效\n", username)
+	fmt.Printf("临时密码: %s（下次登录后必须修改）\n", password)
+	return nil
+}
+
+// ensureSuperRole 返回配置的第一个超级管理员角色编码，角色不存在时创建
+func ensureSuperRole(cfg *config.AppConfig, roleRepo repository.RoleRepository) (string, error) {
+	code := defaultSuperRole
+	if len(cfg.RBAC.SuperRoles) > 0 {
+		code = cfg.RBAC.SuperRoles[0]
+	}
+	_, err := roleRepo.GetByCode(context.Background(), code)
+	if err == nil {
+		return code, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	role := &model.Role{RoleName: "超级管理员", RoleCode: code, RoleDesc: "拥有全部权限", Status: 1}
+	if err := roleRepo.Create(context.Background(), role); err != nil {
+		return "", fmt.Errorf("创建超级管理员角色失败: %w", err)
+	}
+	fmt.Printf("已创建角色 %s\n", code)
+	return code, nil
+}
+
+func roleIDsByCode(roleRepo repository.RoleRepository, codes []string) ([]uint, error) {
+	ids := make([]uint, 0, len(codes))
+	seen := make(map[uint]bool)
+	for _, code := range codes {
+		role, err := roleRepo.GetByCode(context.Background(), code)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("角色 %s 不存在", code)
+			}
+			return nil, err
+		}
+		if !seen[role.ID] {
+			seen[role.ID] = true
+			ids = append(ids, role.ID)
+		}
+	}
+	return ids, nil
+}
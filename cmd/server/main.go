@@ -4,15 +4,20 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"template-backend/config"
-	_ "template-backend/internal/handler"
+	"template-backend/internal/app"
+	"template-backend/internal/handler"
 	"template-backend/internal/middleware"
+	"template-backend/internal/migrations"
 	"template-backend/internal/router"
-	"template-backend/pkg/logger"
+	"template-backend/internal/service"
+	"template-backend/pkg/lifecycle"
+	"template-backend/pkg/redact"
 	"time"
 
 	"go.uber.org/zap"
@@ -28,40 +33,125 @@ import (
 
 func ServerMain() {
 	cfg := config.LoadConfig()
-	logger.Init()
+	db := config.InitDB()
+	a, err := app.New(cfg, db)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	logger := logger.Logger()
+	logger := a.Logger
 	defer logger.Sync()
 
-	r := gin.New()
-	r.Use(gin.Recovery(), middleware.EnhancedLoggingMiddleware(logger), middleware.CORSMiddleware(), middleware.JWTMiddleware())
-	db := config.InitDB()
-	// 自动注册路由（模块通过 init 注册）
-	router.RegisterRoutes(r, db)
-	// 添加Swagger路由
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	if err := migrations.Check(db); err != nil {
+		logger.Fatal("database schema check failed", zap.Error(err))
+	}
+	r := NewRouter(a)
+	syncAPIResources(a, r.Routes())
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.App.Port),
 		Handler: r,
 	}
+	serveErr := make(chan error, 1)
+	a.Lifecycle.Append(lifecycle.Hook{
+		Name: "http server",
+		Start: func(context.Context) error {
+			// 同步监听端口，端口被占用等错误在启动阶段返回
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+					serveErr <- err
+				}
+			}()
+			return nil
+		},
+		Stop:        srv.Shutdown,
+		StopTimeout: time.Duration(cfg.Shutdown.HTTPTimeout) * time.Second,
+	})
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
-		}
-	}()
+	if err := a.Lifecycle.Start(context.Background()); err != nil {
+		logger.Fatal("start failed", zap.Error(err))
+	}
 	logger.Info("Server running", zap.Int("port", cfg.App.Port))
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	select {
+	case <-quit:
+	case err := <-serveErr:
+		logger.Error("http server stopped unexpectedly", zap.Error(err))
+	}
 	logger.Info("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown:", zap.Error(err))
+	// 逆序停止：HTTP 服务停止接收请求，请求日志写完后再关闭数据库
+	if err := a.Lifecycle.Stop(context.Background()); err != nil {
+		logger.Error("shutdown incomplete", zap.Error(err))
 	}
 	logger.Info("Server exiting")
 }
+
+// NewRouter 构建 gin 引擎：全局中间件、各模块注册的业务路由和 Swagger
+func NewRouter(a *app.App) *gin.Engine {
+	r := gin.New()
+	// 登录失败计数和审计按客户端 IP 统计，只有来自可信代理的请求才采用 X-Forwarded-For
+	if err := r.SetTrustedProxies(a.Config.App.TrustedProxies); err != nil {
+		a.Logger.Fatal("invalid app.trusted_proxies", zap.Error(err))
+	}
+	services := a.Services
+	r.Use(gin.Recovery())
+	// 探针和指标在业务中间件之前注册，不经过请求日志、鉴权和权限校验
+	health := handler.NewHealthHandler(a.DB, services.LogWriter)
+	r.GET("/health", health.Health)
+	r.GET("/ready", health.Ready)
+	r.GET("/metrics", gin.WrapH(a.Metrics.Handler()))
+
+	r.Use(middleware.TracingMiddleware(), middleware.RequestIDMiddleware(a.Logger), a.Metrics.Middleware(), middleware.EnhancedLoggingMiddleware(a.Logger, services.LogWriter, redact.New(a.Config.LogMasking)), middleware.CORSMiddleware(),
+		middleware.JWTMiddleware(a.Config, services.Token, services.Session))
+	if a.Config.RBAC.Enabled {
+		r.Use(middleware.RBACMiddleware(a.Config, services.Permission), middleware.DataScopeMiddleware(services.Permission))
+	}
+	// 自动注册路由（模块通过 init 注册）
+	router.RegisterRoutes(r, a)
+	// 添加Swagger路由
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	return r
+}
+
+// syncAPIResources 按 rbac.sync_routes 比对已注册的路由和接口资源，记录差异或创建缺少的资源
+func syncAPIResources(a *app.App, routes gin.RoutesInfo) {
+	cfg := a.Config
+	mode := cfg.RBAC.SyncRoutes
+	if mode == "" || mode == "off" {
+		return
+	}
+	logger := a.Logger
+	apiSync := a.Services.APISync
+
+	var report *service.APISyncReport
+	var err error
+	if mode == "create" {
+		report, err = apiSync.Sync(context.Background(), routes, cfg.RBAC.PublicAPIs)
+	} else {
+		report, err = apiSync.Diff(context.Background(), routes)
+	}
+	if err != nil {
+		logger.Error("sync api resources failed", zap.Error(err))
+		return
+	}
+
+	for _, resource := range report.Created {
+		logger.Info("api resource created", zap.String("code", resource.PermissionCode))
+	}
+	if mode != "create" {
+		for _, route := range report.Missing {
+			logger.Warn("route has no api resource", zap.String("method", route.Method), zap.String("path", route.Path),
+				zap.String("handler", route.Handler))
+		}
+	}
+	for _, resource := range report.Orphaned {
+		logger.Warn("api resource has no route", zap.Int64("id", resource.ID), zap.String("code", resource.PermissionCode))
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"template-backend/config"
+	"template-backend/internal/app"
+	"template-backend/internal/model"
+	"template-backend/internal/testdb"
+	"template-backend/pkg/token"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestLoginFailuresIgnoreUntrustedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		subjects       map[string]int // IP 维度的失败计数
+	}{
+		{
+			name:     "未配置可信代理时按连接地址计数",
+			subjects: map[string]int{"192.0.2.1": 3},
+		},
+		{
+			name:           "来自可信代理时按 X-Forwarded-For 计数",
+			trustedProxies: []string{"192.0.2.1"},
+			subjects:       map[string]int{"10.0.0.1": 1, "10.0.0.2": 1, "10.0.0.3": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.Open(t)
+			cfg := &config.AppConfig{}
+			cfg.App.TrustedProxies = tt.trustedProxies
+			cfg.JWT.Secret = "test"
+			cfg.JWT.SkipAuthUrls = []string{"/api/auth/login"}
+			tokens, err := token.NewManager(cfg.JWT)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := NewRouter(app.Build(cfg, zap.NewNop(), db, tokens, app.NewRepositories(db)))
+
+			// 每次伪造不同的 X-Forwarded-For，试图让 IP 维度的计数从头开始
+			for i := 1; i <= 3; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"username":"nobody","password":"wrong"}`))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Forwarded-For", fmt.Sprintf("10.0.0.%d", i))
+				req.RemoteAddr = "192.0.2.1:40000"
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				if w.Code != http.StatusUnauthorized {
+					t.Fatalf("login #%d status = %d, body = %s", i, w.Code, w.Body.String())
+				}
+			}
+
+			failures, _, err := app.NewRepositories(db).LoginSecurity.ListFailures(context.Background(), 1, 10,
+				map[string]interface{}{"scope": model.LoginScopeIP})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]int, len(failures))
+			for _, f := range failures {
+				got[f.Subject] = f.Failures
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.subjects) {
+				t.Fatalf("ip failures = %v, want %v", got, tt.subjects)
+			}
+		})
+	}
+}
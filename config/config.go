@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"os"
 	"template-backend/internal/model"
+	"template-backend/pkg/datascope"
+	"template-backend/pkg/redact"
+	"template-backend/pkg/tracing"
 	"time"
 
 	"gorm.io/gorm/logger"
 
 	"github.com/spf13/viper"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 
 	"log"
@@ -17,23 +19,147 @@ import (
 
 type AppConfig struct {
 	App struct {
-		Name string
-		Port int
-		Env  string
+		Name           string
+		Port           int
+		Env            string
+		TrustedProxies []string `mapstructure:"trusted_proxies"` // 信任其 X-Forwarded-For 的代理地址或网段，为空时客户端 IP 取连接地址
 	} `mapstructure:"app"`
 
-	Database struct {
-		Host     string
-		DbName   string
-		Account  string
-		Password string
-	} `mapstructure:"database"`
-
-	JWT struct {
-		Secret       string
-		Expires      int
-		SkipAuthUrls []string `mapstructure:"skip_auth_urls"`
-	} `mapstructure:"jwt"`
+	Database DatabaseConfig `mapstructure:"database"`
+
+	JWT JWTConfig `mapstructure:"jwt"`
+
+	LoginSecurity struct {
+		CaptchaThreshold  int `mapstructure:"captcha_threshold"`   // 失败次数达到后要求验证码
+		UserLockThreshold int `mapstructure:"user_lock_threshold"` // 同一用户名失败次数达到后锁定
+		IPLockThreshold   int `mapstructure:"ip_lock_threshold"`   // 同一 IP 失败次数达到后锁定
+		LockDuration      int `mapstructure:"lock_duration"`       // 首次锁定时长（秒），再次锁定时指数增长
+		MaxLockDuration   int `mapstructure:"max_lock_duration"`   // 最长锁定时长（秒）
+		FailureWindow     int `mapstructure:"failure_window"`      // 超过该时长（秒）没有失败则计数清零
+	} `mapstructure:"login_security"`
+
+	TwoFactor struct {
+		Issuer           string   // 认证器应用中显示的签发者
+		EnforcedRoles    []string `mapstructure:"enforced_roles"`    // 必须启用两步验证的角色编码
+		ChallengeExpires int      `mapstructure:"challenge_expires"` // 两步登录挑战令牌有效期（秒）
+	} `mapstructure:"two_factor"`
+
+	PasswordPolicy struct {
+		MinLength     int      `mapstructure:"min_length"`     // 最小长度
+		RequireUpper  bool     `mapstructure:"require_upper"`  // 必须包含大写字母
+		RequireLower  bool     `mapstructure:"require_lower"`  // 必须包含小写字母
+		RequireDigit  bool     `mapstructure:"require_digit"`  // 必须包含数字
+		RequireSymbol bool     `mapstructure:"require_symbol"` // 必须包含特殊字符
+		Banned        []string // 禁止使用的弱密码（不区分大小写）
+		HistorySize   int      `mapstructure:"history_size"` // 不允许与最近 N 次使用过的密码相同
+		MaxAge        int      `mapstructure:"max_age"`      // 密码最长有效期（天），0 表示不过期
+	} `mapstructure:"password_policy"`
+
+	RBAC struct {
+		Enabled       bool
+		DenyUnmatched bool     `mapstructure:"deny_unmatched"` // 未登记为 API 资源的路由是否拒绝访问
+		SuperRoles    []string `mapstructure:"super_roles"`    // 拥有全部权限的角色编码
+		CacheTTL      int      `mapstructure:"cache_ttl"`      // 权限缓存有效期（秒）
+		SyncRoutes    string   `mapstructure:"sync_routes"`    // 启动时同步接口资源：off 不处理，report 只记录差异，create 自动创建缺少的资源
+		PublicAPIs    []string `mapstructure:"public_apis"`    // 自动创建接口资源时，这些路径前缀只要求登录、不校验角色授权
+	} `mapstructure:"rbac"`
+
+	Shutdown struct {
+		Timeout         int // 组件默认停止超时（秒）
+		HTTPTimeout     int `mapstructure:"http_timeout"`      // 等待进行中的请求完成（秒）
+		LogFlushTimeout int `mapstructure:"log_flush_timeout"` // 等待缓冲的请求日志写入各输出目标（秒）
+	} `mapstructure:"shutdown"`
+
+	Tracing tracing.Config `mapstructure:"tracing"`
+
+	LogExport struct {
+		Dir       string // 后台导出文件目录
+		SyncLimit int    `mapstructure:"sync_limit"` // 超过该行数的导出转为后台任务
+		FileTTL   int    `mapstructure:"file_ttl"`   // 后台导出文件保留时长（秒）
+		MaxJobs   int    `mapstructure:"max_jobs"`   // 同时运行的后台导出任务数
+	} `mapstructure:"log_export"`
+
+	LogRetention LogRetentionConfig `mapstructure:"log_retention"`
+
+	LogMasking redact.Config `mapstructure:"log_masking"` // 请求日志脱敏规则
+
+	LogSinks []LogSinkConfig `mapstructure:"log_sinks"` // 请求日志输出目标，未配置时只写入数据库
+}
+
+// LogSinkConfig 请求日志的一个输出目标，每个目标有独立的缓冲区、批量和重试
+type LogSinkConfig struct {
+	Type          string // database | file | stdout | http | syslog
+	Name          string // 指标和日志中的名称，默认与 type 相同，同类型配置多个时必须指定
+	Buffer        int    // 缓冲区容量，满时丢弃新日志，默认 10000
+	BatchSize     int    `mapstructure:"batch_size"`     // 每批写入的条数，默认 100
+	FlushInterval int    `mapstructure:"flush_interval"` // 不足一批时的写入间隔（秒），默认 5
+	MaxRetries    int    `mapstructure:"max_retries"`    // 写入失败后的重试次数，默认 3，-1 表示不重试
+	RetryBackoff  int    `mapstructure:"retry_backoff"`  // 首次重试等待（毫秒），之后每次翻倍，最长 30 秒，默认 1000
+
+	Path       string // file：文件路径
+	MaxSize    int    `mapstructure:"max_size"`    // file：单个文件大小上限（MB），超过后轮转，默认 100
+	MaxBackups int    `mapstructure:"max_backups"` // file：保留的轮转文件数，0 表示全部保留
+
+	URL     string            // http：接收地址，请求体为日志 JSON 数组
+	Headers map[string]string // http：附加请求头，例如鉴权
+	Timeout int               // http、syslog：请求或连接超时（秒），默认 5
+
+	Network string // syslog：udp 或 tcp，默认 udp
+	Address string // syslog：host:port
+	Tag     string // syslog：APP-NAME，默认 template-backend
+}
+
+// LogRetentionConfig 请求日志保留策略，2xx/3xx 和 4xx/5xx 分别按时间和行数保留，超出的日志定时物理删除
+type LogRetentionConfig struct {
+	Enabled    bool             `json:"enabled"`                               // 是否定时清理，关闭后仍可手动执行
+	Interval   int              `json:"interval"`                              // 定时清理间隔（秒）
+	Mode       string           `json:"mode"`                                  // delete 直接删除，archive 先写入 gzip 压缩的 NDJSON 文件再删除
+	ArchiveDir string           `mapstructure:"archive_dir" json:"archiveDir"` // 归档文件目录
+	BatchSize  int              `mapstructure:"batch_size" json:"batchSize"`   // 每批删除的行数
+	Success    LogRetentionRule `json:"success"`                               // 状态码 < 400 的日志
+	Failure    LogRetentionRule `json:"failure"`                               // 状态码 >= 400 的日志
+}
+
+// LogRetentionRule 一类日志的保留规则，两项都为 0 时不清理
+type LogRetentionRule struct {
+	MaxAge  int   `mapstructure:"max_age" json:"maxAge"`   // 保留天数
+	MaxRows int64 `mapstructure:"max_rows" json:"maxRows"` // 最多保留的行数，超出时清理最早的日志
+}
+
+// DatabaseConfig 数据库连接配置，driver 支持 mysql、postgres、sqlite
+type DatabaseConfig struct {
+	Driver          string // 默认 mysql
+	DSN             string // 配置后直接使用，忽略下面的连接参数
+	Host            string // host:port
+	DbName          string
+	Account         string
+	Password        string
+	SSLMode         string `mapstructure:"ssl_mode"` // postgres sslmode，默认 disable
+	Path            string // sqlite 数据库文件，:memory: 为内存数据库
+	MaxOpenConns    int    `mapstructure:"max_open_conns"`     // 最大打开连接数，0 表示不限制
+	MaxIdleConns    int    `mapstructure:"max_idle_conns"`     // 最大空闲连接数
+	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"`  // 连接最长存活时间（秒）
+	ConnMaxIdleTime int    `mapstructure:"conn_max_idle_time"` // 连接最长空闲时间（秒）
+}
+
+type JWTConfig struct {
+	Secret         string   // 未配置 keys 时使用的 HS256 密钥
+	Expires        int      // 访问令牌有效期（秒）
+	RefreshExpires int      `mapstructure:"refresh_expires"` // 刷新令牌有效期（秒）
+	Issuer         string   // 令牌签发者，配置后校验 iss
+	SigningKey     string   `mapstructure:"signing_key"` // 用于签发令牌的 key 的 kid
+	Keys           []JWTKey // 签名/验签密钥，轮换期间旧 key 只用于验签
+	SkipAuthUrls   []string `mapstructure:"skip_auth_urls"` // 免登录地址，按完整路径或分段前缀匹配，例如 /api/auth/login
+}
+
+// JWTKey 单个 JWT 密钥，HS256 使用 secret，RS256/EdDSA 使用 PEM 文件
+type JWTKey struct {
+	Kid            string
+	Algorithm      string
+	Secret         string
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+	VerifyUntil    string `mapstructure:"verify_until"` // RFC3339，超过该时间后不再接受此 key 签发的令牌
 }
 
 var (
@@ -75,10 +201,22 @@ func GetConfig() *AppConfig {
 	return cfg
 }
 
+// InitDB 按全局配置连接数据库，失败时直接退出
 func InitDB() *gorm.DB {
-	database := GetConfig().Database
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", database.Account, database.Password, database.Host, database.DbName)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := OpenDB(GetConfig().Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return db
+}
+
+// OpenDB 连接数据库并注册数据范围插件
+func OpenDB(database DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := database.Dialector()
+	if err != nil {
+		return nil, fmt.Errorf("数据库配置错误: %w", err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
 			SlowThreshold:             time.Second, // Slow SQL threshold
 			LogLevel:                  logger.Info, // Log level
@@ -88,14 +226,20 @@ func InitDB() *gorm.DB {
 		}),
 	})
 	if err != nil {
-		log.Fatalf("数据库连接失败: %v", err)
+		return nil, fmt.Errorf("数据库连接失败: %w", err)
 	}
-	// 自动建表
-	db.AutoMigrate(&model.User{})
-	db.AutoMigrate(&model.Role{})
-	db.AutoMigrate(&model.RoleResource{})
-	db.AutoMigrate(&model.Menu{})
-	db.AutoMigrate(&model.Config{})
-	db.AutoMigrate(&model.Log{})
-	return db
+	if err := database.configurePool(db); err != nil {
+		return nil, fmt.Errorf("数据库连接池配置失败: %w", err)
+	}
+	// 数据范围（行级权限）：按请求 context 中的数据范围过滤业务表
+	if err := db.Use(datascope.Plugin{}); err != nil {
+		return nil, fmt.Errorf("数据范围插件注册失败: %w", err)
+	}
+	// 链路追踪：请求 context 中有 span 时为每条 SQL 创建子 span
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("链路追踪插件注册失败: %w", err)
+	}
+	datascope.RegisterTable(model.HighSchoolAdmissionPlan{}.TableName(), "created_by")
+	datascope.RegisterTable(model.SchoolAdmissionInfo{}.TableName(), "created_by")
+	return db, nil
 }
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Dialector 根据 driver 构建 GORM 方言
+func (d DatabaseConfig) Dialector() (gorm.Dialector, error) {
+	dsn := d.DSN
+	switch d.DriverName() {
+	case DriverMySQL:
+		if dsn == "" {
+			dsn = fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", d.Account, d.Password, d.Host, d.DbName)
+		}
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		if dsn == "" {
+			host, port, err := net.SplitHostPort(d.Host)
+			if err != nil {
+				host, port = d.Host, "5432"
+			}
+			sslMode := d.SSLMode
+			if sslMode == "" {
+				sslMode = "disable"
+			}
+			dsn = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=Local",
+				host, port, d.Account, d.Password, d.DbName, sslMode)
+		}
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		if dsn == "" {
+			dsn = d.Path
+			if dsn == "" {
+				dsn = d.DbName + ".db"
+			}
+			// 并发写入时等待锁而不是直接报 database is locked
+			dsn += "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+		}
+		return sqlite.Open(dsn), nil
+	}
+	return nil, fmt.Errorf("不支持的数据库驱动: %s", d.Driver)
+}
+
+// DriverName 驱动名称，未配置时为 mysql
+func (d DatabaseConfig) DriverName() string {
+	driver := strings.ToLower(strings.TrimSpace(d.Driver))
+	switch driver {
+	case "":
+		return DriverMySQL
+	case "postgresql", "pgsql":
+		return DriverPostgres
+	case "sqlite3":
+		return DriverSQLite
+	}
+	return driver
+}
+
+// configurePool 设置连接池参数
+func (d DatabaseConfig) configurePool(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	maxOpen := d.MaxOpenConns
+	if d.DriverName() == DriverSQLite && d.Path == ":memory:" {
+		// 内存数据库每个连接都是独立的库，只能使用单个连接
+		maxOpen = 1
+	}
+	if maxOpen > 0 {
+		sqlDB.SetMaxOpenConns(maxOpen)
+	}
+	if d.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(d.MaxIdleConns)
+	}
+	if d.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(time.Duration(d.ConnMaxLifetime) * time.Second)
+	}
+	if d.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(time.Duration(d.ConnMaxIdleTime) * time.Second)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+// Package app 应用容器：集中创建配置、日志、数据库、仓储和服务，
+// 路由模块和命令行从容器中取依赖，不再各自创建或依赖包级全局变量
+package app
+
+import (
+	"context"
+	"fmt"
+	"template-backend/config"
+	"template-backend/internal/metrics"
+	"template-backend/internal/repository"
+	"template-backend/internal/service"
+	"template-backend/pkg/lifecycle"
+	"template-backend/pkg/logger"
+	"template-backend/pkg/token"
+	"template-backend/pkg/tracing"
+	"time"
+
+	"github.com/mojocn/base64Captcha"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	logStatsCacheTTL = 30 * time.Second
+)
+
+// App 一次运行所需的全部依赖
+type App struct {
+	Config   *config.AppConfig
+	Logger   *zap.Logger
+	DB       *gorm.DB
+	Tokens   token.Signer
+	Repos    *Repositories
+	Services *Services
+	Metrics  *metrics.Metrics
+	// Lifecycle 已注册链路追踪、数据库、请求日志写入、后台日志导出和日志清理，服务启动时再追加 HTTP 服务；
+	// 停止时逆序执行，保证请求日志在关闭数据库之前写完
+	Lifecycle *lifecycle.Manager
+}
+
+// Repositories 所有仓储，测试时可以替换其中的接口实现
+type Repositories struct {
+	User            repository.UserRepository
+	Role            repository.RoleRepository
+	Menu            repository.MenuRepository
+	Resource        repository.ResourceRepository
+	Token           repository.TokenRepository
+	Session         repository.SessionRepository
+	LoginSecurity   repository.LoginSecurityRepository
+	TwoFactor       repository.TwoFactorRepository
+	PasswordHistory repository.PasswordHistoryRepository
+	Config          repository.ConfigRepository
+	Log             repository.LogRepository
+	LogPurge        repository.LogPurgeRepository
+	LogStats        repository.LogStatsRepository
+	AdmissionPlan   repository.AdmissionPlanRepository
+	SchoolAdmission repository.SchoolAdmissionRepository
+}
+
+// Services 所有服务，按依赖顺序创建；字段均为接口，测试时可以替换单个服务
+type Services struct {
+	Token           service.TokenService
+	Session         service.SessionService
+	LoginGuard      service.LoginGuardService
+	TwoFactor       service.TwoFactorService
+	Password        service.PasswordService
+	Auth            service.AuthService
+	User            service.UserService
+	Role            service.RoleService
+	Menu            service.MenuService
+	Resource        service.ResourceService
+	Permission      service.PermissionService
+	APISync         service.APISyncService
+	Config          service.ConfigService
+	Log             service.LogService
+	LogWriter       service.LogWriter // 请求日志异步写入，由 Lifecycle 启动和停止
+	LogExport       service.LogExportService
+	LogRetention    service.LogRetentionService
+	LogStats        service.LogStatsService
+	AdmissionPlan   service.AdmissionPlanService
+	SchoolAdmission service.SchoolAdmissionService
+
+	// PermissionCache 修改角色、资源和用户角色的服务通过它使权限缓存和菜单缓存失效
+	PermissionCache *service.PermissionCache
+	// CaptchaStore 登录验证码存储
+	CaptchaStore base64Captcha.Store
+}
+
+// New 按配置创建 logger 和令牌密钥，并基于 db 组装容器；logger 同时设置为全局 logger
+func New(cfg *config.AppConfig, db *gorm.DB) (*App, error) {
+	log, err := logger.New(cfg.App.Env)
+	if err != nil {
+		return nil, fmt.Errorf("初始化日志失败: %w", err)
+	}
+	logger.Set(log)
+
+	tokens, err := token.NewManager(cfg.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("jwt 密钥初始化失败: %w", err)
+	}
+	return Build(cfg, log, db, tokens, NewRepositories(db)), nil
+}
+
+// NewRepositories 创建基于 db 的全部仓储
+func NewRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		User:            repository.NewUserRepository(db),
+		Role:            repository.NewRoleRepository(db),
+		Menu:            repository.NewMenuRepository(db),
+		Resource:        repository.NewResourceRepository(db),
+		Token:           repository.NewTokenRepository(db),
+		Session:         repository.NewSessionRepository(db),
+		LoginSecurity:   repository.NewLoginSecurityRepository(db),
+		TwoFactor:       repository.NewTwoFactorRepository(db),
+		PasswordHistory: repository.NewPasswordHistoryRepository(db),
+		Config:          repository.NewConfigRepository(db),
+		Log:             repository.NewLogRepository(db),
+		LogPurge:        repository.NewLogPurgeRepository(db),
+		LogStats:        repository.NewLogStatsRepository(db),
+		AdmissionPlan:   repository.NewAdmissionPlanRepo(db),
+		SchoolAdmission: repository.NewSchoolAdmissionRepository(db),
+	}
+}
+
+// Build 用给定的依赖组装容器，不产生任何副作用；测试中可以传入替换过的仓储和签名器，db 可以为 nil
+func Build(cfg *config.AppConfig, log *zap.Logger, db *gorm.DB, tokens token.Signer, repos *Repositories) *App {
+	a := &App{
+		Config:    cfg,
+		Logger:    log,
+		DB:        db,
+		Tokens:    tokens,
+		Repos:     repos,
+		Services:  NewServices(cfg, log, tokens, repos),
+		Metrics:   metrics.New(),
+		Lifecycle: lifecycle.New(seconds(cfg.Shutdown.Timeout)),
+	}
+	if err := a.Metrics.RegisterLogWriter(a.Services.LogWriter); err != nil {
+		log.Warn("register log writer metrics failed", zap.Error(err))
+	}
+	if db != nil {
+		if err := a.Metrics.RegisterDB(db, cfg.Database.DriverName()); err != nil {
+			log.Warn("register database metrics failed", zap.Error(err))
+		}
+	}
+
+	// 链路追踪最先启动、最后停止，停止时导出其他组件停止过程中产生的 span
+	var stopTracing func(context.Context) error
+	a.Lifecycle.Append(lifecycle.Hook{
+		Name: "tracing",
+		Start: func(ctx context.Context) error {
+			var err error
+			stopTracing, err = tracing.Setup(ctx, cfg.Tracing, cfg.App.Name)
+			return err
+		},
+		Stop: func(ctx context.Context) error {
+			return stopTracing(ctx)
+		},
+	})
+	a.Lifecycle.Append(lifecycle.Hook{
+		Name: "database",
+		Stop: func(context.Context) error {
+			if db == nil {
+				return nil
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.Close()
+		},
+	})
+	a.Lifecycle.Append(lifecycle.Hook{
+		Name: "token denylist",
+		Start: func(context.Context) error {
+			return a.Services.Token.Start()
+		},
+		Stop: a.Services.Token.Stop,
+	})
+	a.Lifecycle.Append(lifecycle.Hook{
+		Name: "log writer",
+		Start: func(context.Context) error {
+			return a.Services.LogWriter.Start()
+		},
+		Stop:        a.Services.LogWriter.Stop,
+		StopTimeout: seconds(cfg.Shutdown.LogFlushTimeout),
+	})
+	a.Lifecycle.Append(lifecycle.Hook{
+		Name: "log export",
+		Start: func(context.Context) error {
+			a.Services.LogExport.Start()
+			return nil
+		},
+		Stop: a.Services.LogExport.Stop,
+	})
+	a.Lifecycle.Append(lifecycle.Hook{
+		Name: "log retention",
+		Start: func(context.Context) error {
+			return a.Services.LogRetention.Start()
+		},
+		Stop: a.Services.LogRetention.Stop,
+	})
+	return a
+}
+
+// NewServices 按依赖顺序创建全部服务
+func NewServices(cfg *config.AppConfig, log *zap.Logger, tokens token.Signer, repos *Repositories) *Services {
+	s := &Services{
+		PermissionCache: service.NewPermissionCache(),
+		CaptchaStore:    base64Captcha.NewMemoryStore(base64Captcha.GCLimitNumber, base64Captcha.Expiration),
+	}
+	s.Token = service.NewTokenService(cfg, tokens, repos.Token, repos.User, log)
+	s.Session = service.NewSessionService(repos.Session, s.Token)
+	s.LoginGuard = service.NewLoginGuardService(cfg, repos.LoginSecurity, s.CaptchaStore)
+	s.TwoFactor = service.NewTwoFactorService(cfg, repos.TwoFactor)
+	s.Password = service.NewPasswordService(cfg, repos.User, repos.PasswordHistory)
+	s.Auth = service.NewAuthService(repos.User, repos.Role, s.Token, s.Session, s.LoginGuard, s.TwoFactor, s.Password)
+	s.User = service.NewUserService(repos.User, s.Session, s.TwoFactor, s.Password, s.PermissionCache)
+	s.Role = service.NewRoleService(repos.Role, s.PermissionCache)
+	s.Menu = service.NewMenuService(repos.Resource, repos.User, repos.Role, cfg.RBAC.SuperRoles, s.PermissionCache)
+	s.Resource = service.NewResourceService(repos.Resource, s.PermissionCache)
+	s.Permission = service.NewPermissionService(repos.Resource, repos.Role, repos.User, cfg.RBAC.SuperRoles,
+		time.Duration(cfg.RBAC.CacheTTL)*time.Second, s.PermissionCache)
+	s.APISync = service.NewAPISyncService(repos.Resource, cfg.JWT.SkipAuthUrls, s.PermissionCache)
+	s.Config = service.NewConfigService(repos.Config)
+	s.Log = service.NewLogService(repos.Log)
+	s.LogWriter = service.NewLogWriter(repos.Log, cfg.LogSinks, log)
+	s.LogExport = service.NewLogExportService(repos.Log, cfg.LogExport.Dir, cfg.LogExport.SyncLimit,
+		seconds(cfg.LogExport.FileTTL), cfg.LogExport.MaxJobs, log)
+	s.LogRetention = service.NewLogRetentionService(cfg, repos.Log, repos.LogPurge, log)
+	s.LogStats = service.NewLogStatsService(repos.LogStats, logStatsCacheTTL)
+	s.AdmissionPlan = service.NewAdmissionPlanService(repos.AdmissionPlan)
+	s.SchoolAdmission = service.NewSchoolAdmissionService(repos.SchoolAdmission)
+	return s
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// WithDB 基于 db（通常是事务）重新组装仓储和服务，配置、logger 和密钥沿用当前容器
+func (a *App) WithDB(db *gorm.DB) *App {
+	return Build(a.Config, a.Logger, db, a.Tokens, NewRepositories(db))
+}
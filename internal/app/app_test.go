@@ -0,0 +1,112 @@
+package app_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"template-backend/config"
+	"template-backend/internal/app"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/internal/router"
+	"template-backend/internal/service"
+	"template-backend/pkg/token"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	// 路由模块在 init 中注册
+	_ "template-backend/internal/handler"
+)
+
+// fakeUserRepository 只实现用例用到的方法，其余方法调用时 panic
+type fakeUserRepository struct {
+	repository.UserRepository
+	users []model.User
+}
+
+func (r *fakeUserRepository) GetList(context.Context, int, int, map[string]interface{}) ([]model.User, int64, error) {
+	return r.users, int64(len(r.users)), nil
+}
+
+// fakeSigner 不加载密钥，JWKS 返回固定的公钥
+type fakeSigner struct{}
+
+func (fakeSigner) Sign(jwt.MapClaims) (string, error) { return "signed", nil }
+
+func (fakeSigner) Parse(string) (jwt.MapClaims, error) { return nil, token.ErrInvalidToken }
+
+func (fakeSigner) JWKS() token.JWKSet {
+	return token.JWKSet{Keys: []token.JWK{{Kty: "OKP", Kid: "test", Use: "sig", Alg: token.AlgEdDSA}}}
+}
+
+// fakeRoleService 替换容器中的角色服务，只实现列表查询
+type fakeRoleService struct {
+	service.RoleService
+	roles []model.Role
+}
+
+func (s fakeRoleService) GetList(context.Context, int, int, map[string]interface{}) ([]model.Role, int64, error) {
+	return s.roles, int64(len(s.roles)), nil
+}
+
+func newTestApp(repos *app.Repositories) (*app.App, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+	a := app.Build(&config.AppConfig{}, zap.NewNop(), nil, fakeSigner{}, repos)
+	return a, gin.New()
+}
+
+func serve(t *testing.T, r *gin.Engine, method, path string, out interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s %s status = %d, body = %s", method, path, w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+		t.Fatalf("%s %s decode: %v", method, path, err)
+	}
+}
+
+func TestBuildWithFakeRepositories(t *testing.T) {
+	users := &fakeUserRepository{users: []model.User{{ID: 7, Username: "alice"}}}
+	a, r := newTestApp(&app.Repositories{User: users})
+	router.RegisterRoutes(r, a)
+
+	var list struct {
+		Data struct {
+			List  []model.User `json:"list"`
+			Total int64        `json:"total"`
+		} `json:"data"`
+	}
+	serve(t, r, http.MethodGet, "/api/users", &list)
+	if list.Data.Total != 1 || len(list.Data.List) != 1 || list.Data.List[0].Username != "alice" {
+		t.Fatalf("users = %+v", list.Data)
+	}
+
+	var jwks token.JWKSet
+	serve(t, r, http.MethodGet, "/api/auth/jwks", &jwks)
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != "test" {
+		t.Fatalf("jwks = %+v", jwks)
+	}
+}
+
+func TestBuildWithReplacedService(t *testing.T) {
+	a, r := newTestApp(&app.Repositories{})
+	// 路由注册时才从容器取服务，注册前替换即可
+	a.Services.Role = fakeRoleService{roles: []model.Role{{ID: 1, RoleCode: "auditor"}}}
+	router.RegisterRoutes(r, a)
+
+	var list struct {
+		Data struct {
+			List []model.Role `json:"list"`
+		} `json:"data"`
+	}
+	serve(t, r, http.MethodGet, "/api/roles", &list)
+	if len(list.Data.List) != 1 || list.Data.List[0].RoleCode != "auditor" {
+		t.Fatalf("roles = %+v", list.Data)
+	}
+}
@@ -0,0 +1,21 @@
+package dto
+
+// LogFilter 日志列表和导出共用的筛选条件，Timestamp 为东八区 "2006-01-02 15:04:05" 格式的起止时间
+type LogFilter struct {
+	Method    string   `json:"method"`
+	Path      string   `json:"path"`
+	Status    int      `json:"status"`
+	IP        string   `json:"ip"`
+	Handler   string   `json:"handler"`
+	TraceID   string   `json:"traceId"`
+	RequestID string   `json:"requestId"`
+	Timestamp []string `json:"timestamp"`
+}
+
+// LogExportRequest 导出日志请求
+type LogExportRequest struct {
+	LogFilter
+	Format  string   `json:"format"`  // csv | xlsx | ndjson，默认 csv
+	Columns []string `json:"columns"` // 导出的列，取值与日志列表的字段名一致，为空时导出常用列
+	Async   bool     `json:"async"`   // 不论行数多少都转为后台任务
+}
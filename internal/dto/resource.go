@@ -1,34 +1,47 @@
 package dto
 
-import "time"
+import (
+	"template-backend/internal/model"
+	"time"
+)
 
 type CreateResourceRequest struct {
-	ResourceName   string  `json:"resource_name" binding:"required" validate:"max=50"`
-	PermissionCode string  `json:"permission_code" binding:"required" validate:"max=100"`
-	Desc           *string `json:"desc" validate:"max=200"`
-	Type           string  `json:"type" binding:"required" validate:"max=20"`
-	ResourcePath   *string `json:"resource_path" validate:"max=500"`
-	HTTPMethod     *string `json:"http_method" validate:"max=10"`
-	ParentID       *int64  `json:"parent_id"`
-	Sort           int     `json:"sort"`
-	RequiresAuth   int8    `json:"requires_auth"`
-	Remark         *string `json:"remark" validate:"max=500"`
-	CreatedBy      *int64  `json:"created_by"`
+	ResourceName   string      `json:"resource_name" binding:"required" validate:"max=50"`
+	PermissionCode string      `json:"permission_code" binding:"required" validate:"max=100"`
+	Desc           *string     `json:"desc" validate:"max=200"`
+	Type           string      `json:"type" binding:"required" validate:"max=20"`
+	ResourcePath   *string     `json:"resource_path" validate:"max=500"`
+	HTTPMethod     *string     `json:"http_method" validate:"max=10"`
+	ParentID       *int64      `json:"parent_id"`
+	Sort           int         `json:"sort"`
+	RequiresAuth   int8        `json:"requires_auth"`
+	Remark         *string     `json:"remark" validate:"max=500"`
+	Name           string      `json:"name" validate:"max=100"`
+	Component      *string     `json:"component" validate:"max=255"`
+	Redirect       string      `json:"redirect" validate:"max=255"`
+	Visible        *bool       `json:"visible"`
+	Meta           *model.Meta `json:"meta"`
+	CreatedBy      *int64      `json:"created_by"`
 }
 
 type UpdateResourceRequest struct {
-	ResourceName   *string `json:"resource_name" validate:"max=50"`
-	PermissionCode *string `json:"permission_code" validate:"max=100"`
-	Desc           *string `json:"desc" validate:"max=200"`
-	Type           *string `json:"type" validate:"max=20"`
-	ResourcePath   *string `json:"resource_path" validate:"max=500"`
-	HTTPMethod     *string `json:"http_method" validate:"max=10"`
-	ParentID       *int64  `json:"parent_id"`
-	Sort           *int    `json:"sort"`
-	Status         *int8   `json:"status"`
-	RequiresAuth   *int8   `json:"requires_auth"`
-	Remark         *string `json:"remark" validate:"max=500"`
-	UpdatedBy      *int64  `json:"updated_by"`
+	ResourceName   *string     `json:"resource_name" validate:"max=50"`
+	PermissionCode *string     `json:"permission_code" validate:"max=100"`
+	Desc           *string     `json:"desc" validate:"max=200"`
+	Type           *string     `json:"type" validate:"max=20"`
+	ResourcePath   *string     `json:"resource_path" validate:"max=500"`
+	HTTPMethod     *string     `json:"http_method" validate:"max=10"`
+	ParentID       *int64      `json:"parent_id"`
+	Sort           *int        `json:"sort"`
+	Status         *int8       `json:"status"`
+	RequiresAuth   *int8       `json:"requires_auth"`
+	Remark         *string     `json:"remark" validate:"max=500"`
+	Name           *string     `json:"name" validate:"max=100"`
+	Component      *string     `json:"component" validate:"max=255"`
+	Redirect       *string     `json:"redirect" validate:"max=255"`
+	Visible        *bool       `json:"visible"`
+	Meta           *model.Meta `json:"meta"`
+	UpdatedBy      *int64      `json:"updated_by"`
 }
 
 type ResourceQueryRequest struct {
@@ -58,6 +71,11 @@ type ResourceResponse struct {
 	Status         int8               `json:"status"`
 	RequiresAuth   int8               `json:"requires_auth"`
 	Remark         *string            `json:"remark"`
+	Name           string             `json:"name"`
+	Component      *string            `json:"component"`
+	Redirect       string             `json:"redirect"`
+	Visible        *bool              `json:"visible"`
+	Meta           *model.Meta        `json:"meta"`
 	CreatedBy      *int64             `json:"created_by"`
 	CreatedAt      time.Time          `json:"created_at"`
 	UpdatedBy      *int64             `json:"updated_by"`
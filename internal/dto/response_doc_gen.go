@@ -219,30 +219,6 @@ type MetaPageResponseDoc struct {
 	Data    MetaPageData `json:"data"`
 }
 
-// PermissionResponseDoc 用于 Swagger 文档展示 (单对象返回)
-type PermissionResponseDoc struct {
-	Code    int              `json:"code"`
-	Msg     string           `json:"msg"`
-	Success bool             `json:"success"`
-	Data    model.Permission `json:"data"`
-}
-
-// PermissionPageData 用于 Swagger 文档展示 (分页数据)
-type PermissionPageData struct {
-	List     []model.Permission `json:"list"`
-	Total    int64              `json:"total"`
-	Page     int                `json:"page"`
-	PageSize int                `json:"pageSize"`
-}
-
-// PermissionPageResponseDoc 用于 Swagger 文档展示 (分页返回)
-type PermissionPageResponseDoc struct {
-	Code    int                `json:"code"`
-	Msg     string             `json:"msg"`
-	Success bool               `json:"success"`
-	Data    PermissionPageData `json:"data"`
-}
-
 // ResourceResponseDoc 用于 Swagger 文档展示 (单对象返回)
 type ResourceResponseDoc struct {
 	Code    int            `json:"code"`
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
-	"template-backend/internal/repository"
+	"template-backend/internal/app"
 	"template-backend/internal/router"
+	"template-backend/pkg/datascope"
 	"template-backend/pkg/utils"
 
 	"gorm.io/gorm"
@@ -26,10 +28,10 @@ type ListRequest struct {
 }
 
 type AdmissionPlanHandler struct {
-	service *service.AdmissionPlanService
+	service service.AdmissionPlanService
 }
 
-func NewAdmissionPlanHandler(svc *service.AdmissionPlanService) *AdmissionPlanHandler {
+func NewAdmissionPlanHandler(svc service.AdmissionPlanService) *AdmissionPlanHandler {
 	return &AdmissionPlanHandler{service: svc}
 }
 
@@ -49,7 +51,7 @@ func NewAdmissionPlanHandler(svc *service.AdmissionPlanService) *AdmissionPlanHa
 func (h *AdmissionPlanHandler) List(c *gin.Context) {
 	var req ListRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		logger.Logger().Error("List 参数绑定失败", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("List 参数绑定失败", zap.Error(err))
 		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
@@ -62,7 +64,7 @@ func (h *AdmissionPlanHandler) List(c *gin.Context) {
 		req.PageSize = 10
 	}
 
-	logger.Logger().Info("List 入参", zap.Any("request", req))
+	logger.FromContext(c.Request.Context()).Info("List 入参", zap.Any("request", req))
 
 	filters := make(map[string]interface{})
 	if req.SchoolName != "" {
@@ -75,9 +77,9 @@ func (h *AdmissionPlanHandler) List(c *gin.Context) {
 		filters["district_type"] = req.DistrictType
 	}
 
-	plans, total, err := h.service.List(req.Page, req.PageSize, filters)
+	plans, total, err := h.service.List(c.Request.Context(), req.Page, req.PageSize, filters)
 	if err != nil {
-		logger.Logger().Error("List 查询失败", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("List 查询失败", zap.Error(err))
 		utils.JSON(c, utils.Error("查询失败", http.StatusInternalServerError))
 		return
 	}
@@ -87,7 +89,7 @@ func (h *AdmissionPlanHandler) List(c *gin.Context) {
 		Page:     req.Page,
 		PageSize: req.PageSize,
 	}
-	logger.Logger().Info("List 查询成功", zap.Int64("total", total))
+	logger.FromContext(c.Request.Context()).Info("List 查询成功", zap.Int64("total", total))
 	utils.JSON(c, utils.Success(result))
 }
 
@@ -103,21 +105,21 @@ func (h *AdmissionPlanHandler) List(c *gin.Context) {
 func (h *AdmissionPlanHandler) GetByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		logger.Logger().Error("GetByID ID参数错误", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("GetByID ID参数错误", zap.Error(err))
 		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
-	logger.Logger().Info("GetByID 入参", zap.Int("id", id))
+	logger.FromContext(c.Request.Context()).Info("GetByID 入参", zap.Int("id", id))
 
-	plan, err := h.service.GetByID(id)
+	plan, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
-		logger.Logger().Error("GetByID 查询失败", zap.Error(err), zap.Int("id", id))
+		logger.FromContext(c.Request.Context()).Error("GetByID 查询失败", zap.Error(err), zap.Int("id", id))
 		utils.JSON(c, utils.Error("未找到数据", http.StatusNotFound))
 		return
 	}
 
-	logger.Logger().Info("GetByID 查询成功", zap.Int("id", id))
+	logger.FromContext(c.Request.Context()).Info("GetByID 查询成功", zap.Int("id", id))
 	utils.JSON(c, utils.Success(plan))
 }
 
@@ -133,20 +135,24 @@ func (h *AdmissionPlanHandler) GetByID(c *gin.Context) {
 func (h *AdmissionPlanHandler) Create(c *gin.Context) {
 	var plan model.HighSchoolAdmissionPlan
 	if err := c.ShouldBindJSON(&plan); err != nil {
-		logger.Logger().Error("Create 参数绑定失败", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Create 参数绑定失败", zap.Error(err))
 		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
-	logger.Logger().Info("Create 入参", zap.Any("plan", plan))
+	logger.FromContext(c.Request.Context()).Info("Create 入参", zap.Any("plan", plan))
 
-	if err := h.service.Create(&plan); err != nil {
-		logger.Logger().Error("Create 创建失败", zap.Error(err), zap.Any("plan", plan))
+	if err := h.service.Create(c.Request.Context(), &plan); err != nil {
+		logger.FromContext(c.Request.Context()).Error("Create 创建失败", zap.Error(err), zap.Any("plan", plan))
+		if errors.Is(err, datascope.ErrOutOfScope) {
+			utils.JSON(c, utils.Error(err.Error(), http.StatusForbidden))
+			return
+		}
 		utils.JSON(c, utils.Error("创建失败", http.StatusInternalServerError))
 		return
 	}
 
-	logger.Logger().Info("Create 创建成功", zap.Int("id", plan.ID))
+	logger.FromContext(c.Request.Context()).Info("Create 创建成功", zap.Int("id", plan.ID))
 	utils.JSON(c, utils.Success(plan))
 }
 
@@ -163,27 +169,34 @@ func (h *AdmissionPlanHandler) Create(c *gin.Context) {
 func (h *AdmissionPlanHandler) Update(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		logger.Logger().Error("Update ID参数错误", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Update ID参数错误", zap.Error(err))
 		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
 	var plan model.HighSchoolAdmissionPlan
 	if err := c.ShouldBindJSON(&plan); err != nil {
-		logger.Logger().Error("Update 参数绑定失败", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Update 参数绑定失败", zap.Error(err))
 		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
-	logger.Logger().Info("Update 入参", zap.Int("id", id), zap.Any("plan", plan))
+	logger.FromContext(c.Request.Context()).Info("Update 入参", zap.Int("id", id), zap.Any("plan", plan))
 
-	if err := h.service.Update(id, &plan); err != nil {
-		logger.Logger().Error("Update 更新失败", zap.Error(err), zap.Int("id", id))
-		utils.JSON(c, utils.Error("更新失败", http.StatusInternalServerError))
+	if err := h.service.Update(c.Request.Context(), id, &plan); err != nil {
+		logger.FromContext(c.Request.Context()).Error("Update 更新失败", zap.Error(err), zap.Int("id", id))
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.JSON(c, utils.Error("未找到数据", http.StatusNotFound))
+		case errors.Is(err, datascope.ErrOutOfScope):
+			utils.JSON(c, utils.Error(err.Error(), http.StatusForbidden))
+		default:
+			utils.JSON(c, utils.Error("更新失败", http.StatusInternalServerError))
+		}
 		return
 	}
 
-	logger.Logger().Info("Update 更新成功", zap.Int("id", id))
+	logger.FromContext(c.Request.Context()).Info("Update 更新成功", zap.Int("id", id))
 	utils.JSON(c, utils.Success(plan))
 }
 
@@ -199,20 +212,20 @@ func (h *AdmissionPlanHandler) Update(c *gin.Context) {
 func (h *AdmissionPlanHandler) Delete(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		logger.Logger().Error("Delete ID参数错误", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Delete ID参数错误", zap.Error(err))
 		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
-	logger.Logger().Info("Delete 入参", zap.Int("id", id))
+	logger.FromContext(c.Request.Context()).Info("Delete 入参", zap.Int("id", id))
 
-	if err := h.service.Delete(id); err != nil {
-		logger.Logger().Error("Delete 删除失败", zap.Error(err), zap.Int("id", id))
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		logger.FromContext(c.Request.Context()).Error("Delete 删除失败", zap.Error(err), zap.Int("id", id))
 		utils.JSON(c, utils.Error("删除失败", http.StatusInternalServerError))
 		return
 	}
 
-	logger.Logger().Info("Delete 删除成功", zap.Int("id", id))
+	logger.FromContext(c.Request.Context()).Info("Delete 删除成功", zap.Int("id", id))
 	utils.JSON(c, utils.Success(""))
 }
 
@@ -221,8 +234,8 @@ func init() {
 	router.RegisterRouteModule(&AdmissionPlanHandler{})
 }
 
-func (h *AdmissionPlanHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
-	h.service = service.NewAdmissionPlanService(repository.NewAdmissionPlanRepo(db))
+func (h *AdmissionPlanHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.service = a.Services.AdmissionPlan
 	api := rg.Group("/plans")
 	{
 		api.GET("", h.List)
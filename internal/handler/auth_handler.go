@@ -2,38 +2,57 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 	"net/http"
-	"template-backend/internal/repository"
+	"strconv"
+	"template-backend/internal/app"
+	"template-backend/internal/model"
 	"template-backend/internal/router"
 	"template-backend/internal/service"
 	"template-backend/pkg/utils"
 )
 
 type AuthHandler struct {
-	authService *service.AuthService
+	authService    service.AuthService
+	tokenService   service.TokenService
+	sessionService service.SessionService
 }
 
-func NewAuthHandler(authService *service.AuthService) *AuthHandler {
+func NewAuthHandler(authService service.AuthService) *AuthHandler {
 	return &AuthHandler{authService: authService}
 }
 
 // Login 用户登录
 func (h *AuthHandler) Login(c *gin.Context) {
-	var req struct {
-		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required"`
+	var req model.LoginForm
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+	resp, err := h.authService.Login(c.Request.Context(), &req, clientInfo(c, req.Device))
+	if err != nil {
+		var lockedErr *service.LoginLockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			c.Header("Retry-After", strconv.Itoa(int(lockedErr.RetryAfter.Seconds())))
+			utils.JSON(c, utils.Error(err.Error(), http.StatusTooManyRequests))
+		case errors.Is(err, service.ErrCaptchaRequired), errors.Is(err, service.ErrCaptchaInvalid):
+			utils.JSON(c, &utils.ApiResponse[gin.H]{Message: err.Error(), Code: http.StatusUnauthorized, Data: gin.H{"needCaptcha": true}})
+		default:
+			utils.JSON(c, utils.Error(err.Error(), http.StatusUnauthorized))
+		}
 		return
 	}
+	utils.JSON(c, utils.Success(resp))
+}
 
-	resp, err := h.authService.Login(req.Username, req.Password)
+// Captcha 获取登录验证码
+func (h *AuthHandler) Captcha(c *gin.Context) {
+	resp, err := h.authService.GenerateCaptcha()
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": err.Error()})
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 	utils.JSON(c, utils.Success(resp))
@@ -44,39 +63,38 @@ func (h *AuthHandler) GetUserInfo(c *gin.Context) {
 	// 从token中获取用户ID
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "未授权"})
+		utils.JSON(c, utils.Error("未授权", http.StatusUnauthorized))
 		return
 	}
 
-	userInfo, err := h.authService.GetUserInfo(userID.(uint))
+	userInfo, err := h.authService.GetUserInfo(c.Request.Context(), userID.(uint))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 
 	utils.JSON(c, utils.Success(userInfo))
 }
 
-// RefreshToken 刷新token
+// RefreshToken 使用刷新令牌换取新的令牌对
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var req struct {
-		Token string `json:"token" binding:"required"`
+		RefreshToken string `json:"refreshToken" binding:"required"`
+		Device       string `json:"device"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
-	newToken, err := h.authService.RefreshToken(req.Token)
+	resp, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken, clientInfo(c, req.Device))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": err.Error()})
+		utils.JSON(c, utils.Error(err.Error(), http.StatusUnauthorized))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"code": 200, "data": gin.H{"token": newToken}})
-
-	utils.JSON(c, utils.Success(gin.H{"token": newToken}))
+	utils.JSON(c, utils.Success(resp))
 }
 
 // ChangePassword 修改密码
@@ -84,46 +102,236 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	// 从token中获取用户ID
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "未授权"})
+		utils.JSON(c, utils.Error("未授权", http.StatusUnauthorized))
 		return
 	}
 
 	var req struct {
 		OldPassword string `json:"oldPassword" binding:"required"`
-		NewPassword string `json:"newPassword" binding:"required,min=6"`
+		NewPassword string `json:"newPassword" binding:"required"` // 复杂度由密码策略校验
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
-	err := h.authService.ChangePassword(userID.(uint), req.OldPassword, req.NewPassword)
+	err := h.authService.ChangePassword(c.Request.Context(), userID.(uint), req.OldPassword, req.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
 		return
 	}
 
 	utils.JSON(c, utils.Success(gin.H{"ok": true}))
 }
 
-// Logout 登出（演示：客户端删除 token 即可；这里返回成功）
+// Logout 登出，吊销当前访问令牌及同一次登录的刷新令牌
 func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.JSON(c, utils.Error("未授权", http.StatusUnauthorized))
+		return
+	}
+
+	err := h.authService.Logout(c.Request.Context(), userID.(uint), c.GetString("jti"), c.GetTime("tokenExpiresAt"), c.GetString("familyID"))
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
+		return
+	}
 	utils.JSON(c, utils.Success(gin.H{"ok": true}))
 }
 
+// GetSessions 获取当前用户的登录会话
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.JSON(c, utils.Error("未授权", http.StatusUnauthorized))
+		return
+	}
+
+	sessions, err := h.sessionService.ListUserSessions(c.Request.Context(), userID.(uint), c.GetString("familyID"))
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
+		return
+	}
+	utils.JSON(c, utils.Success(sessions))
+}
+
+// DeleteSession 结束当前用户的指定会话
+func (h *AuthHandler) DeleteSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.JSON(c, utils.Error("未授权", http.StatusUnauthorized))
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(c.Request.Context(), userID.(uint), c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			utils.JSON(c, utils.Error(err.Error(), http.StatusNotFound))
+			return
+		}
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
+		return
+	}
+	utils.JSON(c, utils.Success(gin.H{"ok": true}))
+}
+
+// TwoFactorLogin 提交两步验证码完成登录
+func (h *AuthHandler) TwoFactorLogin(c *gin.Context) {
+	var req model.TwoFactorLoginForm
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
+		return
+	}
+
+	resp, err := h.authService.VerifyTwoFactor(c.Request.Context(), &req, clientInfo(c, req.Device))
+	if err != nil {
+		var lockedErr *service.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			c.Header("Retry-After", strconv.Itoa(int(lockedErr.RetryAfter.Seconds())))
+			utils.JSON(c, utils.Error(err.Error(), http.StatusTooManyRequests))
+			return
+		}
+		utils.JSON(c, utils.Error(err.Error(), http.StatusUnauthorized))
+		return
+	}
+	utils.JSON(c, utils.Success(resp))
+}
+
+// TwoFactorLoginSetup 登录过程中为强制启用两步验证的用户生成认证器密钥
+func (h *AuthHandler) TwoFactorLoginSetup(c *gin.Context) {
+	var req struct {
+		ChallengeToken string `json:"challengeToken" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
+		return
+	}
+
+	setup, err := h.authService.SetupTwoFactorByChallenge(c.Request.Context(), req.ChallengeToken)
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusUnauthorized))
+		return
+	}
+	utils.JSON(c, utils.Success(setup))
+}
+
+// TwoFactorSetup 开始绑定认证器
+func (h *AuthHandler) TwoFactorSetup(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.JSON(c, utils.Error("未授权", http.StatusUnauthorized))
+		return
+	}
+
+	setup, err := h.authService.SetupTwoFactor(c.Request.Context(), userID.(uint))
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
+		return
+	}
+	utils.JSON(c, utils.Success(setup))
+}
+
+// TwoFactorEnable 校验验证码后启用两步验证，返回的恢复码只展示一次
+func (h *AuthHandler) TwoFactorEnable(c *gin.Context) {
+	userID, code, ok := twoFactorCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.authService.EnableTwoFactor(c.Request.Context(), userID, code)
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
+		return
+	}
+	utils.JSON(c, utils.Success(gin.H{"recoveryCodes": codes}))
+}
+
+// TwoFactorDisable 校验验证码后关闭两步验证
+func (h *AuthHandler) TwoFactorDisable(c *gin.Context) {
+	userID, code, ok := twoFactorCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(c.Request.Context(), userID, code); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
+		return
+	}
+	utils.JSON(c, utils.Success(gin.H{"ok": true}))
+}
+
+// TwoFactorRecoveryCodes 重新生成恢复码
+func (h *AuthHandler) TwoFactorRecoveryCodes(c *gin.Context) {
+	userID, code, ok := twoFactorCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), userID, code)
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
+		return
+	}
+	utils.JSON(c, utils.Success(gin.H{"recoveryCodes": codes}))
+}
+
+// twoFactorCodeRequest 读取当前用户和请求中的验证码，失败时已写入响应
+func twoFactorCodeRequest(c *gin.Context) (uint, string, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.JSON(c, utils.Error("未授权", http.StatusUnauthorized))
+		return 0, "", false
+	}
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
+		return 0, "", false
+	}
+	return userID.(uint), req.Code, true
+}
+
+// JWKS 公开当前验签公钥，供其他服务校验本服务签发的令牌
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, h.tokenService.JWKS())
+}
+
+// clientInfo 从请求中提取客户端信息，用于记录令牌的签发来源
+func clientInfo(c *gin.Context, device string) service.ClientInfo {
+	return service.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Device:    device,
+	}
+}
+
 func init() {
 	// 自动注册路由模块（通过 init 自动调用）
 	router.RegisterRouteModule(&AuthHandler{})
 }
 
-func (h *AuthHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
-	h.authService = service.NewAuthService(repository.NewUserRepository(db), repository.NewRoleRepository(db))
+func (h *AuthHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.tokenService = a.Services.Token
+	h.sessionService = a.Services.Session
+	h.authService = a.Services.Auth
 	auth := rg.Group("/auth")
 	auth.POST("/login", h.Login)
+	auth.GET("/captcha", h.Captcha)
 	// 需要鉴权的接口使用 JWT 中间件
 	auth.POST("/logout", h.Logout)
 	auth.GET("/user", h.GetUserInfo)
 	auth.POST("/refresh", h.RefreshToken)
 	auth.POST("/change-password", h.ChangePassword)
+	auth.GET("/jwks", h.JWKS)
+	auth.GET("/sessions", h.GetSessions)
+	auth.DELETE("/sessions/:id", h.DeleteSession)
+	// 两步验证
+	auth.POST("/2fa/login", h.TwoFactorLogin)
+	auth.POST("/2fa/login/setup", h.TwoFactorLoginSetup)
+	auth.POST("/2fa/setup", h.TwoFactorSetup)
+	auth.POST("/2fa/enable", h.TwoFactorEnable)
+	auth.POST("/2fa/disable", h.TwoFactorDisable)
+	auth.POST("/2fa/recovery-codes", h.TwoFactorRecoveryCodes)
 }
@@ -3,12 +3,11 @@ package handler
 import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 	"net/http"
 	"strconv"
+	"template-backend/internal/app"
 	"template-backend/internal/middleware"
 	"template-backend/internal/model"
-	"template-backend/internal/repository"
 	"template-backend/internal/router"
 	"template-backend/internal/service"
 	"template-backend/pkg/logger"
@@ -40,15 +39,15 @@ func (h *ConfigHandler) GetConfigList(c *gin.Context) {
 		"pageSize":   pageSize,
 	}
 
-	logger.Logger().Info("GetConfigList 入参",
+	logger.FromContext(c.Request.Context()).Info("GetConfigList 入参",
 		zap.String("configKey", configKey),
 		zap.String("configName", configName),
 		zap.Int("pageNum", pageNum),
 		zap.Int("pageSize", pageSize),
 	)
-	configs, total, err := h.service.GetList(params)
+	configs, total, err := h.service.GetList(c.Request.Context(), params)
 	if err != nil {
-		logger.Logger().Error("GetConfigList 失败", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("GetConfigList 失败", zap.Error(err))
 		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
@@ -58,7 +57,7 @@ func (h *ConfigHandler) GetConfigList(c *gin.Context) {
 		Page:     pageNum,
 		PageSize: pageSize,
 	}
-	logger.Logger().Info("GetConfigList 出参", zap.Any("data", data))
+	logger.FromContext(c.Request.Context()).Info("GetConfigList 出参", zap.Any("data", data))
 	utils.JSON(c, utils.Success(data))
 }
 
@@ -67,16 +66,16 @@ func (h *ConfigHandler) GetConfigById(c *gin.Context) {
 	idStr := c.Param("id")
 	id, _ := strconv.ParseInt(idStr, 10, 64)
 
-	logger.Logger().Info("GetConfigById 入参", zap.Int64("id", id))
+	logger.FromContext(c.Request.Context()).Info("GetConfigById 入参", zap.Int64("id", id))
 
-	config, err := h.service.GetByID(id)
+	config, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
-		logger.Logger().Error("GetConfigById 失败", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("GetConfigById 失败", zap.Error(err))
 		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 
-	logger.Logger().Info("GetConfigById 出参", zap.Any("data", config))
+	logger.FromContext(c.Request.Context()).Info("GetConfigById 出参", zap.Any("data", config))
 	utils.JSON(c, utils.Success(config))
 }
 
@@ -88,15 +87,15 @@ func (h *ConfigHandler) AddConfig(c *gin.Context) {
 		return
 	}
 
-	logger.Logger().Info("AddConfig 入参", zap.Any("config", config))
+	logger.FromContext(c.Request.Context()).Info("AddConfig 入参", zap.Any("config", config))
 
-	if err := h.service.Create(&config); err != nil {
-		logger.Logger().Error("AddConfig 失败", zap.Error(err))
+	if err := h.service.Create(c.Request.Context(), &config); err != nil {
+		logger.FromContext(c.Request.Context()).Error("AddConfig 失败", zap.Error(err))
 		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 
-	logger.Logger().Info("AddConfig 出参", zap.Any("data", config))
+	logger.FromContext(c.Request.Context()).Info("AddConfig 出参", zap.Any("data", config))
 	utils.JSON(c, utils.Success(config))
 }
 
@@ -108,15 +107,15 @@ func (h *ConfigHandler) UpdateConfig(c *gin.Context) {
 		return
 	}
 
-	logger.Logger().Info("UpdateConfig 入参", zap.Any("config", config))
+	logger.FromContext(c.Request.Context()).Info("UpdateConfig 入参", zap.Any("config", config))
 
-	if err := h.service.Update(&config); err != nil {
-		logger.Logger().Error("UpdateConfig 失败", zap.Error(err))
+	if err := h.service.Update(c.Request.Context(), &config); err != nil {
+		logger.FromContext(c.Request.Context()).Error("UpdateConfig 失败", zap.Error(err))
 		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 
-	logger.Logger().Info("UpdateConfig 出参", zap.Any("data", config))
+	logger.FromContext(c.Request.Context()).Info("UpdateConfig 出参", zap.Any("data", config))
 	utils.JSON(c, utils.Success(config))
 }
 
@@ -125,15 +124,15 @@ func (h *ConfigHandler) DeleteConfig(c *gin.Context) {
 	idStr := c.Param("id")
 	id, _ := strconv.ParseInt(idStr, 10, 64)
 
-	logger.Logger().Info("DeleteConfig 入参", zap.Int64("id", id))
+	logger.FromContext(c.Request.Context()).Info("DeleteConfig 入参", zap.Int64("id", id))
 
-	if err := h.service.Delete(id); err != nil {
-		logger.Logger().Error("DeleteConfig 失败", zap.Error(err))
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		logger.FromContext(c.Request.Context()).Error("DeleteConfig 失败", zap.Error(err))
 		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 
-	logger.Logger().Info("DeleteConfig 出参", zap.String("msg", "删除成功"))
+	logger.FromContext(c.Request.Context()).Info("DeleteConfig 出参", zap.String("msg", "删除成功"))
 	utils.JSON(c, utils.Success("删除成功"))
 }
 
@@ -142,8 +141,8 @@ func init() {
 	router.RegisterRouteModule(&ConfigHandler{})
 }
 
-func (h *ConfigHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
-	h.service = service.NewConfigService(repository.NewConfigRepository(db))
+func (h *ConfigHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.service = a.Services.Config
 	api := rg.Group("/system/config")
 	api.Use(middleware.ConfigOperatorName("config"))
 	{
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"template-backend/internal/migrations"
+	"template-backend/internal/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	readyCheckTimeout = 2 * time.Second
+	// logBacklogLimit 任一请求日志输出目标积压超过容量的该比例时视为未就绪
+	logBacklogLimit = 0.9
+)
+
+// HealthHandler 存活和就绪探针，挂在根路径下，不经过鉴权和请求日志
+type HealthHandler struct {
+	db        *gorm.DB
+	logWriter service.LogWriter
+}
+
+func NewHealthHandler(db *gorm.DB, logWriter service.LogWriter) *HealthHandler {
+	return &HealthHandler{db: db, logWriter: logWriter}
+}
+
+// GET /health - 存活探针，进程能处理请求即返回 200
+func (h *HealthHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /ready - 就绪探针：数据库可连接、已迁移到最新版本、请求日志没有严重积压
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyCheckTimeout)
+	defer cancel()
+
+	ready := true
+	checks := gin.H{}
+	fail := func(name string, err error) {
+		ready = false
+		checks[name] = gin.H{"status": "fail", "error": err.Error()}
+	}
+
+	if sqlDB, err := h.db.DB(); err != nil {
+		fail("database", err)
+	} else if err := sqlDB.PingContext(ctx); err != nil {
+		fail("database", err)
+	} else {
+		checks["database"] = gin.H{"status": "ok"}
+
+		if err := migrations.Check(h.db.WithContext(ctx)); err != nil {
+			fail("migrations", err)
+		} else {
+			checks["migrations"] = gin.H{"status": "ok"}
+		}
+	}
+
+	logCheck := gin.H{"status": "ok"}
+	sinks := gin.H{}
+	for _, sink := range h.logWriter.Sinks() {
+		pending, capacity := sink.Pending(), sink.Capacity()
+		sinkCheck := gin.H{"status": "ok", "pending": pending, "capacity": capacity,
+			"dropped": sink.Dropped(), "failed": sink.Failed()}
+		if float64(pending) >= float64(capacity)*logBacklogLimit {
+			ready = false
+			sinkCheck["status"] = "fail"
+			logCheck["status"] = "fail"
+		}
+		sinks[sink.Name()] = sinkCheck
+	}
+	logCheck["sinks"] = sinks
+	checks["requestLog"] = logCheck
+
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
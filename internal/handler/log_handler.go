@@ -1,19 +1,23 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"os"
 	"strconv"
+	"template-backend/internal/app"
+	"template-backend/internal/dto"
 	"template-backend/internal/model"
-	"template-backend/internal/repository"
 	"template-backend/internal/router"
 	"template-backend/internal/service"
 	"template-backend/pkg/logger"
+	"template-backend/pkg/tracing"
 	"template-backend/pkg/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
 type LogHandler interface {
@@ -26,7 +30,11 @@ type LogHandler interface {
 }
 
 type logHandler struct {
-	service service.LogService
+	service   service.LogService
+	export    service.LogExportService
+	retention service.LogRetentionService
+	tracing   tracing.Config
+	basePath  string
 }
 
 func NewLogHandler(service service.LogService) LogHandler {
@@ -35,7 +43,7 @@ func NewLogHandler(service service.LogService) LogHandler {
 
 // GetLogList 获取日志列表
 func (h *logHandler) GetLogList(c *gin.Context) {
-	logger.Logger().Info("Handling GetLogList request")
+	logger.FromContext(c.Request.Context()).Info("Handling GetLogList request")
 
 	// 解析查询参数
 	pageNum := 1
@@ -53,37 +61,25 @@ func (h *logHandler) GetLogList(c *gin.Context) {
 	}
 
 	// 构建查询条件
-	conditions := make(map[string]interface{})
-	if method := c.Query("method"); method != "" {
-		conditions["method"] = method
-	}
-	if path := c.Query("path"); path != "" {
-		conditions["path"] = path
+	filter := dto.LogFilter{
+		Method:    c.Query("method"),
+		Path:      c.Query("path"),
+		IP:        c.Query("ip"),
+		Handler:   c.Query("handler"),
+		TraceID:   c.Query("traceId"),
+		RequestID: c.Query("requestId"),
+		Timestamp: c.QueryArray("timestamp[]"),
 	}
 	if statusStr := c.Query("status"); statusStr != "" {
 		if status, err := strconv.Atoi(statusStr); err == nil {
-			conditions["status"] = status
-		}
-	}
-	if ip := c.Query("ip"); ip != "" {
-		conditions["ip"] = ip
-	}
-	if handler := c.Query("handler"); handler != "" {
-		conditions["handler"] = handler
-	}
-	if timestamps := c.QueryArray("timestamp[]"); len(timestamps) == 2 {
-		// 设置东八区时区
-		loc, _ := time.LoadLocation("Asia/Shanghai")
-		startTime, err1 := time.ParseInLocation(time.DateTime, timestamps[0], loc)
-		endTime, err2 := time.ParseInLocation(time.DateTime, timestamps[1], loc)
-		if err1 == nil && err2 == nil {
-			conditions["timestamp"] = []time.Time{startTime, endTime}
+			filter.Status = status
 		}
 	}
+	conditions := logConditions(filter)
 
-	logs, total, err := h.service.GetLogList(pageNum, pageSize, conditions)
+	logs, total, err := h.service.GetLogList(c.Request.Context(), pageNum, pageSize, conditions)
 	if err != nil {
-		logger.Logger().Error("Failed to get log list", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Failed to get log list", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "获取日志列表失败",
@@ -92,6 +88,9 @@ func (h *logHandler) GetLogList(c *gin.Context) {
 		return
 	}
 
+	for i := range logs {
+		logs[i].TraceURL = h.tracing.Link(logs[i].TraceID)
+	}
 	data := utils.PageResult[model.Log]{
 		List:     logs,
 		Total:    total,
@@ -103,12 +102,12 @@ func (h *logHandler) GetLogList(c *gin.Context) {
 
 // GetLogByID 根据ID获取日志详情
 func (h *logHandler) GetLogByID(c *gin.Context) {
-	logger.Logger().Info("Handling GetLogByID request")
+	logger.FromContext(c.Request.Context()).Info("Handling GetLogByID request")
 
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		logger.Logger().Error("Invalid log ID", zap.String("id", idStr), zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Invalid log ID", zap.String("id", idStr), zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 400,
 			"msg":  "无效的日志ID",
@@ -117,9 +116,9 @@ func (h *logHandler) GetLogByID(c *gin.Context) {
 		return
 	}
 
-	log, err := h.service.GetLogByID(uint(id))
+	log, err := h.service.GetLogByID(c.Request.Context(), uint(id))
 	if err != nil {
-		logger.Logger().Error("Failed to get log by ID", zap.Uint("id", uint(id)), zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Failed to get log by ID", zap.Uint("id", uint(id)), zap.Error(err))
 		c.JSON(http.StatusNotFound, gin.H{
 			"code": 404,
 			"msg":  "日志不存在",
@@ -128,17 +127,18 @@ func (h *logHandler) GetLogByID(c *gin.Context) {
 		return
 	}
 
+	log.TraceURL = h.tracing.Link(log.TraceID)
 	utils.JSON(c, utils.Success(log))
 }
 
 // DeleteLog 删除日志
 func (h *logHandler) DeleteLog(c *gin.Context) {
-	logger.Logger().Info("Handling DeleteLog request")
+	logger.FromContext(c.Request.Context()).Info("Handling DeleteLog request")
 
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		logger.Logger().Error("Invalid log ID", zap.String("id", idStr), zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Invalid log ID", zap.String("id", idStr), zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 400,
 			"msg":  "无效的日志ID",
@@ -147,9 +147,9 @@ func (h *logHandler) DeleteLog(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteLog(uint(id))
+	err = h.service.DeleteLog(c.Request.Context(), uint(id))
 	if err != nil {
-		logger.Logger().Error("Failed to delete log", zap.Uint("id", uint(id)), zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Failed to delete log", zap.Uint("id", uint(id)), zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "删除日志失败",
@@ -162,14 +162,14 @@ func (h *logHandler) DeleteLog(c *gin.Context) {
 
 // DeleteLogs 批量删除日志
 func (h *logHandler) DeleteLogs(c *gin.Context) {
-	logger.Logger().Info("Handling DeleteLogs request")
+	logger.FromContext(c.Request.Context()).Info("Handling DeleteLogs request")
 
 	var req struct {
 		IDs []uint `json:"ids"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Logger().Error("Failed to bind delete logs request", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Failed to bind delete logs request", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 400,
 			"msg":  "请求参数错误",
@@ -187,9 +187,9 @@ func (h *logHandler) DeleteLogs(c *gin.Context) {
 		return
 	}
 
-	err := h.service.DeleteLogs(req.IDs)
+	err := h.service.DeleteLogs(c.Request.Context(), req.IDs)
 	if err != nil {
-		logger.Logger().Error("Failed to batch delete logs", zap.Any("ids", req.IDs), zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Failed to batch delete logs", zap.Any("ids", req.IDs), zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "批量删除日志失败",
@@ -203,11 +203,11 @@ func (h *logHandler) DeleteLogs(c *gin.Context) {
 
 // CleanLogs 清空日志
 func (h *logHandler) CleanLogs(c *gin.Context) {
-	logger.Logger().Info("Handling CleanLogs request")
+	logger.FromContext(c.Request.Context()).Info("Handling CleanLogs request")
 
-	err := h.service.CleanLogs()
+	err := h.service.CleanLogs(c.Request.Context())
 	if err != nil {
-		logger.Logger().Error("Failed to clean logs", zap.Error(err))
+		logger.FromContext(c.Request.Context()).Error("Failed to clean logs", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": 500,
 			"msg":  "清空日志失败",
@@ -219,18 +219,176 @@ func (h *logHandler) CleanLogs(c *gin.Context) {
 	utils.JSON(c, utils.Success(""))
 }
 
-// ExportLogs 导出日志
+// ExportLogs 导出日志：筛选条件与列表相同，可选择格式和列。
+// 行数不超过同步上限时直接流式写入响应，否则创建后台任务并返回 202 和任务信息
 func (h *logHandler) ExportLogs(c *gin.Context) {
-	logger.Logger().Info("Handling ExportLogs request")
+	logger.FromContext(c.Request.Context()).Info("Handling ExportLogs request")
+
+	var req dto.LogExportRequest
+	// 允许空请求体，此时按默认格式和列导出全部日志
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.FromContext(c.Request.Context()).Error("Failed to bind export logs request", zap.Error(err))
+		utils.JSON(c, utils.Error("请求参数错误", http.StatusBadRequest))
+		return
+	}
+
+	opts, err := h.export.Validate(service.LogExportOptions{
+		Format:     req.Format,
+		Columns:    req.Columns,
+		Conditions: logConditions(req.LogFilter),
+	})
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
+		return
+	}
+
+	ctx := c.Request.Context()
+	total, err := h.export.Count(ctx, opts)
+	if errors.Is(err, service.ErrLogExportTooMany) {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
+		return
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to count logs for export", zap.Error(err))
+		utils.JSON(c, utils.Error("导出日志失败", http.StatusInternalServerError))
+		return
+	}
 
-	// 这里实现日志导出逻辑，可以导出为CSV或Excel格式
-	// 为简化示例，这里返回一个简单的文本文件
+	if req.Async || h.export.NeedsJob(total) {
+		job, err := h.export.Submit(ctx, opts, total)
+		switch {
+		case errors.Is(err, service.ErrLogExportBusy):
+			utils.JSON(c, utils.Error(err.Error(), http.StatusTooManyRequests))
+			return
+		case err != nil:
+			utils.JSON(c, utils.Error(err.Error(), http.StatusServiceUnavailable))
+			return
+		}
+		utils.JSON(c, utils.Success(h.exportJobResponse(job)), http.StatusAccepted)
+		return
+	}
 
-	content := "日志导出功能待实现"
+	c.Header("Content-Type", h.export.ContentType(opts.Format))
+	c.Header("Content-Disposition", "attachment; filename="+service.LogExportFileName(time.Now(), opts.Format))
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Status(http.StatusOK)
+	// 响应头已发送，出错时只能中断输出并记录日志
+	if rows, err := h.export.Export(ctx, c.Writer, opts); err != nil {
+		logger.FromContext(ctx).Error("Failed to export logs", zap.Int64("rows", rows), zap.Error(err))
+		_ = c.Error(err)
+	}
+}
+
+// logExportJob 后台导出任务，完成后附带下载地址
+type logExportJob struct {
+	*service.LogExportJob
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
+
+func (h *logHandler) exportJobResponse(job *service.LogExportJob) logExportJob {
+	resp := logExportJob{LogExportJob: job}
+	if job.Status == service.LogExportDone {
+		resp.DownloadURL = h.basePath + "/export/jobs/" + job.ID + "/download"
+	}
+	return resp
+}
+
+// GetExportColumns 可导出的列
+func (h *logHandler) GetExportColumns(c *gin.Context) {
+	utils.JSON(c, utils.Success(service.LogExportColumns()))
+}
 
-	c.Header("Content-Type", "text/plain")
-	c.Header("Content-Disposition", "attachment; filename=logs.txt")
-	c.Data(http.StatusOK, "text/plain", []byte(content))
+// GetExportJob 查询后台导出任务状态
+func (h *logHandler) GetExportJob(c *gin.Context) {
+	job, ok := h.export.Job(c.Param("id"))
+	if !ok {
+		utils.JSON(c, utils.Error("导出任务不存在或已过期", http.StatusNotFound))
+		return
+	}
+	utils.JSON(c, utils.Success(h.exportJobResponse(job)))
+}
+
+// DownloadExport 下载后台导出任务生成的文件
+func (h *logHandler) DownloadExport(c *gin.Context) {
+	f, job, err := h.export.Open(c.Param("id"))
+	if job == nil || errors.Is(err, os.ErrNotExist) {
+		utils.JSON(c, utils.Error("导出任务不存在或已过期", http.StatusNotFound))
+		return
+	}
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusConflict))
+		return
+	}
+	defer f.Close()
+
+	modTime := job.CreatedAt
+	if job.FinishedAt != nil {
+		modTime = *job.FinishedAt
+	}
+	c.Header("Content-Type", h.export.ContentType(job.Format))
+	c.Header("Content-Disposition", "attachment; filename="+job.FileName())
+	http.ServeContent(c.Writer, c.Request, job.FileName(), modTime, f)
+}
+
+// GetRetention 当前保留策略、是否正在清理和最近一次清理结果
+func (h *logHandler) GetRetention(c *gin.Context) {
+	last, err := h.retention.LastPurge(c.Request.Context())
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Error("Failed to get last log purge", zap.Error(err))
+		utils.JSON(c, utils.Error("获取日志清理记录失败", http.StatusInternalServerError))
+		return
+	}
+	utils.JSON(c, utils.Success(gin.H{
+		"settings":  h.retention.Settings(),
+		"running":   h.retention.Running(),
+		"lastPurge": last,
+	}))
+}
+
+// RunRetention 立即在后台按保留策略清理一次，结果通过 GetRetention 查询
+func (h *logHandler) RunRetention(c *gin.Context) {
+	switch err := h.retention.Trigger(c.Request.Context(), service.LogPurgeManual); {
+	case errors.Is(err, service.ErrLogPurgeRunning):
+		utils.JSON(c, utils.Error(err.Error(), http.StatusConflict))
+	case err != nil:
+		utils.JSON(c, utils.Error(err.Error(), http.StatusServiceUnavailable))
+	default:
+		utils.JSON(c, utils.Success(""), http.StatusAccepted)
+	}
+}
+
+// logConditions 将筛选条件转换为仓储使用的查询条件
+func logConditions(filter dto.LogFilter) map[string]interface{} {
+	conditions := make(map[string]interface{})
+	if filter.Method != "" {
+		conditions["method"] = filter.Method
+	}
+	if filter.Path != "" {
+		conditions["path"] = filter.Path
+	}
+	if filter.Status != 0 {
+		conditions["status"] = filter.Status
+	}
+	if filter.IP != "" {
+		conditions["ip"] = filter.IP
+	}
+	if filter.Handler != "" {
+		conditions["handler"] = filter.Handler
+	}
+	if filter.TraceID != "" {
+		conditions["traceId"] = filter.TraceID
+	}
+	if filter.RequestID != "" {
+		conditions["requestId"] = filter.RequestID
+	}
+	if len(filter.Timestamp) == 2 {
+		startTime, err1 := time.ParseInLocation(time.DateTime, filter.Timestamp[0], service.LogLocation)
+		endTime, err2 := time.ParseInLocation(time.DateTime, filter.Timestamp[1], service.LogLocation)
+		if err1 == nil && err2 == nil {
+			conditions["timestamp"] = []time.Time{startTime, endTime}
+		}
+	}
+	return conditions
 }
 
 func init() {
@@ -238,10 +396,14 @@ func init() {
 	router.RegisterRouteModule(&logHandler{})
 }
 
-func (h *logHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
-	h.service = service.NewLogService(repository.NewLogRepository(db))
+func (h *logHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.service = a.Services.Log
+	h.export = a.Services.LogExport
+	h.retention = a.Services.LogRetention
+	h.tracing = a.Config.Tracing
 	// 日志相关路由
 	logGroup := rg.Group("/system/log")
+	h.basePath = logGroup.BasePath()
 	{
 		logGroup.GET("/list", h.GetLogList)
 		logGroup.GET("/:id", h.GetLogByID)
@@ -249,6 +411,11 @@ func (h *logHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
 		logGroup.DELETE("", h.DeleteLogs)
 		logGroup.DELETE("/clean", h.CleanLogs)
 		logGroup.POST("/export", h.ExportLogs)
+		logGroup.GET("/export/columns", h.GetExportColumns)
+		logGroup.GET("/export/jobs/:id", h.GetExportJob)
+		logGroup.GET("/export/jobs/:id/download", h.DownloadExport)
+		logGroup.GET("/retention", h.GetRetention)
+		logGroup.POST("/retention/run", h.RunRetention)
 	}
 
 }
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"template-backend/internal/app"
+	"template-backend/internal/router"
+	"template-backend/internal/service"
+	"template-backend/pkg/logger"
+	"template-backend/pkg/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultStatsLimit = 10
+	maxStatsLimit     = 100
+)
+
+// logStatsHandler 日志统计看板接口，时间范围参数与日志列表相同：
+// timestamp[]=开始&timestamp[]=结束（东八区 2006-01-02 15:04:05），不传时统计最近 24 小时
+type logStatsHandler struct {
+	service service.LogStatsService
+}
+
+// GetTimeline 按时间段统计请求数和错误率，interval 可选 minute、hour、day
+func (h *logStatsHandler) GetTimeline(c *gin.Context) {
+	start, end, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+	timeline, err := h.service.Timeline(c.Request.Context(), start, end, c.Query("interval"))
+	h.respond(c, timeline, err)
+}
+
+// GetStatus 按状态码分类统计请求数和占比
+func (h *logStatsHandler) GetStatus(c *gin.Context) {
+	start, end, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+	summary, err := h.service.Status(c.Request.Context(), start, end)
+	h.respond(c, summary, err)
+}
+
+// GetLatency 各处理函数的 p50/p95/p99 耗时
+func (h *logStatsHandler) GetLatency(c *gin.Context) {
+	start, end, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+	latency, err := h.service.Latency(c.Request.Context(), start, end, statsLimit(c))
+	h.respond(c, latency, err)
+}
+
+// GetTopPaths 请求数最多的路径
+func (h *logStatsHandler) GetTopPaths(c *gin.Context) {
+	start, end, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+	paths, err := h.service.TopPaths(c.Request.Context(), start, end, statsLimit(c))
+	h.respond(c, paths, err)
+}
+
+// GetTopIPs 请求数最多的客户端 IP
+func (h *logStatsHandler) GetTopIPs(c *gin.Context) {
+	start, end, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+	ips, err := h.service.TopIPs(c.Request.Context(), start, end, statsLimit(c))
+	h.respond(c, ips, err)
+}
+
+// GetSlowest 耗时最长的请求
+func (h *logStatsHandler) GetSlowest(c *gin.Context) {
+	start, end, ok := h.parseRange(c)
+	if !ok {
+		return
+	}
+	slowest, err := h.service.Slowest(c.Request.Context(), start, end, statsLimit(c))
+	h.respond(c, slowest, err)
+}
+
+func (h *logStatsHandler) parseRange(c *gin.Context) (time.Time, time.Time, bool) {
+	timestamps := c.QueryArray("timestamp[]")
+	if len(timestamps) == 0 {
+		start, end := h.service.DefaultRange()
+		return start, end, true
+	}
+
+	if len(timestamps) == 2 {
+		start, err1 := time.ParseInLocation(time.DateTime, timestamps[0], service.LogLocation)
+		end, err2 := time.ParseInLocation(time.DateTime, timestamps[1], service.LogLocation)
+		if err1 == nil && err2 == nil && end.After(start) {
+			return start, end, true
+		}
+	}
+	utils.JSON(c, utils.Error(service.ErrLogStatsRange.Error(), http.StatusBadRequest))
+	return time.Time{}, time.Time{}, false
+}
+
+func (h *logStatsHandler) respond(c *gin.Context, data interface{}, err error) {
+	switch {
+	case errors.Is(err, service.ErrLogStatsRange), errors.Is(err, service.ErrLogStatsInterval):
+		utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
+	case err != nil:
+		logger.FromContext(c.Request.Context()).Error("Failed to compute log stats", zap.String("path", c.FullPath()), zap.Error(err))
+		utils.JSON(c, utils.Error("日志统计失败", http.StatusInternalServerError))
+	default:
+		utils.JSON(c, utils.Success(data))
+	}
+}
+
+// statsLimit 排行榜返回的条数，默认 10，最多 100
+func statsLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return defaultStatsLimit
+	}
+	return min(limit, maxStatsLimit)
+}
+
+func init() {
+	router.RegisterRouteModule(&logStatsHandler{})
+}
+
+func (h *logStatsHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.service = a.Services.LogStats
+	statsGroup := rg.Group("/system/log/stats")
+	{
+		statsGroup.GET("/timeline", h.GetTimeline)
+		statsGroup.GET("/status", h.GetStatus)
+		statsGroup.GET("/latency", h.GetLatency)
+		statsGroup.GET("/top-paths", h.GetTopPaths)
+		statsGroup.GET("/top-ips", h.GetTopIPs)
+		statsGroup.GET("/slowest", h.GetSlowest)
+	}
+}
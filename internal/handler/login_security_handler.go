@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"template-backend/internal/app"
+	"template-backend/internal/model"
+	"template-backend/internal/router"
+	"template-backend/internal/service"
+	"template-backend/pkg/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginSecurityHandler struct {
+	loginGuard service.LoginGuardService
+}
+
+func NewLoginSecurityHandler(loginGuard service.LoginGuardService) *LoginSecurityHandler {
+	return &LoginSecurityHandler{loginGuard: loginGuard}
+}
+
+// GET /api/system/login/audits - 查询登录审计记录
+func (h *LoginSecurityHandler) GetAuditList(c *gin.Context) {
+	page, pageSize := pageParams(c)
+
+	filters := map[string]interface{}{}
+	if username := c.Query("username"); username != "" {
+		filters["username"] = username
+	}
+	if ip := c.Query("ip"); ip != "" {
+		filters["ip"] = ip
+	}
+	if success := c.Query("success"); success != "" {
+		if v, err := strconv.ParseBool(success); err == nil {
+			filters["success"] = v
+		}
+	}
+	if createdAt := c.QueryArray("createdAt[]"); len(createdAt) == 2 {
+		startTime, err1 := time.ParseInLocation(time.DateTime, createdAt[0], service.LogLocation)
+		endTime, err2 := time.ParseInLocation(time.DateTime, createdAt[1], service.LogLocation)
+		if err1 == nil && err2 == nil {
+			filters["createdAt"] = []time.Time{startTime, endTime}
+		}
+	}
+
+	audits, total, err := h.loginGuard.ListAudits(c.Request.Context(), page, pageSize, filters)
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
+		return
+	}
+	utils.JSON(c, utils.Success(utils.PageResult[model.LoginAudit]{
+		List:     audits,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}))
+}
+
+// GET /api/system/login/failures - 查询登录失败计数与锁定状态
+func (h *LoginSecurityHandler) GetFailureList(c *gin.Context) {
+	page, pageSize := pageParams(c)
+
+	filters := map[string]interface{}{}
+	if scope := c.Query("scope"); scope != "" {
+		filters["scope"] = scope
+	}
+	if subject := c.Query("subject"); subject != "" {
+		filters["subject"] = subject
+	}
+	if locked := c.Query("locked"); locked != "" {
+		if v, err := strconv.ParseBool(locked); err == nil {
+			filters["locked"] = v
+		}
+	}
+
+	failures, total, err := h.loginGuard.ListFailures(c.Request.Context(), page, pageSize, filters)
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
+		return
+	}
+	utils.JSON(c, utils.Success(utils.PageResult[model.LoginFailure]{
+		List:     failures,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}))
+}
+
+// DELETE /api/system/login/failures/:id - 解除锁定并清零失败次数
+func (h *LoginSecurityHandler) ClearFailure(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
+		return
+	}
+	if err := h.loginGuard.ClearFailure(c.Request.Context(), uint(id)); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
+		return
+	}
+	utils.JSON(c, utils.Success(""))
+}
+
+// pageParams 解析 page/pageSize 分页参数
+func pageParams(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	return page, pageSize
+}
+
+func init() {
+	// 自动注册路由模块（通过 init 自动调用）
+	router.RegisterRouteModule(&LoginSecurityHandler{})
+}
+
+func (h *LoginSecurityHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.loginGuard = a.Services.LoginGuard
+	login := rg.Group("/system/login")
+	{
+		login.GET("/audits", h.GetAuditList)
+		login.GET("/failures", h.GetFailureList)
+		login.DELETE("/failures/:id", h.ClearFailure)
+	}
+}
@@ -2,13 +2,13 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 	"net/http"
 	"strconv"
+	"template-backend/internal/app"
 	"template-backend/internal/model"
-	"template-backend/internal/repository"
 	"template-backend/internal/router"
 	"template-backend/internal/service"
 	"template-backend/pkg/logger"
@@ -16,10 +16,10 @@ import (
 )
 
 type MenuHandler struct {
-	service *service.MenuService
+	service service.MenuService
 }
 
-func NewMenuHandler(service *service.MenuService) *MenuHandler {
+func NewMenuHandler(service service.MenuService) *MenuHandler {
 	return &MenuHandler{service: service}
 }
 
@@ -49,27 +49,38 @@ func (h *MenuHandler) GetMenuTree(c *gin.Context) {
 		menu.Visible = &parseBool
 	}
 
-	menus, err := h.service.GetMenuTree(menu)
+	menus, err := h.service.GetMenuTree(c.Request.Context(), menu)
 	if err != nil {
 		utils.JSON(c, utils.Error("get menu tree failed: "+err.Error(), http.StatusInternalServerError))
 		return
 	}
-	for i := range menus {
-		menus[i].UnMarshalMeta()
-	}
 	utils.JSON(c, utils.Success(menus))
 }
 
+// GetUserRoutes 获取当前用户有权访问的菜单树，用于前端生成动态路由
+func (h *MenuHandler) GetUserRoutes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.JSON(c, utils.Error("未授权", http.StatusUnauthorized))
+		return
+	}
+
+	routes, err := h.service.GetUserRoutes(c.Request.Context(), userID.(uint))
+	if err != nil {
+		utils.JSON(c, utils.Error("get user routes failed: "+err.Error(), http.StatusInternalServerError))
+		return
+	}
+	utils.JSON(c, utils.Success(routes))
+}
+
 func (h *MenuHandler) CreateMenu(c *gin.Context) {
 	var menu model.Menu
 	if err := c.ShouldBindJSON(&menu); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	logger.Logger().Info("menu", zap.String("name", menu.Name))
-	menu.MarshalMeta()
-	logger.Logger().Info("menu", zap.String("name", menu.Name))
-	if err := h.service.CreateMenu(&menu); err != nil {
+	logger.FromContext(c.Request.Context()).Info("menu", zap.String("name", menu.Name))
+	if err := h.service.CreateMenu(c.Request.Context(), &menu); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -90,10 +101,9 @@ func (h *MenuHandler) UpdateMenu(c *gin.Context) {
 		return
 	}
 	menu.ID = uint(id)
-	menu.MarshalMeta()
 
-	if err := h.service.UpdateMenu(&menu); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.UpdateMenu(c.Request.Context(), &menu); err != nil {
+		c.JSON(menuErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	utils.JSON(c, utils.Success(menu))
@@ -107,18 +117,26 @@ func (h *MenuHandler) DeleteMenu(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteMenu(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.DeleteMenu(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(menuErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	utils.JSON(c, utils.Success(gin.H{"message": "deleted"}))
 }
 
-func (h *MenuHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
-	h.service = service.NewMenuService(repository.NewMenuRepository(db))
+func menuErrorStatus(err error) int {
+	if errors.Is(err, service.ErrMenuNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *MenuHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.service = a.Services.Menu
 	menu := rg.Group("/menu")
 	{
 		menu.GET("/tree", h.GetMenuTree)
+		menu.GET("/routes", h.GetUserRoutes)
 		menu.POST("", h.CreateMenu)
 		menu.PUT("/:id", h.UpdateMenu)
 		menu.DELETE("/:id", h.DeleteMenu)
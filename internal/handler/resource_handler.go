@@ -2,11 +2,10 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 	"net/http"
 	"strconv"
+	"template-backend/internal/app"
 	"template-backend/internal/dto"
-	"template-backend/internal/repository"
 	"template-backend/internal/router"
 	"template-backend/internal/service"
 	"template-backend/pkg/utils"
@@ -42,7 +41,7 @@ func (h *ResourceHandler) CreateResource(c *gin.Context) {
 		return
 	}
 
-	response, err := h.resourceService.CreateResource(&req)
+	response, err := h.resourceService.CreateResource(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "创建失败",
@@ -78,7 +77,7 @@ func (h *ResourceHandler) GetResource(c *gin.Context) {
 		return
 	}
 
-	response, err := h.resourceService.GetResourceByID(id)
+	response, err := h.resourceService.GetResourceByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "获取失败",
@@ -125,7 +124,7 @@ func (h *ResourceHandler) UpdateResource(c *gin.Context) {
 		return
 	}
 
-	response, err := h.resourceService.UpdateResource(id, &req)
+	response, err := h.resourceService.UpdateResource(c.Request.Context(), id, &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "更新失败",
@@ -161,7 +160,7 @@ func (h *ResourceHandler) DeleteResource(c *gin.Context) {
 		return
 	}
 
-	err = h.resourceService.DeleteResource(id)
+	err = h.resourceService.DeleteResource(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "删除失败",
@@ -205,7 +204,7 @@ func (h *ResourceHandler) ListResources(c *gin.Context) {
 		return
 	}
 
-	response, err := h.resourceService.ListResources(&req)
+	response, err := h.resourceService.ListResources(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "查询失败",
@@ -231,7 +230,7 @@ func (h *ResourceHandler) ResourcesTree(c *gin.Context) {
 		return
 	}
 
-	response, err := h.resourceService.ResourcesTree(&req)
+	response, err := h.resourceService.ResourcesTree(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "查询失败",
@@ -253,8 +252,8 @@ func init() {
 	router.RegisterRouteModule(&ResourceHandler{})
 }
 
-func (h *ResourceHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
-	h.resourceService = service.NewResourceService(repository.NewResourceRepository(db))
+func (h *ResourceHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.resourceService = a.Services.Resource
 	resources := rg.Group("/resources")
 	{
 		resources.POST("", h.CreateResource)
@@ -3,21 +3,21 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 	"net/http"
 	"strconv"
+	"template-backend/internal/app"
 	"template-backend/internal/model"
-	"template-backend/internal/repository"
 	"template-backend/internal/router"
 	"template-backend/internal/service"
+	"template-backend/pkg/datascope"
 	"template-backend/pkg/utils"
 )
 
 type RoleHandler struct {
-	roleService *service.RoleService
+	roleService service.RoleService
 }
 
-func NewRoleHandler(roleService *service.RoleService) *RoleHandler {
+func NewRoleHandler(roleService service.RoleService) *RoleHandler {
 	return &RoleHandler{roleService: roleService}
 }
 
@@ -39,9 +39,9 @@ func (h *RoleHandler) GetRoleList(c *gin.Context) {
 		}
 	}
 
-	roles, total, err := h.roleService.GetList(page, size, filters)
+	roles, total, err := h.roleService.GetList(c.Request.Context(), page, size, filters)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 	utils.JSON(c, utils.Success(gin.H{
@@ -57,12 +57,12 @@ func (h *RoleHandler) GetRoleList(c *gin.Context) {
 func (h *RoleHandler) CreateRole(c *gin.Context) {
 	var req model.Role
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
-	if err := h.roleService.Create(&req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+	if err := h.roleService.Create(c.Request.Context(), &req); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 
@@ -73,15 +73,15 @@ func (h *RoleHandler) CreateRole(c *gin.Context) {
 func (h *RoleHandler) UpdateRole(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 
-	role, err := h.roleService.GetByID(uint(id))
+	role, err := h.roleService.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "角色不存在"})
+		utils.JSON(c, utils.Error("角色不存在", http.StatusNotFound))
 		return
 	}
 
 	var req model.Role
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
@@ -90,8 +90,8 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 	role.RoleDesc = req.RoleDesc
 	role.Status = req.Status
 
-	if err := h.roleService.Update(role); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+	if err := h.roleService.Update(c.Request.Context(), role); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 
@@ -101,8 +101,8 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 // DELETE /api/roles/:id
 func (h *RoleHandler) DeleteRole(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	if err := h.roleService.Delete(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+	if err := h.roleService.Delete(c.Request.Context(), uint(id)); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功"})
@@ -114,11 +114,11 @@ func (h *RoleHandler) BatchDeleteRoles(c *gin.Context) {
 		IDs []uint `json:"ids"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
-	if err := h.roleService.BatchDelete(req.IDs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+	if err := h.roleService.BatchDelete(c.Request.Context(), req.IDs); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "批量删除成功"})
@@ -127,9 +127,9 @@ func (h *RoleHandler) BatchDeleteRoles(c *gin.Context) {
 // GET /api/roles/:id/permissions
 func (h *RoleHandler) GetRolePermissions(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	permissions, err := h.roleService.GetPermissions(uint(id))
+	permissions, err := h.roleService.GetPermissions(c.Request.Context(), uint(id))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"code": 200, "data": permissions})
@@ -143,19 +143,39 @@ func (h *RoleHandler) UpdateRolePermissions(c *gin.Context) {
 		PermissionIds []uint `json:"permissionIds"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
-	if err := h.roleService.UpdatePermissions(uint(id), req.PermissionIds); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+	if err := h.roleService.UpdatePermissions(c.Request.Context(), uint(id), req.PermissionIds); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "权限更新成功"})
 }
 
-func (h *RoleHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
-	h.roleService = service.NewRoleService(repository.NewRoleRepository(db))
+// PUT /api/roles/:id/data-scope
+func (h *RoleHandler) UpdateRoleDataScope(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var req struct {
+		DataScope      string           `json:"dataScope" binding:"required"`
+		DataScopeRules []datascope.Rule `json:"dataScopeRules"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
+		return
+	}
+
+	if err := h.roleService.UpdateDataScope(c.Request.Context(), uint(id), req.DataScope, req.DataScopeRules); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "数据范围更新成功"})
+}
+
+func (h *RoleHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.roleService = a.Services.Role
 	roles := rg.Group("/roles")
 	{
 		roles.GET("", h.GetRoleList)
@@ -165,6 +185,7 @@ func (h *RoleHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
 		roles.DELETE("/batch", h.BatchDeleteRoles)
 		roles.GET("/:id/permissions", h.GetRolePermissions)
 		roles.PUT("/:id/permissions", h.UpdateRolePermissions)
+		roles.PUT("/:id/data-scope", h.UpdateRoleDataScope)
 	}
 }
 
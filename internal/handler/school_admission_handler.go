@@ -1,16 +1,18 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"net/http"
 	"strconv"
+	"template-backend/internal/app"
 	"template-backend/internal/dto"
 	"template-backend/internal/model"
-	"template-backend/internal/repository"
 	"template-backend/internal/router"
 	"template-backend/internal/service"
+	"template-backend/pkg/datascope"
 	"template-backend/pkg/logger"
 	"template-backend/pkg/utils"
 )
@@ -27,8 +29,8 @@ func init() {
 }
 
 // Register 注册路由
-func (h *SchoolAdmissionHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
-	h.svc = service.NewSchoolAdmissionService(repository.NewSchoolAdmissionRepository(db))
+func (h *SchoolAdmissionHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.svc = a.Services.SchoolAdmission
 	school := rg.Group("/school-admission")
 	{
 		school.POST("", h.Create)
@@ -54,8 +56,8 @@ func (h *SchoolAdmissionHandler) Create(c *gin.Context) {
 		utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
 		return
 	}
-	if err := h.svc.Create(&req); err != nil {
-		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
+	if err := h.svc.Create(c.Request.Context(), &req); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), dataErrorStatus(err)))
 		return
 	}
 	utils.JSON(c, utils.Success(req))
@@ -71,7 +73,7 @@ func (h *SchoolAdmissionHandler) Create(c *gin.Context) {
 // @Router /school-admission/{id} [get]
 func (h *SchoolAdmissionHandler) GetByID(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	info, err := h.svc.GetByID(id)
+	info, err := h.svc.GetByID(c.Request.Context(), id)
 	if err != nil {
 		utils.JSON(c, utils.Error(err.Error(), http.StatusNotFound))
 		return
@@ -99,12 +101,12 @@ func (h *SchoolAdmissionHandler) List(c *gin.Context) {
 		return
 	}
 	if req.Page <= 0 || req.PageSize <= 0 {
-		logger.Logger().Info("invalid params", zap.Int("page", req.Page), zap.Int("pageSize", req.PageSize))
+		logger.FromContext(c.Request.Context()).Info("invalid params", zap.Int("page", req.Page), zap.Int("pageSize", req.PageSize))
 		utils.JSON(c, utils.Error("invalid params", http.StatusBadRequest))
 		return
 	}
 
-	list, total, err := h.svc.List(&req)
+	list, total, err := h.svc.List(c.Request.Context(), &req)
 	if err != nil {
 		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
@@ -136,13 +138,24 @@ func (h *SchoolAdmissionHandler) Update(c *gin.Context) {
 		return
 	}
 	req.ID = id
-	if err := h.svc.Update(&req); err != nil {
-		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
+	if err := h.svc.Update(c.Request.Context(), &req); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), dataErrorStatus(err)))
 		return
 	}
 	utils.JSON(c, utils.Success(req))
 }
 
+// dataErrorStatus 数据不存在或超出数据范围时返回对应的状态码
+func dataErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, datascope.ErrOutOfScope):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
 // Delete godoc
 // @Summary 删除中考录取信息
 // @Tags 中考录取线
@@ -153,12 +166,12 @@ func (h *SchoolAdmissionHandler) Update(c *gin.Context) {
 // @Router /school-admission/{id} [delete]
 func (h *SchoolAdmissionHandler) Delete(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	admissionInfo, err := h.svc.GetByID(id)
+	admissionInfo, err := h.svc.GetByID(c.Request.Context(), id)
 	if err != nil {
 		utils.JSON(c, utils.Error(err.Error(), http.StatusNotFound))
 		return
 	}
-	if err := h.svc.Delete(id); err != nil {
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
 		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
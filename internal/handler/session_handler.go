@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"template-backend/internal/app"
+	"template-backend/internal/model"
+	"template-backend/internal/router"
+	"template-backend/internal/service"
+	"template-backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	sessionService service.SessionService
+}
+
+func NewSessionHandler(sessionService service.SessionService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+// GET /api/system/sessions - 查询全部在线会话
+func (h *SessionHandler) GetSessionList(c *gin.Context) {
+	page, pageSize := pageParams(c)
+
+	filters := map[string]interface{}{}
+	if username := c.Query("username"); username != "" {
+		filters["username"] = username
+	}
+	if userID := c.Query("userId"); userID != "" {
+		if v, err := strconv.Atoi(userID); err == nil {
+			filters["userId"] = v
+		}
+	}
+	if ip := c.Query("ip"); ip != "" {
+		filters["ip"] = ip
+	}
+
+	sessions, total, err := h.sessionService.List(c.Request.Context(), page, pageSize, filters)
+	if err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
+		return
+	}
+	utils.JSON(c, utils.Success(utils.PageResult[model.Session]{
+		List:     sessions,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}))
+}
+
+// DELETE /api/system/sessions/:id - 结束任意会话
+func (h *SessionHandler) DeleteSession(c *gin.Context) {
+	if err := h.sessionService.RevokeSession(c.Request.Context(), 0, c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			utils.JSON(c, utils.Error(err.Error(), http.StatusNotFound))
+			return
+		}
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
+		return
+	}
+	utils.JSON(c, utils.Success(""))
+}
+
+func init() {
+	// 自动注册路由模块（通过 init 自动调用）
+	router.RegisterRouteModule(&SessionHandler{})
+}
+
+func (h *SessionHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.sessionService = a.Services.Session
+	sessions := rg.Group("/system/sessions")
+	{
+		sessions.GET("", h.GetSessionList)
+		sessions.DELETE("/:id", h.DeleteSession)
+	}
+}
@@ -2,23 +2,23 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"template-backend/internal/app"
 	"template-backend/internal/model"
-	"template-backend/internal/repository"
 	"template-backend/internal/router"
 	"template-backend/internal/service"
 	"template-backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type UserHandler struct {
-	userService *service.UserService
+	userService service.UserService
 }
 
-func NewUserHandler(userService *service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService) *UserHandler {
 	return &UserHandler{userService: userService}
 }
 
@@ -43,9 +43,9 @@ func (h *UserHandler) GetList(c *gin.Context) {
 		}
 	}
 
-	users, total, err := h.userService.GetList(page, pageSize, filters)
+	users, total, err := h.userService.GetList(c.Request.Context(), page, pageSize, filters)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 
@@ -63,9 +63,9 @@ func (h *UserHandler) GetList(c *gin.Context) {
 // GET /api/users/:id
 func (h *UserHandler) GetByID(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	user, err := h.userService.GetByID(uint(id))
+	user, err := h.userService.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "用户不存在"})
+		utils.JSON(c, utils.Error("用户不存在", http.StatusNotFound))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"code": 200, "data": user})
@@ -75,43 +75,43 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 func (h *UserHandler) Create(c *gin.Context) {
 	var req model.UserCreateInformation
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 	var user model.User
 	utils.DeepCopyStruct(&user, &req)
-	password, err := utils.HashPassword(user.Password)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Password 设置不正确"})
-		return
-	}
-	user.Password = password
 
-	if err := h.userService.Create(&user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+	// 密码由 UserService 按密码策略校验后加密
+	if err := h.userService.Create(c.Request.Context(), &user); err != nil {
+		var policyErr *service.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			utils.JSON(c, utils.Error(err.Error(), http.StatusBadRequest))
+			return
+		}
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 	if req.RoleIds != nil {
-		if err := h.userService.AssignRoles(user.ID, req.RoleIds); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+		if err := h.userService.AssignRoles(c.Request.Context(), user.ID, req.RoleIds); err != nil {
+			utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 			return
 		}
 	}
-	c.JSON(http.StatusOK, gin.H{"code": 200, "data": req})
+	c.JSON(http.StatusOK, gin.H{"code": 200, "data": user})
 }
 
 // PUT /api/users/:id
 func (h *UserHandler) Update(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	user, err := h.userService.GetByID(uint(id))
+	user, err := h.userService.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "用户不存在"})
+		utils.JSON(c, utils.Error("用户不存在", http.StatusNotFound))
 		return
 	}
 
 	var req model.User
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
@@ -121,13 +121,13 @@ func (h *UserHandler) Update(c *gin.Context) {
 	user.Gender = req.Gender
 	user.Status = req.Status
 
-	if err := h.userService.Update(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+	if err := h.userService.Update(c.Request.Context(), user); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 	if req.RoleIds != nil {
-		if err := h.userService.AssignRoles(user.ID, req.RoleIds); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+		if err := h.userService.AssignRoles(c.Request.Context(), user.ID, req.RoleIds); err != nil {
+			utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 			return
 		}
 	}
@@ -137,8 +137,8 @@ func (h *UserHandler) Update(c *gin.Context) {
 // DELETE /api/users/:id
 func (h *UserHandler) Delete(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	if err := h.userService.Delete(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+	if err := h.userService.Delete(c.Request.Context(), uint(id)); err != nil {
+		utils.JSON(c, utils.Error(err.Error(), http.StatusInternalServerError))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功"})
@@ -152,19 +152,19 @@ func (h *UserHandler) AssignRoles(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		utils.JSON(c, utils.Error("参数错误", http.StatusBadRequest))
 		return
 	}
 
-	if err := h.userService.AssignRoles(uint(userID), req.RoleIDs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "角色分配失败: " + err.Error()})
+	if err := h.userService.AssignRoles(c.Request.Context(), uint(userID), req.RoleIDs); err != nil {
+		utils.JSON(c, utils.Error("角色分配失败: "+err.Error(), http.StatusInternalServerError))
 		return
 	}
 
 	// 获取更新后的用户信息
-	userWithRoles, err := h.userService.GetByID(uint(userID))
+	userWithRoles, err := h.userService.GetByID(c.Request.Context(), uint(userID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取用户信息失败: " + err.Error()})
+		utils.JSON(c, utils.Error("获取用户信息失败: "+err.Error(), http.StatusInternalServerError))
 		return
 	}
 
@@ -175,17 +175,67 @@ func (h *UserHandler) AssignRoles(c *gin.Context) {
 func (h *UserHandler) GetUserRoles(c *gin.Context) {
 	userID, _ := strconv.Atoi(c.Param("id"))
 
-	roles, err := h.userService.GetUserRoles(uint(userID))
+	roles, err := h.userService.GetUserRoles(c.Request.Context(), uint(userID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取用户角色失败: " + err.Error()})
+		utils.JSON(c, utils.Error("获取用户角色失败: "+err.Error(), http.StatusInternalServerError))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"code": 200, "data": roles})
 }
 
-func (h *UserHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
-	h.userService = service.NewUserService(repository.NewUserRepository(db))
+// GET /api/users/:id/sessions - 获取用户的登录会话
+func (h *UserHandler) GetUserSessions(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+
+	sessions, err := h.userService.GetUserSessions(c.Request.Context(), uint(userID))
+	if err != nil {
+		utils.JSON(c, utils.Error("获取用户会话失败: "+err.Error(), http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "data": sessions})
+}
+
+// POST /api/users/:id/force-logout - 强制用户下线
+func (h *UserHandler) ForceLogout(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+
+	if err := h.userService.ForceLogout(c.Request.Context(), uint(userID)); err != nil {
+		utils.JSON(c, utils.Error("强制下线失败: "+err.Error(), http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已强制下线"})
+}
+
+// POST /api/users/:id/reset-password - 重置密码，返回一次性临时密码
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+
+	password, err := h.userService.ResetPassword(c.Request.Context(), uint(userID))
+	if err != nil {
+		utils.JSON(c, utils.Error("重置密码失败: "+err.Error(), http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "data": gin.H{"password": password}, "message": "密码已重置，用户下次登录需修改密码"})
+}
+
+// DELETE /api/users/:id/2fa - 重置用户的两步验证
+func (h *UserHandler) ResetTwoFactor(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Param("id"))
+
+	if err := h.userService.ResetTwoFactor(c.Request.Context(), uint(userID)); err != nil {
+		utils.JSON(c, utils.Error("重置两步验证失败: "+err.Error(), http.StatusInternalServerError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "两步验证已重置"})
+}
+
+func (h *UserHandler) Register(rg *gin.RouterGroup, a *app.App) {
+	h.userService = a.Services.User
 	users := rg.Group("/users")
 	users.GET("", h.GetList)
 	users.GET("/:id", h.GetByID)
@@ -196,6 +246,14 @@ func (h *UserHandler) Register(rg *gin.RouterGroup, db *gorm.DB) {
 	// 新增角色相关路由
 	users.POST("/:id/roles", h.AssignRoles)
 	users.GET("/:id/roles", h.GetUserRoles)
+
+	// 会话管理
+	users.GET("/:id/sessions", h.GetUserSessions)
+	users.POST("/:id/force-logout", h.ForceLogout)
+	users.POST("/:id/reset-password", h.ResetPassword)
+
+	// 两步验证
+	users.DELETE("/:id/2fa", h.ResetTwoFactor)
 }
 
 func init() {
@@ -0,0 +1,31 @@
+package logsink
+
+import (
+	"context"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+)
+
+// DatabaseSink 写入 logs 表
+type DatabaseSink struct {
+	repo repository.LogRepository
+}
+
+func NewDatabaseSink(repo repository.LogRepository) *DatabaseSink {
+	return &DatabaseSink{repo: repo}
+}
+
+func (s *DatabaseSink) Write(ctx context.Context, logs []*model.Log) error {
+	if err := s.repo.CreateInBatches(ctx, logs); err != nil {
+		// 事务已回滚，清掉回填的主键，重试时重新分配
+		for _, log := range logs {
+			log.ID = 0
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *DatabaseSink) Close() error {
+	return nil
+}
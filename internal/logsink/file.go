@@ -0,0 +1,107 @@
+package logsink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"template-backend/internal/model"
+	"time"
+)
+
+// FileSink 以 JSON Lines 追加写入文件，超过 maxSize 时把当前文件重命名为带时间后缀的备份，
+// 例如 requests.jsonl → requests-20240101-150405.000.jsonl，只保留最近 maxBackups 个
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+func NewFileSink(path string, maxSize int64, maxBackups int) *FileSink {
+	return &FileSink{path: path, maxSize: maxSize, maxBackups: maxBackups}
+}
+
+func (s *FileSink) Write(_ context.Context, logs []*model.Log) error {
+	buf, err := encodeLines(logs)
+	if err != nil {
+		return err
+	}
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	if s.size > 0 && s.size+int64(len(buf)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(buf)
+	if err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil {
+		// 截掉写了一半的内容，重试时整批重写；下次写入时重新打开文件
+		if n > 0 {
+			_ = s.file.Truncate(s.size)
+		}
+		_ = s.Close()
+		return fmt.Errorf("写入请求日志文件失败: %w", err)
+	}
+	s.size += int64(n)
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("创建请求日志目录失败: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("打开请求日志文件失败: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.file, s.size = f, info.Size()
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+	ext := filepath.Ext(s.path)
+	prefix := strings.TrimSuffix(s.path, ext) + "-"
+	backup := prefix + time.Now().Format("20060102-150405.000") + ext
+	if err := os.Rename(s.path, backup); err != nil {
+		return fmt.Errorf("轮转请求日志文件失败: %w", err)
+	}
+	if s.maxBackups > 0 {
+		// 时间后缀定长，按文件名排序即按时间排序
+		backups, _ := filepath.Glob(prefix + "*" + ext)
+		sort.Strings(backups)
+		for len(backups) > s.maxBackups {
+			_ = os.Remove(backups[0])
+			backups = backups[1:]
+		}
+	}
+	return s.open()
+}
@@ -0,0 +1,54 @@
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"template-backend/internal/model"
+	"time"
+)
+
+// HTTPSink 每批以 JSON 数组 POST 到收集器，非 2xx 响应视为失败
+type HTTPSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func NewHTTPSink(url string, headers map[string]string, timeout time.Duration) *HTTPSink {
+	return &HTTPSink{url: url, headers: headers, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *HTTPSink) Write(ctx context.Context, logs []*model.Log) error {
+	body, err := json.Marshal(logs)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("收集器返回 %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (s *HTTPSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,128 @@
+// Package logsink 请求日志的输出目标：数据库、轮转的 JSON Lines 文件、标准输出、HTTP 收集器和 syslog。
+// 每个目标由一个 Worker 驱动，拥有独立的有界缓冲区、批量写入和失败退避重试，慢的目标不会拖住其他目标
+package logsink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	TypeDatabase = "database"
+	TypeFile     = "file"
+	TypeStdout   = "stdout"
+	TypeHTTP     = "http"
+	TypeSyslog   = "syslog"
+)
+
+const (
+	defaultBuffer        = 10000
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5 * time.Second
+	defaultMaxRetries    = 3
+	defaultRetryBackoff  = time.Second
+	maxRetryBackoff      = 30 * time.Second
+	defaultTimeout       = 5 * time.Second
+)
+
+// Sink 一个输出目标，只由所属 Worker 的消费协程调用。Write 返回错误时 Worker 按退避重试整批，
+// 远端目标可能因此收到重复日志
+type Sink interface {
+	Write(ctx context.Context, logs []*model.Log) error
+	Close() error
+}
+
+// New 按配置创建输出目标和驱动它的 Worker，只校验配置，不打开文件或建立连接；写入失败的日志输出到 log
+func New(cfg config.LogSinkConfig, repo repository.LogRepository, log *zap.Logger) (*Worker, error) {
+	if cfg.Name == "" {
+		cfg.Name = cfg.Type
+	}
+	timeout := seconds(cfg.Timeout, defaultTimeout)
+
+	var sink Sink
+	switch cfg.Type {
+	case TypeDatabase:
+		sink = NewDatabaseSink(repo)
+	case TypeFile:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("请求日志输出 %s 未配置 path", cfg.Name)
+		}
+		maxSize := cfg.MaxSize
+		if maxSize <= 0 {
+			maxSize = 100
+		}
+		sink = NewFileSink(cfg.Path, int64(maxSize)<<20, cfg.MaxBackups)
+	case TypeStdout:
+		sink = NewStdoutSink()
+	case TypeHTTP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("请求日志输出 %s 未配置 url", cfg.Name)
+		}
+		sink = NewHTTPSink(cfg.URL, cfg.Headers, timeout)
+	case TypeSyslog:
+		if cfg.Address == "" {
+			return nil, fmt.Errorf("请求日志输出 %s 未配置 address", cfg.Name)
+		}
+		network := cfg.Network
+		if network == "" {
+			network = "udp"
+		}
+		if network != "udp" && network != "tcp" {
+			return nil, fmt.Errorf("请求日志输出 %s 的 network 只支持 udp 或 tcp", cfg.Name)
+		}
+		tag := cfg.Tag
+		if tag == "" {
+			tag = "template-backend"
+		}
+		sink = NewSyslogSink(network, cfg.Address, tag, timeout)
+	case "":
+		return nil, errors.New("请求日志输出未配置 type")
+	default:
+		return nil, fmt.Errorf("不支持的请求日志输出类型: %s", cfg.Type)
+	}
+
+	maxRetries := cfg.MaxRetries
+	switch {
+	case maxRetries < 0:
+		maxRetries = 0
+	case maxRetries == 0:
+		maxRetries = defaultMaxRetries
+	}
+	return NewWorker(sink, Options{
+		Name:          cfg.Name,
+		Buffer:        positive(cfg.Buffer, defaultBuffer),
+		BatchSize:     positive(cfg.BatchSize, defaultBatchSize),
+		FlushInterval: seconds(cfg.FlushInterval, defaultFlushInterval),
+		MaxRetries:    maxRetries,
+		RetryBackoff:  millis(cfg.RetryBackoff, defaultRetryBackoff),
+		Logger:        log,
+	}), nil
+}
+
+func positive(n, fallback int) int {
+	if n <= 0 {
+		return fallback
+	}
+	return n
+}
+
+func seconds(n int, fallback time.Duration) time.Duration {
+	if n <= 0 {
+		return fallback
+	}
+	return time.Duration(n) * time.Second
+}
+
+func millis(n int, fallback time.Duration) time.Duration {
+	if n <= 0 {
+		return fallback
+	}
+	return time.Duration(n) * time.Millisecond
+}
@@ -0,0 +1,255 @@
+package logsink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"testing"
+	"time"
+)
+
+func testLogs() []*model.Log {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []*model.Log{
+		{Timestamp: now, Method: "GET", Path: "/api/users", Status: 200, RequestID: "req-1"},
+		{Timestamp: now, Method: "POST", Path: "/api/login", Status: 500, RequestID: "req-2"},
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.LogSinkConfig
+		ok   bool
+	}{
+		{"database", config.LogSinkConfig{Type: TypeDatabase}, true},
+		{"missing type", config.LogSinkConfig{}, false},
+		{"unknown type", config.LogSinkConfig{Type: "kafka"}, false},
+		{"file without path", config.LogSinkConfig{Type: TypeFile}, false},
+		{"http without url", config.LogSinkConfig{Type: TypeHTTP}, false},
+		{"syslog without address", config.LogSinkConfig{Type: TypeSyslog}, false},
+		{"syslog bad network", config.LogSinkConfig{Type: TypeSyslog, Address: "localhost:514", Network: "unix"}, false},
+		{"syslog", config.LogSinkConfig{Type: TypeSyslog, Address: "localhost:514"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := New(tt.cfg, nil, nil)
+			if (err == nil) != tt.ok {
+				t.Fatalf("New err = %v, want ok = %v", err, tt.ok)
+			}
+			if err == nil && w.Name() != tt.cfg.Type {
+				t.Fatalf("Name = %q, want %q", w.Name(), tt.cfg.Type)
+			}
+		})
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var calls atomic.Int32
+	var received []*model.Log
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次返回 503，模拟收集器暂时不可用
+		if calls.Add(1) == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("X-Token") != "abc" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad headers", http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink := NewHTTPSink(srv.URL, map[string]string{"X-Token": "abc"}, time.Second)
+	defer sink.Close()
+	err := sink.Write(context.Background(), testLogs())
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("first Write err = %v, want 503", err)
+	}
+	if err := sink.Write(context.Background(), testLogs()); err != nil {
+		t.Fatalf("second Write: %v", err)
+	}
+	if len(received) != 2 || received[1].RequestID != "req-2" {
+		t.Fatalf("received = %+v", received)
+	}
+}
+
+func TestHTTPSinkThroughWorker(t *testing.T) {
+	var calls, logs atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var batch []json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&batch)
+		logs.Add(int32(len(batch)))
+	}))
+	defer srv.Close()
+
+	w, err := New(config.LogSinkConfig{Type: TypeHTTP, URL: srv.URL, BatchSize: 2, RetryBackoff: 10}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	for _, log := range append(testLogs(), testLogs()...) {
+		w.Enqueue(log)
+	}
+	stop(t, w)
+
+	if logs.Load() != 4 || w.Failed() != 0 {
+		t.Fatalf("collector received %d logs, failed %d, want 4, 0", logs.Load(), w.Failed())
+	}
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink := NewSyslogSink("udp", conn.LocalAddr().String(), "app", time.Second)
+	defer sink.Close()
+	if err := sink.Write(context.Background(), testLogs()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	buf := make([]byte, 64<<10)
+	for i, wantPri := range []string{"<134>1 ", "<131>1 "} {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("read datagram %d: %v", i, err)
+		}
+		checkSyslogMessage(t, string(buf[:n]), wantPri, testLogs()[i].RequestID)
+	}
+}
+
+func TestSyslogSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	frames := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go readTestFrames(conn, frames)
+		}
+	}()
+
+	sink := NewSyslogSink("tcp", ln.Addr().String(), "app", time.Second)
+	defer sink.Close()
+	if err := sink.Write(context.Background(), testLogs()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	for i, wantPri := range []string{"<134>1 ", "<131>1 "} {
+		checkSyslogMessage(t, receiveFrame(t, frames), wantPri, testLogs()[i].RequestID)
+	}
+
+	// 连接断开后下一次写入重新连接
+	sink.conn.Close()
+	if err := sink.Write(context.Background(), testLogs()[:1]); err == nil {
+		t.Fatal("Write on closed connection succeeded")
+	}
+	if err := sink.Write(context.Background(), testLogs()[:1]); err != nil {
+		t.Fatalf("Write after reconnect: %v", err)
+	}
+	checkSyslogMessage(t, receiveFrame(t, frames), "<134>1 ", "req-1")
+}
+
+func receiveFrame(t *testing.T, frames <-chan string) string {
+	t.Helper()
+	select {
+	case frame := <-frames:
+		return frame
+	case <-time.After(2 * time.Second):
+		t.Fatal("frame not received")
+		return ""
+	}
+}
+
+// readTestFrames 按 RFC 6587 长度前缀读取帧
+func readTestFrames(conn net.Conn, frames chan<- string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		prefix, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(prefix, " "))
+		if err != nil {
+			return
+		}
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return
+		}
+		frames <- string(msg)
+	}
+}
+
+func checkSyslogMessage(t *testing.T, msg, wantPri, wantRequestID string) {
+	t.Helper()
+	if !strings.HasPrefix(msg, wantPri) {
+		t.Fatalf("message %q, want prefix %q", msg, wantPri)
+	}
+	fields := strings.SplitN(msg, " ", 8)
+	if len(fields) != 8 || fields[3] != "app" || fields[5] != "request" || fields[6] != "-" {
+		t.Fatalf("malformed header: %q", msg)
+	}
+	var log model.Log
+	if err := json.Unmarshal([]byte(fields[7]), &log); err != nil {
+		t.Fatalf("message body is not a log: %v", err)
+	}
+	if log.RequestID != wantRequestID {
+		t.Fatalf("requestId = %q, want %q", log.RequestID, wantRequestID)
+	}
+}
+
+func TestFileSinkRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "requests.jsonl")
+	sink := NewFileSink(path, 200, 1)
+	defer sink.Close()
+	for i := 0; i < 3; i++ {
+		if err := sink.Write(context.Background(), testLogs()); err != nil {
+			t.Fatalf("Write #%d: %v", i, err)
+		}
+		// 备份文件名精确到毫秒
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "requests-*.jsonl"))
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want 1 kept", backups)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Fatalf("current file has %d lines, want 2", lines)
+	}
+}
@@ -0,0 +1,45 @@
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"template-backend/internal/model"
+)
+
+// StdoutSink 以 JSON Lines 输出到标准输出，便于容器环境由采集器收集
+type StdoutSink struct {
+	out io.Writer
+}
+
+func NewStdoutSink() *StdoutSink {
+	return &StdoutSink{out: os.Stdout}
+}
+
+func (s *StdoutSink) Write(_ context.Context, logs []*model.Log) error {
+	buf, err := encodeLines(logs)
+	if err != nil {
+		return err
+	}
+	_, err = s.out.Write(buf)
+	return err
+}
+
+func (s *StdoutSink) Close() error {
+	return nil
+}
+
+// encodeLines 每条日志编码为一行 JSON
+func encodeLines(logs []*model.Log) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, log := range logs {
+		if err := enc.Encode(log); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
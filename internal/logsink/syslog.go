@@ -0,0 +1,110 @@
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"template-backend/internal/model"
+	"time"
+)
+
+// syslog 设施 local0，严重级别按状态码：5xx 为 err，4xx 为 warning，其余为 info
+const (
+	syslogFacility = 16
+	syslogErr      = 3
+	syslogWarning  = 4
+	syslogInfo     = 6
+)
+
+// SyslogSink 以 RFC 5424 格式发送到 syslog 服务，消息体为日志 JSON。
+// UDP 每条一个数据报；TCP 按 RFC 6587 的长度前缀分帧。连接在首次写入时建立，出错后下次重连
+type SyslogSink struct {
+	network  string
+	address  string
+	tag      string
+	timeout  time.Duration
+	hostname string
+	pid      string
+
+	conn net.Conn
+}
+
+func NewSyslogSink(network, address, tag string, timeout time.Duration) *SyslogSink {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogSink{
+		network:  network,
+		address:  address,
+		tag:      tag,
+		timeout:  timeout,
+		hostname: hostname,
+		pid:      strconv.Itoa(os.Getpid()),
+	}
+}
+
+func (s *SyslogSink) Write(ctx context.Context, logs []*model.Log) error {
+	if s.conn == nil {
+		dialer := net.Dialer{Timeout: s.timeout}
+		conn, err := dialer.DialContext(ctx, s.network, s.address)
+		if err != nil {
+			return fmt.Errorf("连接 syslog 失败: %w", err)
+		}
+		s.conn = conn
+	}
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(s.timeout)); err != nil {
+		_ = s.Close()
+		return err
+	}
+	for _, log := range logs {
+		msg, err := s.format(log)
+		if err != nil {
+			return err
+		}
+		if s.network == "tcp" {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		if _, err := s.conn.Write(msg); err != nil {
+			_ = s.Close()
+			return fmt.Errorf("发送 syslog 失败: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SyslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (s *SyslogSink) format(log *model.Log) ([]byte, error) {
+	severity := syslogInfo
+	switch {
+	case log.Status >= 500:
+		severity = syslogErr
+	case log.Status >= 400:
+		severity = syslogWarning
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %s request - ",
+		syslogFacility*8+severity, log.Timestamp.Format(time.RFC3339Nano), s.hostname, s.tag, s.pid)
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(log); err != nil {
+		return nil, err
+	}
+	// 去掉 Encode 追加的换行
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
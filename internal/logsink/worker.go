@@ -0,0 +1,187 @@
+package logsink
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"template-backend/internal/model"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Options Worker 的缓冲、批量和重试参数
+type Options struct {
+	Name          string
+	Buffer        int           // 缓冲区容量
+	BatchSize     int           // 每批条数
+	FlushInterval time.Duration // 不足一批时的写入间隔
+	MaxRetries    int           // 失败后的重试次数
+	RetryBackoff  time.Duration // 首次重试等待，之后每次翻倍
+	Logger        *zap.Logger   // 写入失败的日志，为 nil 时不输出
+}
+
+// Worker 驱动一个输出目标：Enqueue 投递到有界缓冲区，消费协程按批写入，失败时退避重试，
+// 重试用尽后丢弃该批并计入 Failed
+type Worker struct {
+	name    string
+	sink    Sink
+	opts    Options
+	entries chan *model.Log
+
+	// closed 在 mu 写锁下置位并关闭 entries，Enqueue 持读锁检查，停止后的投递直接丢弃而不是向已关闭的通道发送
+	mu     sync.RWMutex
+	closed bool
+
+	done      chan struct{}
+	abort     chan struct{} // Stop 超时后关闭，放弃正在等待的重试
+	abortOnce sync.Once
+
+	dropped atomic.Int64
+	failed  atomic.Int64
+	onFlush func(count int, elapsed time.Duration, err error)
+}
+
+func NewWorker(sink Sink, opts Options) *Worker {
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+	return &Worker{
+		name:    opts.Name,
+		sink:    sink,
+		opts:    opts,
+		entries: make(chan *model.Log, opts.Buffer),
+		done:    make(chan struct{}),
+		abort:   make(chan struct{}),
+	}
+}
+
+// Name 输出目标名称
+func (w *Worker) Name() string {
+	return w.name
+}
+
+// Enqueue 投递一条日志，缓冲区满或已停止时丢弃并返回 false；
+// 服务关闭超时后仍在处理的请求可能在 Stop 之后调用
+func (w *Worker) Enqueue(log *model.Log) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.dropped.Add(1)
+		return false
+	}
+	select {
+	case w.entries <- log:
+		return true
+	default:
+		w.dropped.Add(1)
+		return false
+	}
+}
+
+// Pending 缓冲区中等待写入的日志数
+func (w *Worker) Pending() int {
+	return len(w.entries)
+}
+
+// Capacity 缓冲区容量
+func (w *Worker) Capacity() int {
+	return cap(w.entries)
+}
+
+// Dropped 因缓冲区已满或已停止被丢弃的日志总数
+func (w *Worker) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Failed 重试用尽后被丢弃的日志总数
+func (w *Worker) Failed() int64 {
+	return w.failed.Load()
+}
+
+// OnFlush 设置每批写入完成（含重试）后的回调，需在 Start 之前设置
+func (w *Worker) OnFlush(fn func(count int, elapsed time.Duration, err error)) {
+	w.onFlush = fn
+}
+
+// Start 启动消费协程
+func (w *Worker) Start() {
+	go w.consume()
+}
+
+// Stop 关闭缓冲区并等待写完剩余日志后关闭输出目标；ctx 结束时放弃正在等待的重试并返回错误
+func (w *Worker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.entries)
+	}
+	w.mu.Unlock()
+	select {
+	case <-w.done:
+		return w.sink.Close()
+	case <-ctx.Done():
+		w.abortOnce.Do(func() { close(w.abort) })
+		return fmt.Errorf("请求日志输出 %s 未写完，缓冲区剩余 %d 条: %w", w.name, len(w.entries), ctx.Err())
+	}
+}
+
+func (w *Worker) consume() {
+	defer close(w.done)
+	batch := make([]*model.Log, 0, w.opts.BatchSize)
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry, ok := <-w.entries:
+			if !ok {
+				if len(batch) > 0 {
+					w.flush(batch)
+				}
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= w.opts.BatchSize {
+				w.flush(batch)
+				batch = make([]*model.Log, 0, w.opts.BatchSize)
+			}
+
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = make([]*model.Log, 0, w.opts.BatchSize)
+			}
+		}
+	}
+}
+
+func (w *Worker) flush(batch []*model.Log) {
+	start := time.Now()
+	backoff := w.opts.RetryBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = w.sink.Write(context.Background(), batch); err == nil || attempt >= w.opts.MaxRetries {
+			break
+		}
+		w.opts.Logger.Warn("Log sink write failed, retrying",
+			zap.String("sink", w.name), zap.Int("count", len(batch)), zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff), zap.Error(err))
+		select {
+		case <-time.After(backoff):
+		case <-w.abort:
+			attempt = w.opts.MaxRetries
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+
+	if err != nil {
+		w.failed.Add(int64(len(batch)))
+		w.opts.Logger.Error("Log sink write failed, batch dropped",
+			zap.String("sink", w.name), zap.Int("count", len(batch)), zap.Error(err))
+	}
+	if w.onFlush != nil {
+		w.onFlush(len(batch), time.Since(start), err)
+	}
+}
@@ -0,0 +1,234 @@
+package logsink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"template-backend/internal/model"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeSink 记录每次写入的批次，前 failures 次写入返回错误
+type fakeSink struct {
+	mu       sync.Mutex
+	failures int // 小于 0 时始终失败
+	attempts []time.Time
+	batches  [][]*model.Log
+	closed   bool
+}
+
+func (s *fakeSink) Write(_ context.Context, logs []*model.Log) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts = append(s.attempts, time.Now())
+	if s.failures != 0 {
+		s.failures--
+		return errors.New("collector unavailable")
+	}
+	s.batches = append(s.batches, append([]*model.Log(nil), logs...))
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *fakeSink) sizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := make([]int, len(s.batches))
+	for i, b := range s.batches {
+		sizes[i] = len(b)
+	}
+	return sizes
+}
+
+func testOptions() Options {
+	return Options{Name: "test", Buffer: 100, BatchSize: 100, FlushInterval: time.Hour, RetryBackoff: 10 * time.Millisecond}
+}
+
+func stop(t *testing.T, w *Worker) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+func TestWorkerBatchesBySize(t *testing.T) {
+	sink := &fakeSink{}
+	opts := testOptions()
+	opts.BatchSize = 3
+	w := NewWorker(sink, opts)
+	w.Start()
+	for i := 0; i < 7; i++ {
+		w.Enqueue(&model.Log{ID: uint(i)})
+	}
+	stop(t, w)
+
+	got := sink.sizes()
+	if len(got) != 3 || got[0] != 3 || got[1] != 3 || got[2] != 1 {
+		t.Fatalf("batch sizes = %v, want [3 3 1]", got)
+	}
+	if !sink.closed {
+		t.Fatal("sink not closed after Stop")
+	}
+}
+
+func TestWorkerFlushesOnInterval(t *testing.T) {
+	sink := &fakeSink{}
+	opts := testOptions()
+	opts.FlushInterval = 20 * time.Millisecond
+	w := NewWorker(sink, opts)
+	w.Start()
+	defer stop(t, w)
+	w.Enqueue(&model.Log{})
+	w.Enqueue(&model.Log{})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.sizes()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("partial batch not flushed by interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := sink.sizes(); got[0] != 2 {
+		t.Fatalf("batch sizes = %v, want [2]", got)
+	}
+}
+
+func TestWorkerRetriesWithBackoff(t *testing.T) {
+	sink := &fakeSink{failures: 2}
+	opts := testOptions()
+	opts.MaxRetries = 3
+	w := NewWorker(sink, opts)
+	var flushErr error
+	w.OnFlush(func(_ int, _ time.Duration, err error) { flushErr = err })
+	w.Start()
+	w.Enqueue(&model.Log{})
+	stop(t, w)
+
+	if flushErr != nil || w.Failed() != 0 {
+		t.Fatalf("flush err = %v, failed = %d, want success", flushErr, w.Failed())
+	}
+	if len(sink.attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(sink.attempts))
+	}
+	first, second := sink.attempts[1].Sub(sink.attempts[0]), sink.attempts[2].Sub(sink.attempts[1])
+	if first < opts.RetryBackoff || second < 2*opts.RetryBackoff {
+		t.Fatalf("backoff = %v, %v, want >= %v, %v", first, second, opts.RetryBackoff, 2*opts.RetryBackoff)
+	}
+}
+
+func TestWorkerDropsBatchAfterRetries(t *testing.T) {
+	sink := &fakeSink{failures: -1}
+	opts := testOptions()
+	opts.MaxRetries = 2
+	w := NewWorker(sink, opts)
+	var flushErr error
+	w.OnFlush(func(_ int, _ time.Duration, err error) { flushErr = err })
+	w.Start()
+	w.Enqueue(&model.Log{})
+	w.Enqueue(&model.Log{})
+	stop(t, w)
+
+	if flushErr == nil {
+		t.Fatal("OnFlush err = nil, want write error")
+	}
+	if len(sink.attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(sink.attempts))
+	}
+	if w.Failed() != 2 {
+		t.Fatalf("Failed = %d, want 2", w.Failed())
+	}
+}
+
+func TestWorkerLogsFailuresToInjectedLogger(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	opts := testOptions()
+	opts.MaxRetries = 1
+	opts.Logger = zap.New(core)
+	w := NewWorker(&fakeSink{failures: -1}, opts)
+	w.Start()
+	w.Enqueue(&model.Log{})
+	stop(t, w)
+
+	want := []string{"Log sink write failed, retrying", "Log sink write failed, batch dropped"}
+	entries := logs.AllUntimed()
+	if len(entries) != len(want) {
+		t.Fatalf("logged %d entries, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Message != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, entry.Message, want[i])
+		}
+	}
+}
+
+func TestWorkerDropsWhenBufferFull(t *testing.T) {
+	sink := &fakeSink{}
+	opts := testOptions()
+	opts.Buffer = 2
+	w := NewWorker(sink, opts)
+	for i, want := range []bool{true, true, false} {
+		if got := w.Enqueue(&model.Log{}); got != want {
+			t.Fatalf("Enqueue #%d = %v, want %v", i, got, want)
+		}
+	}
+	if w.Pending() != 2 || w.Dropped() != 1 {
+		t.Fatalf("pending = %d, dropped = %d, want 2, 1", w.Pending(), w.Dropped())
+	}
+
+	// 启动前缓冲的日志在 Stop 时全部写完
+	w.Start()
+	stop(t, w)
+	if got := sink.sizes(); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("batch sizes = %v, want [2]", got)
+	}
+}
+
+func TestWorkerEnqueueAfterStop(t *testing.T) {
+	w := NewWorker(&fakeSink{}, testOptions())
+	w.Start()
+	stop(t, w)
+	if w.Enqueue(&model.Log{}) {
+		t.Fatal("Enqueue after Stop = true, want false")
+	}
+	if w.Dropped() != 1 {
+		t.Fatalf("Dropped = %d, want 1", w.Dropped())
+	}
+	// 重复 Stop 不应 panic
+	stop(t, w)
+}
+
+func TestWorkerStopAbortsPendingRetry(t *testing.T) {
+	sink := &fakeSink{failures: -1}
+	opts := testOptions()
+	opts.MaxRetries = 5
+	opts.RetryBackoff = time.Hour
+	w := NewWorker(sink, opts)
+	w.Start()
+	w.Enqueue(&model.Log{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := w.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop err = %v, want deadline exceeded", err)
+	}
+	select {
+	case <-w.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("consumer still waiting on retry after Stop timed out")
+	}
+	if w.Failed() != 1 {
+		t.Fatalf("Failed = %d, want 1", w.Failed())
+	}
+}
@@ -0,0 +1,124 @@
+// Package metrics Prometheus 指标：HTTP 请求、数据库连接池和请求日志写入
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"template-backend/internal/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+// unmatchedRoute 未匹配到路由的请求统一记为该值，避免按原始路径产生大量标签
+const unmatchedRoute = "unmatched"
+
+// Metrics 每个应用容器一个独立的注册表，测试中可以重复创建
+type Metrics struct {
+	registry         *prometheus.Registry
+	requests         *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	logBatchDuration *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP 请求数，route 为 gin 路由模板",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP 请求耗时，route 为 gin 路由模板",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		logBatchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "request_log_batch_insert_duration_seconds",
+			Help:    "请求日志批量写入输出目标的耗时，包含重试",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"sink", "result"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.logBatchDuration,
+	)
+	return m
+}
+
+// RegisterDB 采集数据库连接池状态
+func (m *Metrics) RegisterDB(db *gorm.DB, name string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return m.registry.Register(collectors.NewDBStatsCollector(sqlDB, name))
+}
+
+// RegisterLogWriter 按输出目标采集请求日志缓冲区的积压数、丢弃数、写入失败数和批量写入耗时
+func (m *Metrics) RegisterLogWriter(w service.LogWriter) error {
+	for _, sink := range w.Sinks() {
+		labels := prometheus.Labels{"sink": sink.Name()}
+		depth := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "request_log_queue_depth",
+			Help:        "等待写入的请求日志数",
+			ConstLabels: labels,
+		}, func() float64 { return float64(sink.Pending()) })
+		capacity := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "request_log_queue_capacity",
+			Help:        "请求日志缓冲区容量",
+			ConstLabels: labels,
+		}, func() float64 { return float64(sink.Capacity()) })
+		dropped := prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "request_log_dropped_total",
+			Help:        "缓冲区已满或已停止时被丢弃的请求日志数",
+			ConstLabels: labels,
+		}, func() float64 { return float64(sink.Dropped()) })
+		failed := prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "request_log_failed_total",
+			Help:        "重试用尽后被丢弃的请求日志数",
+			ConstLabels: labels,
+		}, func() float64 { return float64(sink.Failed()) })
+		for _, c := range []prometheus.Collector{depth, capacity, dropped, failed} {
+			if err := m.registry.Register(c); err != nil {
+				return err
+			}
+		}
+
+		sink.OnFlush(func(_ int, elapsed time.Duration, err error) {
+			result := "success"
+			if err != nil {
+				result = "error"
+			}
+			m.logBatchDuration.WithLabelValues(sink.Name(), result).Observe(elapsed.Seconds())
+		})
+	}
+	return nil
+}
+
+// Middleware 按路由模板统计请求数和耗时
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.requests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.requestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler Prometheus 抓取接口
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
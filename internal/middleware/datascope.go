@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"template-backend/internal/service"
+	"template-backend/pkg/datascope"
+	"template-backend/pkg/logger"
+	"template-backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DataScopeMiddleware 解析当前用户角色的数据范围并放入请求 context，
+// 仓储层使用 db.WithContext(ctx) 时由 datascope 插件自动过滤
+func DataScopeMiddleware(permissionService service.PermissionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		scope, err := permissionService.DataScope(c.Request.Context(), userID.(uint))
+		if err != nil {
+			logger.FromContext(c.Request.Context()).Error("load data scope failed", zap.Any("userID", userID), zap.Error(err))
+			utils.JSON(c, utils.Error("权限校验失败", http.StatusInternalServerError))
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(datascope.WithScope(c.Request.Context(), scope))
+		c.Next()
+	}
+}
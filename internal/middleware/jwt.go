@@ -1,31 +1,26 @@
 package middleware
 
 import (
-	"errors"
 	"go.uber.org/zap"
 	"net/http"
 	"strings"
 	"template-backend/config"
+	"template-backend/internal/service"
 	"template-backend/pkg/utils"
 
 	"template-backend/pkg/logger"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte("secret123")
-
-func JWTMiddleware() gin.HandlerFunc {
+func JWTMiddleware(cfg *config.AppConfig, tokenService service.TokenService, sessionService service.SessionService) gin.HandlerFunc {
+	//获取不需要进行验证的 url
+	skipAuthUrls := cfg.JWT.SkipAuthUrls
 	return func(c *gin.Context) {
-		//获取不需要进行验证的 url
-		skipAuthUrls := config.GetConfig().JWT.SkipAuthUrls
-		for _, url := range skipAuthUrls {
-			if strings.Contains(c.Request.URL.Path, url) {
-				logger.Logger().Info("url should skip auth ", zap.String("url", url))
-				c.Next()
-				return
-			}
+		if url, ok := utils.MatchPath(skipAuthUrls, c.Request.URL.Path); ok {
+			logger.FromContext(c.Request.Context()).Info("url should skip auth ", zap.String("url", url))
+			c.Next()
+			return
 		}
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -42,7 +37,7 @@ func JWTMiddleware() gin.HandlerFunc {
 		}
 
 		tokenStr := parts[1]
-		claims, err := parseToken(tokenStr)
+		claims, err := tokenService.ParseAccessToken(c.Request.Context(), tokenStr)
 		if err != nil {
 			utils.JSON(c, utils.Error(err.Error(), http.StatusUnauthorized))
 			c.Abort()
@@ -53,31 +48,23 @@ func JWTMiddleware() gin.HandlerFunc {
 		if username, ok := claims["username"].(string); ok {
 			c.Set("username", username)
 		}
-		// 将 username 放到 context，供 handler 使用
-		if userId, ok := claims["userId"].(string); ok {
-			c.Set("userId", userId)
+		// 将 userID 放到 context，供 handler 使用（jwt 数字类型解析为 float64）
+		if userId, ok := claims["userId"].(float64); ok {
+			c.Set("userID", uint(userId))
 		}
-
-		c.Next()
-	}
-}
-
-func parseToken(tokenStr string) (map[string]interface{}, error) {
-	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+		// 令牌标识，供登出时吊销
+		if jti, ok := claims["jti"].(string); ok {
+			c.Set("jti", jti)
 		}
-		return jwtSecret, nil
-	})
-	if err != nil || !token.Valid {
-		return nil, errors.New("invalid token")
-	}
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		m := map[string]interface{}{}
-		for k, v := range claims {
-			m[k] = v
+		if familyID, ok := claims["fid"].(string); ok {
+			c.Set("familyID", familyID)
+			// 记录会话最后活跃时间
+			sessionService.Touch(c.Request.Context(), familyID, c.ClientIP())
 		}
-		return m, nil
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			c.Set("tokenExpiresAt", exp.Time)
+		}
+
+		c.Next()
 	}
-	return nil, errors.New("cannot parse claims")
 }
@@ -6,34 +6,59 @@ import (
 	"io"
 	"net/http"
 	"strings"
-	"template-backend/internal/global"
 	"template-backend/internal/model"
+	"template-backend/internal/service"
 	"template-backend/pkg/logger"
+	"template-backend/pkg/redact"
+	"template-backend/pkg/tracing"
+	"template-backend/pkg/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-// ResponseWriter 是一个包装的 ResponseWriter，用于捕获响应内容
+// ResponseWriter 是一个包装的 ResponseWriter，用于捕获响应内容；
+// 只保留前 limit 字节，导出下载等大响应不会整体留在内存中
 type ResponseWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body     *bytes.Buffer
+	limit    int
+	overflow bool // 响应超过 limit，记录的内容已截断
 }
 
-func (w ResponseWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if n := w.capacity(len(b)); n > 0 {
+		w.body.Write(b[:n])
+	}
 	return w.ResponseWriter.Write(b)
 }
 
-func (w ResponseWriter) WriteString(s string) (int, error) {
-	w.body.WriteString(s)
+func (w *ResponseWriter) WriteString(s string) (int, error) {
+	if n := w.capacity(len(s)); n > 0 {
+		w.body.WriteString(s[:n])
+	}
 	return w.ResponseWriter.WriteString(s)
 }
 
+// capacity 本次写入中还能记录的字节数
+func (w *ResponseWriter) capacity(size int) int {
+	remaining := max(w.limit-w.body.Len(), 0)
+	if size > remaining {
+		w.overflow = true
+		return remaining
+	}
+	return size
+}
+
+// omittedBody 按路由配置不记录的请求体或响应体
+const omittedBody = "(omitted)"
+
 // LoggingConfig 日志配置
 type LoggingConfig struct {
 	Logger     *zap.Logger
+	Writer     service.LogWriter // 为 nil 时只输出日志，不分发到输出目标
+	Redactor   *redact.Redactor  // 请求体、响应体和查询参数的脱敏规则，为 nil 时使用默认规则
 	SkipPaths  []string
 	MaxBodyLen int
 }
@@ -50,20 +75,28 @@ func LoggingMiddlewareWithConfig(config LoggingConfig) gin.HandlerFunc {
 		maxBodyLen = 1024 // 默认最大1KB
 	}
 
+	redactor := config.Redactor
+	if redactor == nil {
+		redactor = redact.New(redact.Config{})
+	}
+
 	return func(c *gin.Context) {
 		for _, url := range config.SkipPaths {
 			if c.Request.Method == http.MethodOptions || strings.Contains(c.Request.URL.Path, url) {
-				logger.Logger().Info("no need to log this url ", zap.String("url", url), zap.String("method", c.Request.Method))
+				logger.FromContext(c.Request.Context()).Info("no need to log this url ", zap.String("url", url), zap.String("method", c.Request.Method))
 				c.Next()
 				return
 			}
 		}
 
 		start := time.Now()
+		policy := redactor.For(c.Request.Method, c.FullPath())
 
 		// 读取请求体（添加长度限制）
 		var requestBody interface{}
-		if c.Request.Body != nil && c.Request.Method != http.MethodGet {
+		if policy.SkipRequest {
+			requestBody = omittedBody
+		} else if c.Request.Body != nil && c.Request.Method != http.MethodGet {
 			// 限制读取的字节数
 			bodyBytes, _ := io.ReadAll(io.LimitReader(c.Request.Body, int64(maxBodyLen)))
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes)) // 重新设置 Body
@@ -79,14 +112,19 @@ func LoggingMiddlewareWithConfig(config LoggingConfig) gin.HandlerFunc {
 			} else {
 				requestBody = string(bodyBytes)
 			}
+			// 脱敏后再写入日志通道和 zap
+			requestBody = policy.Value(requestBody)
 		}
 
 		// 包装 ResponseWriter 以捕获响应
 		blw := &ResponseWriter{
 			ResponseWriter: c.Writer,
 			body:           bytes.NewBufferString(""),
+			limit:          maxBodyLen,
+		}
+		if !policy.SkipResponse {
+			c.Writer = blw
 		}
-		c.Writer = blw
 
 		// 处理请求
 		c.Next()
@@ -104,8 +142,9 @@ func LoggingMiddlewareWithConfig(config LoggingConfig) gin.HandlerFunc {
 		var responseBody interface{}
 		responseBodyBytes := blw.body.Bytes()
 		// 限制响应体大小
-		if len(responseBodyBytes) > maxBodyLen {
-			responseBodyBytes = responseBodyBytes[:maxBodyLen]
+		if policy.SkipResponse {
+			responseBody = omittedBody
+		} else if blw.overflow {
 			responseBody = string(responseBodyBytes) + "...(truncated)"
 			truncated = true
 		} else if len(responseBodyBytes) > 0 {
@@ -120,13 +159,17 @@ func LoggingMiddlewareWithConfig(config LoggingConfig) gin.HandlerFunc {
 				responseBody = string(responseBodyBytes)
 			}
 		}
+		if responseBody != nil && !policy.SkipResponse {
+			responseBody = policy.Value(responseBody)
+		}
+		query := policy.Text(c.Request.URL.RawQuery)
 
 		// 创建日志对象，符合 model.Log 结构
 		logEntry := &model.Log{
 			Timestamp:     time.Now(),
 			Method:        c.Request.Method,
 			Path:          c.Request.URL.Path,
-			Query:         c.Request.URL.RawQuery,
+			Query:         query,
 			IP:            clientIP,
 			UserAgent:     userAgent,
 			Status:        c.Writer.Status(),
@@ -137,6 +180,8 @@ func LoggingMiddlewareWithConfig(config LoggingConfig) gin.HandlerFunc {
 			Errors:        "", // 错误信息将在下面处理
 			ContentLength: c.Request.ContentLength,
 			Truncated:     truncated,
+			TraceID:       tracing.TraceID(c.Request.Context()),
+			RequestID:     c.GetString(utils.RequestIDKey),
 			CreatedAt:     time.Now(),
 		}
 		// 处理错误信息
@@ -149,23 +194,28 @@ func LoggingMiddlewareWithConfig(config LoggingConfig) gin.HandlerFunc {
 			logEntry.Errors = strings.Join(errors, "\n")
 		}
 
-		select {
-		case global.GetLogChan() <- logEntry: // 这里应该发送到通道
-		default:
-			// 通道满时丢弃日志，避免阻塞
+		if config.Writer != nil {
+			// 输出目标缓冲区满时丢弃日志，避免阻塞
+			config.Writer.Enqueue(logEntry)
 		}
 
 		// 记录日志
 		fields := []zap.Field{
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.Request.URL.Path),
-			zap.String("query", c.Request.URL.RawQuery),
+			zap.String("query", query),
 			zap.String("ip", clientIP),
 			zap.String("user-agent", userAgent),
 			zap.Int("status", c.Writer.Status()),
 			zap.Duration("latency", latency),
 			zap.String("handler", c.HandlerName()),
 		}
+		if logEntry.RequestID != "" {
+			fields = append(fields, zap.String("requestId", logEntry.RequestID))
+		}
+		if logEntry.TraceID != "" {
+			fields = append(fields, zap.String("traceId", logEntry.TraceID))
+		}
 
 		// 只有当请求体不为空时才记录
 		if requestBody != nil {
@@ -187,9 +237,11 @@ func LoggingMiddlewareWithConfig(config LoggingConfig) gin.HandlerFunc {
 }
 
 // 默认的日志中间件
-func EnhancedLoggingMiddleware(logger *zap.Logger) gin.HandlerFunc {
+func EnhancedLoggingMiddleware(logger *zap.Logger, writer service.LogWriter, redactor *redact.Redactor) gin.HandlerFunc {
 	return LoggingMiddlewareWithConfig(LoggingConfig{
-		Logger: logger,
+		Logger:   logger,
+		Writer:   writer,
+		Redactor: redactor,
 		SkipPaths: []string{
 			"/health",
 			"/metrics",
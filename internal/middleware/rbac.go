@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"template-backend/config"
+	"template-backend/internal/service"
+	"template-backend/pkg/logger"
+	"template-backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RBACMiddleware 根据路由模板和请求方法匹配 API 资源，校验当前用户的角色是否拥有该资源
+func RBACMiddleware(cfg *config.AppConfig, permissionService service.PermissionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 与 JWT 中间件保持一致，跳过无需鉴权的 url
+		if _, ok := utils.MatchPath(cfg.JWT.SkipAuthUrls, c.Request.URL.Path); ok {
+			c.Next()
+			return
+		}
+
+		// 未匹配到路由时交给 gin 返回 404
+		routePath := c.FullPath()
+		if routePath == "" {
+			c.Next()
+			return
+		}
+
+		resource, err := permissionService.MatchAPI(c.Request.Context(), c.Request.Method, routePath)
+		if err != nil {
+			logger.FromContext(c.Request.Context()).Error("load api resources failed", zap.Error(err))
+			utils.JSON(c, utils.Error("权限校验失败", http.StatusInternalServerError))
+			c.Abort()
+			return
+		}
+		if resource == nil {
+			if cfg.RBAC.DenyUnmatched {
+				utils.JSON(c, utils.Error("接口未登记权限: "+c.Request.Method+" "+routePath, http.StatusForbidden))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if resource.Status != 1 {
+			utils.JSON(c, utils.Error("接口已禁用: "+resource.PermissionCode, http.StatusForbidden))
+			c.Abort()
+			return
+		}
+		if resource.RequiresAuth == 0 {
+			c.Next()
+			return
+		}
+
+		userID, exists := c.Get("userID")
+		if !exists {
+			utils.JSON(c, utils.Error("未授权", http.StatusUnauthorized))
+			c.Abort()
+			return
+		}
+
+		allowed, err := permissionService.HasPermission(c.Request.Context(), userID.(uint), resource.ID)
+		if err != nil {
+			logger.FromContext(c.Request.Context()).Error("check permission failed", zap.Any("userID", userID), zap.Error(err))
+			utils.JSON(c, utils.Error("权限校验失败", http.StatusInternalServerError))
+			c.Abort()
+			return
+		}
+		if !allowed {
+			utils.JSON(c, utils.Error("缺少权限: "+resource.PermissionCode, http.StatusForbidden))
+			c.Abort()
+			return
+		}
+
+		c.Set("permissionCode", resource.PermissionCode)
+		c.Next()
+	}
+}
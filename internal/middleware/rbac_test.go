@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/internal/service"
+	"template-backend/internal/testdb"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRBACMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	db := testdb.Open(t)
+	users, roles, resources := repository.NewUserRepository(db), repository.NewRoleRepository(db), repository.NewResourceRepository(db)
+
+	api := func(code, method, path string) *model.Resource {
+		resource := &model.Resource{ResourceName: code, PermissionCode: code, Type: model.ResourceTypeAPI, ResourcePath: &path, HTTPMethod: &method}
+		if err := resources.Create(ctx, resource); err != nil {
+			t.Fatalf("create resource %s: %v", code, err)
+		}
+		return resource
+	}
+	list := api("log:list", "GET", "/api/logs")
+	remove := api("log:delete", "DELETE", "/api/logs/:id")
+	ping := api("ping", "GET", "/api/ping")
+	disabled := api("log:purge", "POST", "/api/logs/purge")
+	if err := resources.Update(ctx, ping.ID, map[string]interface{}{"requires_auth": 0}); err != nil {
+		t.Fatalf("mark public: %v", err)
+	}
+	if err := resources.Update(ctx, disabled.ID, map[string]interface{}{"status": 0}); err != nil {
+		t.Fatalf("disable resource: %v", err)
+	}
+
+	role := func(code string, status int, grants ...*model.Resource) *model.Role {
+		r := &model.Role{RoleName: code, RoleCode: code}
+		if err := roles.Create(ctx, r); err != nil {
+			t.Fatalf("create role %s: %v", code, err)
+		}
+		if status != 1 {
+			if err := db.Model(r).Update("status", status).Error; err != nil {
+				t.Fatalf("update role status: %v", err)
+			}
+		}
+		ids := make([]uint, 0, len(grants))
+		for _, g := range grants {
+			ids = append(ids, uint(g.ID))
+		}
+		if err := roles.UpdatePermissions(ctx, r.ID, ids); err != nil {
+			t.Fatalf("grant %s: %v", code, err)
+		}
+		return r
+	}
+	user := func(name string, rs ...*model.Role) uint {
+		u := &model.User{Username: name}
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatalf("create user %s: %v", name, err)
+		}
+		ids := make([]uint, 0, len(rs))
+		for _, r := range rs {
+			ids = append(ids, r.ID)
+		}
+		if err := users.AssignRoles(ctx, u.ID, ids); err != nil {
+			t.Fatalf("assign roles %s: %v", name, err)
+		}
+		return u.ID
+	}
+	auditor := user("alice", role("auditor", 1, list))
+	suspended := user("bob", role("suspended", 0, list))
+	admin := user("carol", role("super_admin", 1))
+	nobody := user("dave")
+
+	cfg := &config.AppConfig{}
+	cfg.JWT.SkipAuthUrls = []string{"/api/auth/login"}
+	cfg.RBAC.DenyUnmatched = true
+	cfg.RBAC.SuperRoles = []string{"super_admin"}
+	permCache := service.NewPermissionCache()
+	permissions := service.NewPermissionService(resources, roles, users, cfg.RBAC.SuperRoles, time.Hour, permCache)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		// 模拟 JWT 中间件写入的用户 ID
+		if id, err := strconv.Atoi(c.GetHeader("X-User-ID")); err == nil {
+			c.Set("userID", uint(id))
+		}
+	}, RBACMiddleware(cfg, permissions))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/logs", ok)
+	r.DELETE("/api/logs/:id", ok)
+	r.GET("/api/ping", ok)
+	r.POST("/api/logs/purge", ok)
+	r.GET("/api/unregistered", ok)
+	r.POST("/api/auth/login", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		userID uint
+		status int
+	}{
+		{"角色已授权", "GET", "/api/logs", auditor, http.StatusOK},
+		{"角色未授权", "DELETE", "/api/logs/1", auditor, http.StatusForbidden},
+		{"角色已禁用", "GET", "/api/logs", suspended, http.StatusForbidden},
+		{"超级管理员", "DELETE", "/api/logs/1", admin, http.StatusOK},
+		{"没有角色", "GET", "/api/logs", nobody, http.StatusForbidden},
+		{"未登录", "GET", "/api/logs", 0, http.StatusUnauthorized},
+		{"公开接口", "GET", "/api/ping", 0, http.StatusOK},
+		{"接口已禁用", "POST", "/api/logs/purge", admin, http.StatusForbidden},
+		{"未登记的接口", "GET", "/api/unregistered", admin, http.StatusForbidden},
+		{"免登录地址", "POST", "/api/auth/login", 0, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.userID != 0 {
+				req.Header.Set("X-User-ID", strconv.Itoa(int(tt.userID)))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("%s %s status = %d, want %d, body = %s", tt.method, tt.path, w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+
+	// 授权变更后失效缓存，下一次请求立即生效
+	auditorRole, err := roles.GetByCode(ctx, "auditor")
+	if err != nil {
+		t.Fatalf("get role: %v", err)
+	}
+	if err := roles.UpdatePermissions(ctx, auditorRole.ID, []uint{uint(list.ID), uint(remove.ID)}); err != nil {
+		t.Fatalf("grant delete: %v", err)
+	}
+	permCache.Invalidate()
+	req := httptest.NewRequest(http.MethodDelete, "/api/logs/1", nil)
+	req.Header.Set("X-User-ID", strconv.Itoa(int(auditor)))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("after invalidate status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
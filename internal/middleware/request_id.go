@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"template-backend/pkg/logger"
+	"template-backend/pkg/tracing"
+	"template-backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	// maxRequestIDLen 调用方传入的请求 ID 超过该长度或包含其他字符时重新生成
+	maxRequestIDLen = 128
+)
+
+// RequestIDMiddleware 沿用请求头中的 X-Request-ID，没有时生成，并写回响应头。
+// 请求 ID 放入 gin.Context（utils.RequestIDKey），同时生成带请求 ID 和 trace ID 的 logger 放入请求 context，
+// 服务和仓储通过 logger.FromContext(ctx) 输出的日志都能关联到该请求
+func RequestIDMiddleware(log *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set(utils.RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		ctx := c.Request.Context()
+		fields := []zap.Field{zap.String("requestId", requestID)}
+		if traceID := tracing.TraceID(ctx); traceID != "" {
+			fields = append(fields, zap.String("traceId", traceID))
+		}
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request_id", requestID))
+		c.Request = c.Request.WithContext(logger.WithContext(ctx, log.With(fields...)))
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,301 @@
+package migrations
+
+import (
+	"template-backend/pkg/migrate"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下结构体是引入版本化迁移时各表结构的快照，与 internal/model 中的模型相互独立：
+// 模型之后的变更必须通过新的迁移完成，不能修改这里。
+// 关联关系由 user_roles、role_resources 两张关联表显式建表，不再声明 many2many
+
+type baselineUser struct {
+	ID                 uint   `gorm:"primaryKey"`
+	Username           string `gorm:"uniqueIndex;size:64;not null"`
+	Nickname           string `gorm:"size:64"`
+	Email              string `gorm:"size:128"`
+	Phone              string `gorm:"size:20"`
+	Gender             string `gorm:"size:10"`
+	Status             int    `gorm:"default:1"`
+	Password           string `gorm:"size:128"`
+	PasswordChangedAt  *time.Time
+	MustChangePassword bool `gorm:"default:false"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineRole struct {
+	ID             uint   `gorm:"primaryKey"`
+	RoleName       string `gorm:"size:64;not null"`
+	RoleCode       string `gorm:"size:64;uniqueIndex;not null"`
+	RoleDesc       string `gorm:"size:255"`
+	Status         int    `gorm:"default:1"`
+	DataScope      string `gorm:"size:16;default:all"`
+	DataScopeRules string `gorm:"type:text"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (baselineRole) TableName() string { return "roles" }
+
+type baselineUserRole struct {
+	UserID uint `gorm:"primaryKey"`
+	RoleID uint `gorm:"primaryKey"`
+}
+
+func (baselineUserRole) TableName() string { return "user_roles" }
+
+type baselineResource struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement;comment:资源ID，主键"`
+	ResourceName   string    `gorm:"type:varchar(50);not null;comment:资源名称"`
+	PermissionCode string    `gorm:"type:varchar(100);not null;uniqueIndex:uk_permission_code;comment:权限标识码，唯一标识"`
+	Desc           *string   `gorm:"type:varchar(200);comment:资源描述"`
+	Type           string    `gorm:"type:varchar(20);not null;comment:资源类型：DIRECTORY-目录，MENU-菜单，BUTTON-按钮，API-接口"`
+	ResourcePath   *string   `gorm:"type:varchar(500);comment:资源路径（菜单路由或API路径）"`
+	HTTPMethod     *string   `gorm:"type:varchar(10);comment:HTTP方法（API类型使用）：GET,POST,PUT,DELETE等"`
+	ParentID       *int64    `gorm:"comment:父权限ID（用于构建菜单树形结构）"`
+	Sort           int       `gorm:"not null;default:0;comment:排序字段"`
+	Status         int8      `gorm:"not null;default:1;comment:权限状态：1-启用，0-禁用"`
+	RequiresAuth   int8      `gorm:"not null;default:1;comment:是否需要鉴权：1-需要，0-不需要"`
+	Remark         *string   `gorm:"type:varchar(500);comment:备注信息"`
+	Name           string    `gorm:"type:varchar(100);comment:前端路由名称"`
+	Component      *string   `gorm:"type:varchar(255);comment:前端组件路径"`
+	Redirect       string    `gorm:"type:varchar(255);comment:路由重定向地址"`
+	Visible        *bool     `gorm:"comment:是否在菜单中显示"`
+	Meta           *string   `gorm:"type:json;comment:前端路由元信息"`
+	LegacyMenuID   *uint     `gorm:"uniqueIndex;comment:合并前的菜单ID"`
+	CreatedBy      *int64    `gorm:"comment:创建人ID"`
+	CreatedAt      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;comment:创建时间"`
+	UpdatedBy      *int64    `gorm:"comment:更新人ID"`
+	UpdatedAt      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;autoUpdateTime;comment:更新时间"`
+}
+
+func (baselineResource) TableName() string { return "resources" }
+
+type baselineRoleResource struct {
+	ResourceID uint `gorm:"primaryKey"`
+	RoleID     uint `gorm:"primaryKey"`
+}
+
+func (baselineRoleResource) TableName() string { return "role_resources" }
+
+type baselineMenu struct {
+	ID         uint `gorm:"primaryKey"`
+	Name       string
+	Path       string
+	Component  *string
+	ParentID   *uint
+	Type       int
+	Redirect   string
+	Permission *string
+	Visible    *bool
+	Sort       *int
+	MetaJSON   string `gorm:"type:json"`
+}
+
+func (baselineMenu) TableName() string { return "menus" }
+
+type baselineConfig struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement"`
+	ConfigKey   string    `gorm:"size:100;not null;unique"`
+	ConfigName  string    `gorm:"size:100;not null"`
+	ConfigValue string    `gorm:"size:500;not null"`
+	ConfigType  string    `gorm:"size:1;not null"`
+	Remark      string    `gorm:"size:500"`
+	CreateTime  time.Time `gorm:"autoCreateTime"`
+}
+
+func (baselineConfig) TableName() string { return "configs" }
+
+type baselineLog struct {
+	ID            uint `gorm:"primaryKey"`
+	Timestamp     time.Time
+	Method        string
+	Path          string
+	Query         string
+	IP            string
+	UserAgent     string
+	Status        int
+	Latency       int64
+	Handler       string
+	Request       string
+	Response      string
+	Errors        string
+	ContentLength int64
+	Truncated     bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineLog) TableName() string { return "logs" }
+
+type baselineRefreshToken struct {
+	ID         uint      `gorm:"primaryKey"`
+	JTI        string    `gorm:"size:64;uniqueIndex;not null"`
+	FamilyID   string    `gorm:"size:64;index;not null"`
+	UserID     uint      `gorm:"index;not null"`
+	AccessJTI  string    `gorm:"size:64"`
+	Device     string    `gorm:"size:128"`
+	UserAgent  string    `gorm:"size:512"`
+	IP         string    `gorm:"size:64"`
+	ExpiresAt  time.Time `gorm:"index"`
+	RevokedAt  *time.Time
+	ReplacedBy string `gorm:"size:64"`
+	CreatedAt  time.Time
+}
+
+func (baselineRefreshToken) TableName() string { return "refresh_tokens" }
+
+type baselineRevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:64"`
+	UserID    uint      `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+func (baselineRevokedToken) TableName() string { return "revoked_tokens" }
+
+type baselineSession struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	UserID     uint   `gorm:"index;not null"`
+	Username   string `gorm:"size:64"`
+	IP         string `gorm:"size:64"`
+	UserAgent  string `gorm:"size:512"`
+	Device     string `gorm:"size:128"`
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time  `gorm:"index"`
+	RevokedAt  *time.Time `gorm:"index"`
+}
+
+func (baselineSession) TableName() string { return "user_sessions" }
+
+type baselineLoginFailure struct {
+	ID           uint   `gorm:"primaryKey"`
+	Scope        string `gorm:"size:10;not null;uniqueIndex:uk_scope_subject"`
+	Subject      string `gorm:"size:128;not null;uniqueIndex:uk_scope_subject"`
+	Failures     int    `gorm:"not null;default:0"`
+	LockCount    int    `gorm:"not null;default:0"`
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+	UpdatedAt    time.Time
+}
+
+func (baselineLoginFailure) TableName() string { return "login_failures" }
+
+type baselineLoginAudit struct {
+	ID        uint   `gorm:"primaryKey"`
+	Username  string `gorm:"size:64;index"`
+	UserID    uint   `gorm:"index"`
+	IP        string `gorm:"size:64;index"`
+	UserAgent string `gorm:"size:512"`
+	Success   bool
+	Reason    string    `gorm:"size:64"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (baselineLoginAudit) TableName() string { return "login_audits" }
+
+type baselineUserTwoFactor struct {
+	UserID        uint   `gorm:"primaryKey"`
+	Secret        string `gorm:"size:64;not null"`
+	Enabled       bool   `gorm:"not null;default:false"`
+	RecoveryCodes string `gorm:"type:text"`
+	LastUsedStep  int64
+	EnabledAt     *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (baselineUserTwoFactor) TableName() string { return "user_two_factors" }
+
+type baselinePasswordHistory struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"index;not null"`
+	Password  string `gorm:"size:128;not null"`
+	CreatedAt time.Time
+}
+
+func (baselinePasswordHistory) TableName() string { return "password_histories" }
+
+type baselineAdmissionPlan struct {
+	ID               int    `gorm:"primaryKey;autoIncrement"`
+	Year             int    `gorm:"not null"`
+	DistrictType     string `gorm:"type:varchar(50)"`
+	SchoolName       string `gorm:"type:varchar(255);not null"`
+	SchoolLevel      string `gorm:"type:varchar(50)"`
+	OperationNature  string `gorm:"type:varchar(50)"`
+	TotalStudents    *int
+	BoardingStudents *int
+	DayStudents      *int
+	AdmissionScope   string `gorm:"type:text"`
+	Remarks          string `gorm:"type:text"`
+	AcdStudents      int    `gorm:"default:0"`
+	AcStudents       int    `gorm:"default:0"`
+	DStudents        int    `gorm:"default:0"`
+	CreatedBy        uint   `gorm:"index"`
+}
+
+func (baselineAdmissionPlan) TableName() string { return "high_school_admission_plan" }
+
+// baselineSchoolAdmission 的 year 列直接按 smallint 建表，MySQL 旧库的 year 类型由 portable_year_column 迁移转换
+type baselineSchoolAdmission struct {
+	ID             int       `gorm:"primaryKey;autoIncrement"`
+	SchoolCode     string    `gorm:"size:20;not null"`
+	SchoolName     string    `gorm:"size:100;not null"`
+	Category       string    `gorm:"size:20;not null"`
+	TotalScore     int       `gorm:"not null"`
+	TieBreaker     string    `gorm:"size:255"`
+	AdmissionScope string    `gorm:"size:255"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+	Year           int       `gorm:"type:smallint;not null"`
+	CreatedBy      uint      `gorm:"index"`
+}
+
+func (baselineSchoolAdmission) TableName() string { return "school_admission_info" }
+
+// baselineModels 引入版本化迁移时的全部表。
+// 已通过 AutoMigrate 建表的数据库执行该迁移只会补齐缺失的表和字段
+var baselineModels = []interface{}{
+	&baselineUser{},
+	&baselineRole{},
+	&baselineUserRole{},
+	&baselineResource{},
+	&baselineRoleResource{},
+	&baselineMenu{},
+	&baselineConfig{},
+	&baselineLog{},
+	&baselineRefreshToken{},
+	&baselineRevokedToken{},
+	&baselineSession{},
+	&baselineLoginFailure{},
+	&baselineLoginAudit{},
+	&baselineUserTwoFactor{},
+	&baselinePasswordHistory{},
+	&baselineAdmissionPlan{},
+	&baselineSchoolAdmission{},
+}
+
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: "20261017000000",
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(baselineModels...)
+		},
+		Down: func(tx *gorm.DB) error {
+			for i := len(baselineModels) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(baselineModels[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"template-backend/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// school_admission_info.year 由 MySQL 专有的 year 类型改为 smallint；
+// 基线迁移已按 smallint 建表，这里只转换引入迁移前由 AutoMigrate 创建的 MySQL 旧库
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: "20261017000100",
+		Name:    "portable_year_column",
+		Up: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			return tx.Exec("ALTER TABLE school_admission_info MODIFY year SMALLINT NOT NULL").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			return tx.Exec("ALTER TABLE school_admission_info MODIFY year YEAR NOT NULL").Error
+		},
+	})
+}
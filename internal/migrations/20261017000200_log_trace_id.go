@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"template-backend/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// logTraceID 本次迁移新增的列
+type logTraceID struct {
+	TraceID string `gorm:"type:varchar(32);index"`
+}
+
+func (logTraceID) TableName() string { return "logs" }
+
+// 请求日志记录链路追踪的 trace ID，用于从日志跳转到链路详情
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: "20261017000200",
+		Name:    "log_trace_id",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&logTraceID{}, "TraceID"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&logTraceID{}, "TraceID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&logTraceID{}, "TraceID"); err != nil {
+				return err
+			}
+			// 不用 Migrator().DropColumn：sqlite 驱动会重建整张表并丢失其他索引
+			return tx.Exec("ALTER TABLE logs DROP COLUMN trace_id").Error
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"template-backend/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// logRequestID 本次迁移新增的列
+type logRequestID struct {
+	RequestID string `gorm:"type:varchar(128);index"`
+}
+
+func (logRequestID) TableName() string { return "logs" }
+
+// 请求日志记录请求 ID（X-Request-ID），用于按请求关联日志
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: "20261017000300",
+		Name:    "log_request_id",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&logRequestID{}, "RequestID"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&logRequestID{}, "RequestID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&logRequestID{}, "RequestID"); err != nil {
+				return err
+			}
+			// 不用 Migrator().DropColumn：sqlite 驱动会重建整张表并丢失其他索引
+			return tx.Exec("ALTER TABLE logs DROP COLUMN request_id").Error
+		},
+	})
+}
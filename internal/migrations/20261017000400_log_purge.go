@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"template-backend/pkg/migrate"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// logPurge 本次迁移创建的表结构
+type logPurge struct {
+	ID          uint      `gorm:"primaryKey"`
+	Trigger     string    `gorm:"type:varchar(20)"`
+	Mode        string    `gorm:"type:varchar(20)"`
+	StartedAt   time.Time `gorm:"index"`
+	FinishedAt  time.Time
+	SoftDeleted int64
+	Success     int64
+	Failure     int64
+	Archived    int64
+	Files       string `gorm:"type:text"`
+	Error       string `gorm:"type:text"`
+}
+
+func (logPurge) TableName() string { return "log_purges" }
+
+// 请求日志定时清理的执行记录
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: "20261017000400",
+		Name:    "log_purge",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&logPurge{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&logPurge{})
+		},
+	})
+}
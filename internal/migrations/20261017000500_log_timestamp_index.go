@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"template-backend/pkg/migrate"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// logTimestamp 本次迁移新增索引的列
+type logTimestamp struct {
+	Timestamp time.Time `gorm:"index"`
+}
+
+func (logTimestamp) TableName() string { return "logs" }
+
+// 日志统计、查询和保留策略清理都按时间范围过滤，为 timestamp 建索引
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: "20261017000500",
+		Name:    "log_timestamp_index",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateIndex(&logTimestamp{}, "Timestamp")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropIndex(&logTimestamp{}, "Timestamp")
+		},
+	})
+}
@@ -0,0 +1,30 @@
+// Package migrations 项目的数据库迁移。
+//
+// 新增迁移使用 `migrate create <name>` 生成文件，在 Up/Down 中编写变更；
+// 修改模型结构后必须同时新增迁移，服务启动时会拒绝运行在未迁移的数据库上。
+// 迁移中不要引用 internal/model 的模型，需要结构体时在迁移文件内定义只含本次变更字段的快照，
+// 否则模型的后续修改会改变已发布迁移的行为。
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"template-backend/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotMigrated 数据库存在未执行的迁移
+var ErrNotMigrated = errors.New("数据库未迁移到最新版本，请先执行 migrate up")
+
+// Check 校验数据库已执行全部迁移
+func Check(db *gorm.DB) error {
+	pending, err := migrate.New(db).Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w（待执行 %d 个，最早为 %s_%s）", ErrNotMigrated, len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
@@ -15,6 +15,7 @@ type HighSchoolAdmissionPlan struct {
 	AcdStudents      int    `gorm:"default:0" json:"acd_students"`
 	AcStudents       int    `gorm:"default:0" json:"ac_students"`
 	DStudents        int    `gorm:"default:0" json:"d_students"`
+	CreatedBy        uint   `gorm:"index" json:"created_by"` // 创建人，用于数据范围过滤
 }
 
 func (HighSchoolAdmissionPlan) TableName() string {
@@ -1,27 +1,60 @@
 package model
 
 type LoginForm struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	Remember *bool  `json:"remember,omitempty"`
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	Remember    *bool  `json:"remember,omitempty"`
+	Device      string `json:"device,omitempty"`
+	CaptchaID   string `json:"captchaId,omitempty"`
+	CaptchaCode string `json:"captchaCode,omitempty"`
+}
+
+type CaptchaResponse struct {
+	CaptchaID string `json:"captchaId"`
+	Image     string `json:"image"` // base64 data URI
 }
 
 type UserInfo struct {
-	ID          uint     `json:"id"`
-	Username    string   `json:"username"`
-	Email       string   `json:"email"`
-	Nickname    string   `json:"nickname"`
-	Avatar      string   `json:"avatar,omitempty"`
-	Roles       []string `json:"roles"`
-	Permissions []string `json:"permissions"`
-	CreatedAt   string   `json:"createdAt"`
-	UpdatedAt   string   `json:"updatedAt"`
+	ID               uint     `json:"id"`
+	Username         string   `json:"username"`
+	Email            string   `json:"email"`
+	Nickname         string   `json:"nickname"`
+	Avatar           string   `json:"avatar,omitempty"`
+	Roles            []string `json:"roles"`
+	Permissions      []string `json:"permissions"`
+	TwoFactorEnabled bool     `json:"twoFactorEnabled"`
+	CreatedAt        string   `json:"createdAt"`
+	UpdatedAt        string   `json:"updatedAt"`
 }
 
 type LoginResponse struct {
-	Token     string   `json:"token"`
-	UserInfo  UserInfo `json:"userInfo"`
-	ExpiresIn int      `json:"expiresIn,omitempty"`
+	Token            string   `json:"token"`
+	RefreshToken     string   `json:"refreshToken"`
+	UserInfo         UserInfo `json:"userInfo"`
+	ExpiresIn        int      `json:"expiresIn,omitempty"`
+	RefreshExpiresIn int      `json:"refreshExpiresIn,omitempty"`
+	// 密码已过期或被管理员重置，客户端应引导用户修改密码
+	MustChangePassword bool `json:"mustChangePassword,omitempty"`
+
+	// 两步验证：密码校验通过后返回挑战令牌，客户端再提交验证码完成登录
+	TwoFactorRequired      bool     `json:"twoFactorRequired,omitempty"`
+	TwoFactorSetupRequired bool     `json:"twoFactorSetupRequired,omitempty"` // 所属角色强制启用但尚未绑定
+	ChallengeToken         string   `json:"challengeToken,omitempty"`
+	RecoveryCodes          []string `json:"recoveryCodes,omitempty"` // 登录时完成绑定才返回，仅展示一次
+}
+
+type TwoFactorLoginForm struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recoveryCode"`
+	Device         string `json:"device,omitempty"`
+}
+
+type TokenResponse struct {
+	Token            string `json:"token"`
+	RefreshToken     string `json:"refreshToken"`
+	ExpiresIn        int    `json:"expiresIn"`
+	RefreshExpiresIn int    `json:"refreshExpiresIn"`
 }
 
 type ChangePasswordForm struct {
@@ -8,7 +8,7 @@ import (
 // Log HTTP请求日志结构体
 type Log struct {
 	ID            uint           `gorm:"primaryKey" json:"id"`
-	Timestamp     time.Time      `json:"timestamp"`
+	Timestamp     time.Time      `gorm:"index" json:"timestamp"`
 	Method        string         `json:"method"`
 	Path          string         `json:"path"`
 	Query         string         `json:"query"`
@@ -22,6 +22,9 @@ type Log struct {
 	Errors        string         `json:"errors"`
 	ContentLength int64          `json:"contentLength"`
 	Truncated     bool           `json:"truncated"`
+	TraceID       string         `gorm:"type:varchar(32);index" json:"traceId"`
+	RequestID     string         `gorm:"type:varchar(128);index" json:"requestId"`
+	TraceURL      string         `gorm:"-" json:"traceUrl,omitempty"` // 链路详情地址，查询时按配置生成
 	CreatedAt     time.Time      `json:"createdAt"`
 	UpdatedAt     time.Time      `json:"updatedAt"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// LogPurge 一次请求日志清理的结果
+type LogPurge struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Trigger     string    `gorm:"type:varchar(20)" json:"trigger"` // schedule 定时执行，manual 手动执行
+	Mode        string    `gorm:"type:varchar(20)" json:"mode"`    // delete 直接删除，archive 归档后删除
+	StartedAt   time.Time `gorm:"index" json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
+	SoftDeleted int64     `json:"softDeleted"` // 物理删除的已软删除日志数
+	Success     int64     `json:"success"`     // 清理的 2xx/3xx 日志数
+	Failure     int64     `json:"failure"`     // 清理的 4xx/5xx 日志数
+	Archived    int64     `json:"archived"`    // 写入归档文件的日志数
+	Files       []string  `gorm:"serializer:json" json:"files"`
+	Error       string    `gorm:"type:text" json:"error,omitempty"`
+}
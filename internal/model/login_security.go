@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+const (
+	LoginScopeUser = "user"
+	LoginScopeIP   = "ip"
+)
+
+// LoginFailure 按用户名或 IP 统计的登录失败计数
+type LoginFailure struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Scope        string     `gorm:"size:10;not null;uniqueIndex:uk_scope_subject" json:"scope"` // user 或 ip
+	Subject      string     `gorm:"size:128;not null;uniqueIndex:uk_scope_subject" json:"subject"`
+	Failures     int        `gorm:"not null;default:0" json:"failures"`
+	LockCount    int        `gorm:"not null;default:0" json:"lockCount"` // 连续锁定次数，用于计算指数退避
+	LastFailedAt time.Time  `json:"lastFailedAt"`
+	LockedUntil  *time.Time `json:"lockedUntil"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// LoginAudit 登录审计记录
+type LoginAudit struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Username  string    `gorm:"size:64;index" json:"username"`
+	UserID    uint      `gorm:"index" json:"userId"`
+	IP        string    `gorm:"size:64;index" json:"ip"`
+	UserAgent string    `gorm:"size:512" json:"userAgent"`
+	Success   bool      `json:"success"`
+	Reason    string    `gorm:"size:64" json:"reason"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// PasswordHistory 用户历史密码摘要，用于阻止重复使用近期密码
+type PasswordHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"userId"`
+	Password  string    `gorm:"size:128;not null" json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -2,12 +2,21 @@ package model
 
 import "time"
 
+// 资源类型：目录、菜单、按钮组成前端路由树，接口用于后端鉴权
+const (
+	ResourceTypeDirectory = "DIRECTORY"
+	ResourceTypeMenu      = "MENU"
+	ResourceTypeButton    = "BUTTON"
+	ResourceTypeAPI       = "API"
+)
+
+// Resource 统一的权限资源树，菜单类资源同时携带前端路由元数据
 type Resource struct {
 	ID             int64     `json:"id" gorm:"primaryKey;autoIncrement;comment:资源ID，主键"`
 	ResourceName   string    `json:"resource_name" gorm:"type:varchar(50);not null;comment:资源名称"`
 	PermissionCode string    `json:"permission_code" gorm:"type:varchar(100);not null;uniqueIndex:uk_permission_code;comment:权限标识码，唯一标识"`
 	Desc           *string   `json:"desc" gorm:"type:varchar(200);comment:资源描述"`
-	Type           string    `json:"type" gorm:"type:varchar(20);not null;comment:资源类型：MENU-菜单，BUTTON-按钮，API-接口"`
+	Type           string    `json:"type" gorm:"type:varchar(20);not null;comment:资源类型：DIRECTORY-目录，MENU-菜单，BUTTON-按钮，API-接口"`
 	ResourcePath   *string   `json:"resource_path" gorm:"type:varchar(500);comment:资源路径（菜单路由或API路径）"`
 	HTTPMethod     *string   `json:"http_method" gorm:"type:varchar(10);comment:HTTP方法（API类型使用）：GET,POST,PUT,DELETE等"`
 	ParentID       *int64    `json:"parent_id" gorm:"comment:父权限ID（用于构建菜单树形结构）"`
@@ -15,6 +24,12 @@ type Resource struct {
 	Status         int8      `json:"status" gorm:"not null;default:1;comment:权限状态：1-启用，0-禁用"`
 	RequiresAuth   int8      `json:"requires_auth" gorm:"not null;default:1;comment:是否需要鉴权：1-需要，0-不需要"`
 	Remark         *string   `json:"remark" gorm:"type:varchar(500);comment:备注信息"`
+	Name           string    `json:"name" gorm:"type:varchar(100);comment:前端路由名称"`
+	Component      *string   `json:"component" gorm:"type:varchar(255);comment:前端组件路径"`
+	Redirect       string    `json:"redirect" gorm:"type:varchar(255);comment:路由重定向地址"`
+	Visible        *bool     `json:"visible" gorm:"comment:是否在菜单中显示"`
+	Meta           *Meta     `json:"meta" gorm:"type:json;serializer:json;comment:前端路由元信息"`
+	LegacyMenuID   *uint     `json:"-" gorm:"uniqueIndex;comment:合并前的菜单ID"`
 	CreatedBy      *int64    `json:"created_by" gorm:"comment:创建人ID"`
 	CreatedAt      time.Time `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP;comment:创建时间"`
 	UpdatedBy      *int64    `json:"updated_by" gorm:"comment:更新人ID"`
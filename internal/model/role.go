@@ -1,16 +1,22 @@
 // model/role.go
 package model
 
-import "time"
+import (
+	"template-backend/pkg/datascope"
+	"time"
+)
 
 type Role struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	RoleName  string    `gorm:"size:64;not null" json:"roleName"`
-	RoleCode  string    `gorm:"size:64;uniqueIndex;not null" json:"roleCode"`
-	RoleDesc  string    `gorm:"size:255" json:"roleDesc"`
-	Status    int       `gorm:"default:1" json:"status"`
-	CreatedAt time.Time `json:"createTime"`
-	UpdatedAt time.Time `json:"updateTime"`
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	RoleName string `gorm:"size:64;not null" json:"roleName"`
+	RoleCode string `gorm:"size:64;uniqueIndex;not null" json:"roleCode"`
+	RoleDesc string `gorm:"size:255" json:"roleDesc"`
+	Status   int    `gorm:"default:1" json:"status"`
+	// 数据范围：all 全部数据，custom 按 DataScopeRules 过滤，own 仅本人创建的数据
+	DataScope      string           `gorm:"size:16;default:all" json:"dataScope"`
+	DataScopeRules []datascope.Rule `gorm:"type:text;serializer:json" json:"dataScopeRules"`
+	CreatedAt      time.Time        `json:"createTime"`
+	UpdatedAt      time.Time        `json:"updateTime"`
 	// 多对多关系
 	Resources []Resource `gorm:"many2many:role_resources;" json:"resources,omitempty"`
 
@@ -12,7 +12,8 @@ type SchoolAdmissionInfo struct {
 	AdmissionScope string    `gorm:"size:255" json:"admissionScope"`
 	CreatedAt      time.Time `gorm:"autoCreateTime" json:"createdAt"`
 	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
-	Year           int       `gorm:"type:year;not null" json:"year"`
+	Year           int       `gorm:"type:smallint;not null" json:"year"` // 不使用 MySQL 专有的 year 类型，便于支持其他数据库
+	CreatedBy      uint      `gorm:"index" json:"createdBy"`             // 创建人，用于数据范围过滤
 }
 
 func (SchoolAdmissionInfo) TableName() string {
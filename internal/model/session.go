@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Session 一次登录会话，ID 与该次登录签发的刷新令牌 FamilyID 相同
+type Session struct {
+	ID         string     `gorm:"primaryKey;size:64" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"userId"`
+	Username   string     `gorm:"size:64" json:"username"`
+	IP         string     `gorm:"size:64" json:"ip"`
+	UserAgent  string     `gorm:"size:512" json:"userAgent"`
+	Device     string     `gorm:"size:128" json:"device"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	ExpiresAt  time.Time  `gorm:"index" json:"expiresAt"`
+	RevokedAt  *time.Time `gorm:"index" json:"revokedAt,omitempty"`
+	Current    bool       `gorm:"-" json:"current"` // 是否为发起请求的会话
+}
+
+func (Session) TableName() string {
+	return "user_sessions"
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// RefreshToken 服务端保存的刷新令牌，同一次登录轮换出的令牌属于同一个 FamilyID
+type RefreshToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	JTI        string     `gorm:"size:64;uniqueIndex;not null" json:"jti"`
+	FamilyID   string     `gorm:"size:64;index;not null" json:"familyId"`
+	UserID     uint       `gorm:"index;not null" json:"userId"`
+	AccessJTI  string     `gorm:"size:64" json:"-"` // 与该刷新令牌同时签发的访问令牌
+	Device     string     `gorm:"size:128" json:"device"`
+	UserAgent  string     `gorm:"size:512" json:"userAgent"`
+	IP         string     `gorm:"size:64" json:"ip"`
+	ExpiresAt  time.Time  `gorm:"index" json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	ReplacedBy string     `gorm:"size:64" json:"replacedBy"` // 轮换后的新令牌 jti
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// RevokedToken 已吊销但尚未过期的访问令牌
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:64" json:"jti"`
+	UserID    uint      `gorm:"index" json:"userId"`
+	ExpiresAt time.Time `gorm:"index" json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
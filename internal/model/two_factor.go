@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// UserTwoFactor 用户的 TOTP 两步验证配置，Enabled 为 false 时表示正在绑定
+type UserTwoFactor struct {
+	UserID        uint       `gorm:"primaryKey" json:"userId"`
+	Secret        string     `gorm:"size:64;not null" json:"-"`
+	Enabled       bool       `gorm:"not null;default:false" json:"enabled"`
+	RecoveryCodes []string   `gorm:"type:text;serializer:json" json:"-"` // 恢复码的 SHA-256 摘要
+	LastUsedStep  int64      `json:"-"`                                  // 最近一次使用的时间步，防止验证码重放
+	EnabledAt     *time.Time `json:"enabledAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// TwoFactorSetup 绑定认证器应用所需的信息
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"` // 前端据此生成二维码
+}
@@ -1,17 +1,19 @@
 package repository
 
 import (
+	"context"
 	"template-backend/internal/model"
 
 	"gorm.io/gorm"
 )
 
 type ConfigRepository interface {
-	GetList(params map[string]interface{}) ([]model.Config, int64, error)
-	GetByID(id int64) (*model.Config, error)
-	Create(config *model.Config) error
-	Update(config *model.Config) error
-	Delete(id int64) error
+	GetList(ctx context.Context, params map[string]interface{}) ([]model.Config, int64, error)
+	GetByID(ctx context.Context, id int64) (*model.Config, error)
+	GetByKey(ctx context.Context, key string) (*model.Config, error)
+	Create(ctx context.Context, config *model.Config) error
+	Update(ctx context.Context, config *model.Config) error
+	Delete(ctx context.Context, id int64) error
 }
 
 type configRepository struct {
@@ -22,11 +24,11 @@ func NewConfigRepository(db *gorm.DB) ConfigRepository {
 	return &configRepository{db: db}
 }
 
-func (r *configRepository) GetList(params map[string]interface{}) ([]model.Config, int64, error) {
+func (r *configRepository) GetList(ctx context.Context, params map[string]interface{}) ([]model.Config, int64, error) {
 	var configs []model.Config
 	var total int64
 
-	query := r.db.Model(&model.Config{})
+	query := r.db.WithContext(ctx).Model(&model.Config{})
 
 	// 动态条件
 	if v, ok := params["configKey"].(string); ok && v != "" {
@@ -51,22 +53,31 @@ func (r *configRepository) GetList(params map[string]interface{}) ([]model.Confi
 	return configs, total, err
 }
 
-func (r *configRepository) GetByID(id int64) (*model.Config, error) {
+func (r *configRepository) GetByID(ctx context.Context, id int64) (*model.Config, error) {
 	var config model.Config
-	if err := r.db.First(&config, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&config, id).Error; err != nil {
 		return nil, err
 	}
 	return &config, nil
 }
 
-func (r *configRepository) Create(config *model.Config) error {
-	return r.db.Create(config).Error
+// GetByKey 按配置键精确查询
+func (r *configRepository) GetByKey(ctx context.Context, key string) (*model.Config, error) {
+	var config model.Config
+	if err := r.db.WithContext(ctx).Where("config_key = ?", key).First(&config).Error; err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (r *configRepository) Create(ctx context.Context, config *model.Config) error {
+	return r.db.WithContext(ctx).Create(config).Error
 }
 
-func (r *configRepository) Update(config *model.Config) error {
-	return r.db.Save(config).Error
+func (r *configRepository) Update(ctx context.Context, config *model.Config) error {
+	return r.db.WithContext(ctx).Save(config).Error
 }
 
-func (r *configRepository) Delete(id int64) error {
-	return r.db.Delete(&model.Config{}, id).Error
+func (r *configRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&model.Config{}, id).Error
 }
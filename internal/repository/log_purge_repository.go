@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"template-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type LogPurgeRepository interface {
+	Create(ctx context.Context, purge *model.LogPurge) error
+	// Latest 最近一次清理记录，没有记录时返回 nil
+	Latest(ctx context.Context) (*model.LogPurge, error)
+}
+
+type logPurgeRepository struct {
+	db *gorm.DB
+}
+
+func NewLogPurgeRepository(db *gorm.DB) LogPurgeRepository {
+	return &logPurgeRepository{db: db}
+}
+
+func (r *logPurgeRepository) Create(ctx context.Context, purge *model.LogPurge) error {
+	return r.db.WithContext(ctx).Create(purge).Error
+}
+
+func (r *logPurgeRepository) Latest(ctx context.Context) (*model.LogPurge, error) {
+	var purge model.LogPurge
+	err := r.db.WithContext(ctx).Order("id DESC").First(&purge).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &purge, nil
+}
@@ -2,19 +2,39 @@
 package repository
 
 import (
+	"context"
+
 	"gorm.io/gorm"
 	"template-backend/internal/model"
 	"time"
 )
 
 type LogRepository interface {
-	Create(log *model.Log) error
-	CreateInBatches(logs []*model.Log) error
-	GetByID(id uint) (*model.Log, error)
-	List(pageNum, pageSize int, conditions map[string]interface{}) ([]model.Log, int64, error)
-	Delete(id uint) error
-	DeleteBatch(ids []uint) error
-	Clean() error
+	Create(ctx context.Context, log *model.Log) error
+	CreateInBatches(ctx context.Context, logs []*model.Log) error
+	GetByID(ctx context.Context, id uint) (*model.Log, error)
+	List(ctx context.Context, pageNum, pageSize int, conditions map[string]interface{}) ([]model.Log, int64, error)
+	Count(ctx context.Context, conditions map[string]interface{}) (int64, error)
+	// Each 按 id 顺序逐行读取符合条件的日志，fn 返回错误时停止；结果集不会整体加载到内存
+	Each(ctx context.Context, conditions map[string]interface{}, fn func(*model.Log) error) error
+	Delete(ctx context.Context, id uint) error
+	DeleteBatch(ctx context.Context, ids []uint) error
+	Clean(ctx context.Context) error
+
+	// 以下用于保留策略清理，ExpiredIDs、FindByIDs 和 HardDelete 包含已软删除的日志
+	CutoffID(ctx context.Context, scope LogPurgeScope, keep int64) (uint, error)
+	ExpiredIDs(ctx context.Context, scope LogPurgeScope, limit int) ([]uint, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]model.Log, error)
+	HardDelete(ctx context.Context, ids []uint) (int64, error)
+}
+
+// LogPurgeScope 保留策略的清理范围，Before 和 MaxID 满足其一即视为过期
+type LogPurgeScope struct {
+	SoftDeleted bool      // 只匹配已软删除的日志，忽略其他条件
+	MinStatus   int       // 状态码下限（含）
+	MaxStatus   int       // 状态码上限（不含），0 表示不限
+	Before      time.Time // 早于该时间的日志，零值表示不按时间
+	MaxID       uint      // id 不大于该值的日志，0 表示不按行数
 }
 
 type logRepository struct {
@@ -26,34 +46,88 @@ func NewLogRepository(db *gorm.DB) LogRepository {
 }
 
 // BatchCreate 批量创建日志记录
-func (r *logRepository) CreateInBatches(logs []*model.Log) error {
+func (r *logRepository) CreateInBatches(ctx context.Context, logs []*model.Log) error {
 	// 如果日志列表为空，直接返回
 	if len(logs) == 0 {
 		return nil
 	}
-	return r.db.CreateInBatches(logs, 100).Error
+	return r.db.WithContext(ctx).CreateInBatches(logs, 100).Error
 }
 
-func (r *logRepository) Create(log *model.Log) error {
-	return r.db.Create(log).Error
+func (r *logRepository) Create(ctx context.Context, log *model.Log) error {
+	return r.db.WithContext(ctx).Create(log).Error
 }
 
-func (r *logRepository) GetByID(id uint) (*model.Log, error) {
+func (r *logRepository) GetByID(ctx context.Context, id uint) (*model.Log, error) {
 	var log model.Log
-	err := r.db.Where("id = ?", id).First(&log).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&log).Error
 	if err != nil {
 		return nil, err
 	}
 	return &log, nil
 }
 
-func (r *logRepository) List(pageNum, pageSize int, conditions map[string]interface{}) ([]model.Log, int64, error) {
+func (r *logRepository) List(ctx context.Context, pageNum, pageSize int, conditions map[string]interface{}) ([]model.Log, int64, error) {
 	var logs []model.Log
 	var total int64
 
-	db := r.db.Model(&model.Log{})
+	db := applyLogConditions(r.db.WithContext(ctx).Model(&model.Log{}), conditions)
+
+	// 获取总数
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (pageNum - 1) * pageSize
+	err := db.Offset(offset).Limit(pageSize).Order("timestamp DESC").Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+func (r *logRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Log{}, id).Error
+}
 
-	// 添加查询条件
+func (r *logRepository) DeleteBatch(ctx context.Context, ids []uint) error {
+	return r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.Log{}).Error
+}
+
+func (r *logRepository) Clean(ctx context.Context) error {
+	return r.db.WithContext(ctx).Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Log{}).Error
+}
+
+func (r *logRepository) Count(ctx context.Context, conditions map[string]interface{}) (int64, error) {
+	var total int64
+	err := applyLogConditions(r.db.WithContext(ctx).Model(&model.Log{}), conditions).Count(&total).Error
+	return total, err
+}
+
+func (r *logRepository) Each(ctx context.Context, conditions map[string]interface{}, fn func(*model.Log) error) error {
+	db := applyLogConditions(r.db.WithContext(ctx).Model(&model.Log{}), conditions)
+	rows, err := db.Order("id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log model.Log
+		if err := db.ScanRows(rows, &log); err != nil {
+			return err
+		}
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// applyLogConditions 列表、计数和导出共用的查询条件
+func applyLogConditions(db *gorm.DB, conditions map[string]interface{}) *gorm.DB {
 	if method, ok := conditions["method"]; ok && method != "" {
 		db = db.Where("method = ?", method)
 	}
@@ -69,35 +143,69 @@ func (r *logRepository) List(pageNum, pageSize int, conditions map[string]interf
 	if handler, ok := conditions["handler"]; ok && handler != "" {
 		db = db.Where("handler LIKE ?", "%"+handler.(string)+"%")
 	}
+	if traceID, ok := conditions["traceId"]; ok && traceID != "" {
+		db = db.Where("trace_id = ?", traceID)
+	}
+	if requestID, ok := conditions["requestId"]; ok && requestID != "" {
+		db = db.Where("request_id = ?", requestID)
+	}
 	if timestampRange, ok := conditions["timestamp"]; ok {
 		if rangeArr, valid := timestampRange.([]time.Time); valid && len(rangeArr) == 2 {
 			db = db.Where("timestamp BETWEEN ? AND ?", rangeArr[0], rangeArr[1])
 		}
 	}
+	return db
+}
 
-	// 获取总数
-	if err := db.Count(&total).Error; err != nil {
-		return nil, 0, err
+// CutoffID 按 id 从新到旧跳过 keep 条后的第一条日志 id，id 不大于它的日志超出保留行数；
+// 不足 keep 条时返回 0。日志按写入顺序分配 id，id 越小越早
+func (r *logRepository) CutoffID(ctx context.Context, scope LogPurgeScope, keep int64) (uint, error) {
+	var ids []uint
+	err := statusRange(r.db.WithContext(ctx).Model(&model.Log{}), scope).
+		Order("id DESC").Offset(int(keep)).Limit(1).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
 	}
+	return ids[0], nil
+}
 
-	// 分页查询
-	offset := (pageNum - 1) * pageSize
-	err := db.Offset(offset).Limit(pageSize).Order("timestamp DESC").Find(&logs).Error
-	if err != nil {
-		return nil, 0, err
+func (r *logRepository) ExpiredIDs(ctx context.Context, scope LogPurgeScope, limit int) ([]uint, error) {
+	db := r.db.WithContext(ctx).Unscoped().Model(&model.Log{})
+	switch {
+	case scope.SoftDeleted:
+		db = db.Where("deleted_at IS NOT NULL")
+	case !scope.Before.IsZero() && scope.MaxID > 0:
+		db = statusRange(db, scope).Where("timestamp < ? OR id <= ?", scope.Before, scope.MaxID)
+	case !scope.Before.IsZero():
+		db = statusRange(db, scope).Where("timestamp < ?", scope.Before)
+	case scope.MaxID > 0:
+		db = statusRange(db, scope).Where("id <= ?", scope.MaxID)
+	default:
+		return nil, nil
 	}
 
-	return logs, total, nil
+	var ids []uint
+	err := db.Order("id").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
 }
 
-func (r *logRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Log{}, id).Error
+func (r *logRepository) FindByIDs(ctx context.Context, ids []uint) ([]model.Log, error) {
+	var logs []model.Log
+	err := r.db.WithContext(ctx).Unscoped().Where("id IN ?", ids).Order("id").Find(&logs).Error
+	return logs, err
 }
 
-func (r *logRepository) DeleteBatch(ids []uint) error {
-	return r.db.Where("id IN ?", ids).Delete(&model.Log{}).Error
+func (r *logRepository) HardDelete(ctx context.Context, ids []uint) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("id IN ?", ids).Delete(&model.Log{})
+	return result.RowsAffected, result.Error
 }
 
-func (r *logRepository) Clean() error {
-	return r.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Log{}).Error
+func statusRange(db *gorm.DB, scope LogPurgeScope) *gorm.DB {
+	if scope.MinStatus > 0 {
+		db = db.Where("status >= ?", scope.MinStatus)
+	}
+	if scope.MaxStatus > 0 {
+		db = db.Where("status < ?", scope.MaxStatus)
+	}
+	return db
 }
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"template-backend/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LogBucket 一个时间段内按状态码分类的请求数，Bucket 为 (Unix 秒 + 偏移) / 时间段长度
+type LogBucket struct {
+	Bucket      int64
+	Total       int64
+	Success     int64 // 状态码 < 400
+	ClientError int64 // 4xx
+	ServerError int64 // 5xx
+}
+
+// LogStatusCount 单个状态码的请求数
+type LogStatusCount struct {
+	Status int   `json:"status"`
+	Count  int64 `json:"count"`
+}
+
+// LogHandlerLatency 单个处理函数的耗时分位数（毫秒），按最近秩法计算
+type LogHandlerLatency struct {
+	Handler string  `json:"handler"`
+	Count   int64   `json:"count"`
+	Avg     float64 `json:"avg"`
+	P50     int64   `json:"p50"`
+	P95     int64   `json:"p95"`
+	P99     int64   `json:"p99"`
+	Max     int64   `json:"max"`
+}
+
+// LogPathCount 按方法和路径统计的请求数
+type LogPathCount struct {
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Count      int64   `json:"count"`
+	Errors     int64   `json:"errors"` // 状态码 >= 400
+	AvgLatency float64 `json:"avgLatency"`
+}
+
+// LogIPCount 按客户端 IP 统计的请求数
+type LogIPCount struct {
+	IP     string `json:"ip"`
+	Count  int64  `json:"count"`
+	Errors int64  `json:"errors"` // 状态码 >= 400
+}
+
+// LogSlowRequest 耗时最长的请求
+type LogSlowRequest struct {
+	ID        uint      `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	Latency   int64     `json:"latency"`
+	Handler   string    `json:"handler"`
+	IP        string    `json:"ip"`
+	RequestID string    `json:"requestId"`
+	TraceID   string    `json:"traceId"`
+}
+
+// LogStatsRepository 请求日志聚合统计，时间范围为 [start, end)，全部使用 SQL 聚合并依赖 timestamp 索引
+type LogStatsRepository interface {
+	Timeline(ctx context.Context, start, end time.Time, size time.Duration, offset int64) ([]LogBucket, error)
+	StatusCounts(ctx context.Context, start, end time.Time) ([]LogStatusCount, error)
+	HandlerLatency(ctx context.Context, start, end time.Time, limit int) ([]LogHandlerLatency, error)
+	TopPaths(ctx context.Context, start, end time.Time, limit int) ([]LogPathCount, error)
+	TopIPs(ctx context.Context, start, end time.Time, limit int) ([]LogIPCount, error)
+	Slowest(ctx context.Context, start, end time.Time, limit int) ([]LogSlowRequest, error)
+}
+
+type logStatsRepository struct {
+	db *gorm.DB
+}
+
+func NewLogStatsRepository(db *gorm.DB) LogStatsRepository {
+	return &logStatsRepository{db: db}
+}
+
+// between 日志按服务器本地时区写入，sqlite 以文本比较时间，参数需转换到同一时区
+func (r *logStatsRepository) between(ctx context.Context, start, end time.Time) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&model.Log{}).
+		Where("timestamp >= ? AND timestamp < ?", start.In(time.Local), end.In(time.Local))
+}
+
+// epochExpr 各数据库取 timestamp 的 Unix 秒
+func (r *logStatsRepository) epochExpr() string {
+	switch r.db.Dialector.Name() {
+	case "postgres":
+		return "CAST(EXTRACT(EPOCH FROM timestamp) AS BIGINT)"
+	case "sqlite":
+		return "CAST(strftime('%s', timestamp) AS INTEGER)"
+	default:
+		return "UNIX_TIMESTAMP(timestamp)"
+	}
+}
+
+func (r *logStatsRepository) Timeline(ctx context.Context, start, end time.Time, size time.Duration, offset int64) ([]LogBucket, error) {
+	seconds := int64(size / time.Second)
+	if seconds <= 0 {
+		return nil, fmt.Errorf("无效的统计时间段: %s", size)
+	}
+	// 数值直接写入 SQL：占位符在 SELECT 和 GROUP BY 中出现两次时 postgres 视为不同表达式
+	div := "/"
+	if r.db.Dialector.Name() == "mysql" {
+		div = "DIV"
+	}
+	bucket := fmt.Sprintf("(%s + %d) %s %d", r.epochExpr(), offset, div, seconds)
+
+	var buckets []LogBucket
+	err := r.between(ctx, start, end).
+		Select(bucket + ` AS bucket, COUNT(*) AS total,
+			SUM(CASE WHEN status < 400 THEN 1 ELSE 0 END) AS success,
+			SUM(CASE WHEN status >= 400 AND status < 500 THEN 1 ELSE 0 END) AS client_error,
+			SUM(CASE WHEN status >= 500 THEN 1 ELSE 0 END) AS server_error`).
+		Group("bucket").Order("bucket").Scan(&buckets).Error
+	return buckets, err
+}
+
+func (r *logStatsRepository) StatusCounts(ctx context.Context, start, end time.Time) ([]LogStatusCount, error) {
+	var counts []LogStatusCount
+	err := r.between(ctx, start, end).
+		Select("status, COUNT(*) AS count").
+		Group("status").Order("status").Scan(&counts).Error
+	return counts, err
+}
+
+func (r *logStatsRepository) HandlerLatency(ctx context.Context, start, end time.Time, limit int) ([]LogHandlerLatency, error) {
+	// 窗口函数给每个处理函数内的请求按耗时编号，第 ceil(p*n) 个即为 p 分位数
+	ranked := r.between(ctx, start, end).Select(`handler, latency,
+		ROW_NUMBER() OVER (PARTITION BY handler ORDER BY latency) AS rn,
+		COUNT(*) OVER (PARTITION BY handler) AS cnt`)
+
+	var result []LogHandlerLatency
+	err := r.db.WithContext(ctx).Table("(?) AS ranked", ranked).
+		Select(`handler, MAX(cnt) AS count, AVG(latency) AS avg,
+			MIN(CASE WHEN rn >= cnt * 0.50 THEN latency END) AS p50,
+			MIN(CASE WHEN rn >= cnt * 0.95 THEN latency END) AS p95,
+			MIN(CASE WHEN rn >= cnt * 0.99 THEN latency END) AS p99,
+			MAX(latency) AS max`).
+		Group("handler").Order("p95 DESC").Limit(limit).Scan(&result).Error
+	return result, err
+}
+
+func (r *logStatsRepository) TopPaths(ctx context.Context, start, end time.Time, limit int) ([]LogPathCount, error) {
+	var result []LogPathCount
+	err := r.between(ctx, start, end).
+		Select(`method, path, COUNT(*) AS count,
+			SUM(CASE WHEN status >= 400 THEN 1 ELSE 0 END) AS errors,
+			AVG(latency) AS avg_latency`).
+		Group("method, path").Order("count DESC").Limit(limit).Scan(&result).Error
+	return result, err
+}
+
+func (r *logStatsRepository) TopIPs(ctx context.Context, start, end time.Time, limit int) ([]LogIPCount, error) {
+	var result []LogIPCount
+	err := r.between(ctx, start, end).
+		Select("ip, COUNT(*) AS count, SUM(CASE WHEN status >= 400 THEN 1 ELSE 0 END) AS errors").
+		Group("ip").Order("count DESC").Limit(limit).Scan(&result).Error
+	return result, err
+}
+
+func (r *logStatsRepository) Slowest(ctx context.Context, start, end time.Time, limit int) ([]LogSlowRequest, error) {
+	var result []LogSlowRequest
+	err := r.between(ctx, start, end).
+		Select("id, timestamp, method, path, status, latency, handler, ip, request_id, trace_id").
+		Order("latency DESC").Limit(limit).Scan(&result).Error
+	return result, err
+}
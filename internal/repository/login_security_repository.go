@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"template-backend/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginSecurityRepository interface {
+	GetFailure(ctx context.Context, scope, subject string) (*model.LoginFailure, error)
+	IncrementFailure(ctx context.Context, scope, subject string, now, windowStart time.Time) (*model.LoginFailure, error)
+	LockFailure(ctx context.Context, failure *model.LoginFailure, lockedUntil time.Time) (bool, error)
+	DeleteFailure(ctx context.Context, scope, subject string) error
+	DeleteFailureByID(ctx context.Context, id uint) error
+	ListFailures(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.LoginFailure, int64, error)
+	CreateAudit(ctx context.Context, audit *model.LoginAudit) error
+	ListAudits(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.LoginAudit, int64, error)
+}
+
+type loginSecurityRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginSecurityRepository(db *gorm.DB) LoginSecurityRepository {
+	return &loginSecurityRepository{db: db}
+}
+
+// GetFailure 获取失败计数，不存在时返回 nil
+func (r *loginSecurityRepository) GetFailure(ctx context.Context, scope, subject string) (*model.LoginFailure, error) {
+	var failure model.LoginFailure
+	err := r.db.WithContext(ctx).Where("scope = ? AND subject = ?", scope, subject).First(&failure).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+// IncrementFailure 原子地累加失败次数并返回累加后的记录。
+// 首次失败先插入计数为 0 的行，并发插入由唯一索引去重；
+// 上次失败早于 windowStart 且未处于锁定中的记录重新从 1 计数
+func (r *loginSecurityRepository) IncrementFailure(ctx context.Context, scope, subject string, now, windowStart time.Time) (*model.LoginFailure, error) {
+	initial := &model.LoginFailure{Scope: scope, Subject: subject, LastFailedAt: now}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(initial).Error; err != nil {
+		return nil, err
+	}
+
+	// last_failed_at 必须最后赋值：MySQL 按顺序执行 SET，前面的条件要读取更新前的值
+	expired := "last_failed_at < ? AND (locked_until IS NULL OR locked_until < ?)"
+	err := r.db.WithContext(ctx).Model(&model.LoginFailure{}).
+		Where("scope = ? AND subject = ?", scope, subject).
+		Updates(map[string]interface{}{
+			"failures":       gorm.Expr("CASE WHEN "+expired+" THEN 1 ELSE failures + 1 END", windowStart, now),
+			"lock_count":     gorm.Expr("CASE WHEN "+expired+" THEN 0 ELSE lock_count END", windowStart, now),
+			"last_failed_at": now,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var failure model.LoginFailure
+	if err := r.db.WithContext(ctx).Where("scope = ? AND subject = ?", scope, subject).First(&failure).Error; err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+// LockFailure 锁定到 lockedUntil 并清零失败次数。
+// 以读取时的 lock_count 为条件，并发请求同时达到阈值时只有一个生效，返回是否由本次锁定
+func (r *loginSecurityRepository) LockFailure(ctx context.Context, failure *model.LoginFailure, lockedUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.LoginFailure{}).
+		Where("id = ? AND lock_count = ?", failure.ID, failure.LockCount).
+		Updates(map[string]interface{}{
+			"failures":     0,
+			"lock_count":   gorm.Expr("lock_count + 1"),
+			"locked_until": lockedUntil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *loginSecurityRepository) DeleteFailure(ctx context.Context, scope, subject string) error {
+	return r.db.WithContext(ctx).Where("scope = ? AND subject = ?", scope, subject).Delete(&model.LoginFailure{}).Error
+}
+
+func (r *loginSecurityRepository) DeleteFailureByID(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.LoginFailure{}, id).Error
+}
+
+func (r *loginSecurityRepository) ListFailures(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.LoginFailure, int64, error) {
+	var failures []model.LoginFailure
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.LoginFailure{})
+	if scope, ok := filters["scope"]; ok {
+		query = query.Where("scope = ?", scope)
+	}
+	if subject, ok := filters["subject"]; ok {
+		query = query.Where("subject LIKE ?", "%"+subject.(string)+"%")
+	}
+	if locked, ok := filters["locked"]; ok && locked.(bool) {
+		query = query.Where("locked_until > ?", time.Now())
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("last_failed_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&failures).Error
+	return failures, total, err
+}
+
+func (r *loginSecurityRepository) CreateAudit(ctx context.Context, audit *model.LoginAudit) error {
+	return r.db.WithContext(ctx).Create(audit).Error
+}
+
+func (r *loginSecurityRepository) ListAudits(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.LoginAudit, int64, error) {
+	var audits []model.LoginAudit
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.LoginAudit{})
+	if username, ok := filters["username"]; ok {
+		query = query.Where("username LIKE ?", "%"+username.(string)+"%")
+	}
+	if ip, ok := filters["ip"]; ok {
+		query = query.Where("ip = ?", ip)
+	}
+	if success, ok := filters["success"]; ok {
+		query = query.Where("success = ?", success)
+	}
+	if timeRange, ok := filters["createdAt"]; ok {
+		if rangeArr, valid := timeRange.([]time.Time); valid && len(rangeArr) == 2 {
+			query = query.Where("created_at BETWEEN ? AND ?", rangeArr[0], rangeArr[1])
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&audits).Error
+	return audits, total, err
+}
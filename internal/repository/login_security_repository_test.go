@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"template-backend/internal/model"
+	"template-backend/internal/testdb"
+	"testing"
+	"time"
+)
+
+func TestIncrementFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLoginSecurityRepository(testdb.Open(t))
+	start := time.Now()
+
+	tests := []struct {
+		name     string
+		at       time.Duration // 相对 start 的失败时间
+		window   time.Duration
+		failures int
+	}{
+		{"首次失败", 0, time.Minute, 1},
+		{"窗口内累加", time.Second, time.Minute, 2},
+		{"窗口内继续累加", 2 * time.Second, time.Minute, 3},
+		{"超过窗口重新计数", 2 * time.Hour, time.Minute, 1},
+	}
+	for _, tt := range tests {
+		now := start.Add(tt.at)
+		failure, err := repo.IncrementFailure(ctx, "user", "alice", now, now.Add(-tt.window))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if failure.Failures != tt.failures {
+			t.Errorf("%s: failures = %d, want %d", tt.name, failure.Failures, tt.failures)
+		}
+	}
+
+	// 不同维度互不影响
+	failure, err := repo.IncrementFailure(ctx, "ip", "alice", start, start.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failure.Failures != 1 {
+		t.Fatalf("ip failures = %d, want 1", failure.Failures)
+	}
+}
+
+func TestLockFailureOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLoginSecurityRepository(testdb.Open(t))
+	now := time.Now()
+
+	failure, err := repo.IncrementFailure(ctx, "ip", "10.0.0.1", now, now.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 两个请求读取到同一条记录后同时尝试锁定，只有一个生效
+	stale := *failure
+	for i, want := range []bool{true, false} {
+		ok, err := repo.LockFailure(ctx, &stale, now.Add(time.Minute))
+		if err != nil {
+			t.Fatalf("lock #%d: %v", i, err)
+		}
+		if ok != want {
+			t.Fatalf("lock #%d = %v, want %v", i, ok, want)
+		}
+	}
+
+	got, err := repo.GetFailure(ctx, "ip", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LockCount != 1 || got.Failures != 0 || got.LockedUntil == nil {
+		t.Fatalf("failure = %+v, want locked once with failures reset", got)
+	}
+
+	// 锁定期间超过窗口的失败不会清零连续锁定次数
+	later := now.Add(30 * time.Second)
+	got, err = repo.IncrementFailure(ctx, "ip", "10.0.0.1", later, later.Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LockCount != 1 || got.Failures != 1 {
+		t.Fatalf("failure = %+v, want lock_count 1 and failures 1", got)
+	}
+
+	if err := repo.DeleteFailureByID(ctx, got.ID); err != nil {
+		t.Fatal(err)
+	}
+	list, total, err := repo.ListFailures(ctx, 1, 10, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 || len(list) != 0 {
+		t.Fatalf("failures = %+v, want none", list)
+	}
+}
+
+func TestLoginAudits(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLoginSecurityRepository(testdb.Open(t))
+
+	for _, audit := range []*model.LoginAudit{
+		{Username: "alice", IP: "10.0.0.1"},
+		{Username: "bob", IP: "10.0.0.2"},
+	} {
+		if err := repo.CreateAudit(ctx, audit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	audits, total, err := repo.ListAudits(ctx, 1, 10, map[string]interface{}{"username": "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(audits) != 1 || audits[0].Username != "alice" {
+		t.Fatalf("audits = %+v, want alice only", audits)
+	}
+}
@@ -1,74 +1,28 @@
 package repository
 
 import (
+	"context"
 	"template-backend/internal/model"
 
 	"gorm.io/gorm"
 )
 
-type MenuRepository struct {
-	db *gorm.DB
-}
-
-func NewMenuRepository(db *gorm.DB) *MenuRepository {
-	return &MenuRepository{db: db}
-}
-
-func (r *MenuRepository) GetMenuTree(menu model.Menu) ([]*model.Menu, error) {
-	var menus []*model.Menu
-	db := r.db.Model(&model.Menu{})
-	if menu.Type != 0 {
-		db = db.Where("type = ?", menu.Type)
-	}
-
-	if menu.Visible != nil {
-		db = db.Where("visible = ?", *menu.Visible)
-	}
-
-	if menu.Name != "" {
-		db = db.Where("name LIKE ?", "%"+menu.Name+"%")
-	}
-
-	if err := db.Find(&menus).Error; err != nil {
-		return nil, err
-	}
-
-	// 构建树
-	menuMap := make(map[uint]*model.Menu)
-	var roots []*model.Menu
-
-	for _, m := range menus {
-		menuMap[m.ID] = m
-	}
-
-	for _, m := range menus {
-		if m.ParentID != nil {
-			if parent, ok := menuMap[*m.ParentID]; ok {
-				parent.Children = append(parent.Children, m)
-			}
-		} else {
-			roots = append(roots, m)
-		}
-	}
-	return roots, nil
+// MenuRepository 旧版菜单表，菜单已合并到 resources，仅供迁移读取
+type MenuRepository interface {
+	ListAll(ctx context.Context) ([]*model.Menu, error)
 }
 
-func (r *MenuRepository) Create(menu *model.Menu) error {
-	return r.db.Create(menu).Error
-}
-
-func (r *MenuRepository) Update(menu *model.Menu) error {
-	return r.db.Save(menu).Error
+type menuRepository struct {
+	db *gorm.DB
 }
 
-func (r *MenuRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Menu{}, id).Error
+func NewMenuRepository(db *gorm.DB) MenuRepository {
+	return &menuRepository{db: db}
 }
 
-func (r *MenuRepository) GetByID(id uint) (*model.Menu, error) {
-	var menu model.Menu
-	if err := r.db.First(&menu, id).Error; err != nil {
-		return nil, err
-	}
-	return &menu, nil
+// ListAll 查询全部菜单，按排序字段升序
+func (r *menuRepository) ListAll(ctx context.Context) ([]*model.Menu, error) {
+	var menus []*model.Menu
+	err := r.db.WithContext(ctx).Order("sort ASC, id ASC").Find(&menus).Error
+	return menus, err
 }
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"template-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, h *model.PasswordHistory) error
+	ListRecent(ctx context.Context, userID uint, limit int) ([]model.PasswordHistory, error)
+	Prune(ctx context.Context, userID uint, keep int) error
+	DeleteByUser(ctx context.Context, userID uint) error
+}
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: db}
+}
+
+func (r *passwordHistoryRepository) Create(ctx context.Context, h *model.PasswordHistory) error {
+	return r.db.WithContext(ctx).Create(h).Error
+}
+
+// ListRecent 查询用户最近的 limit 条历史密码
+func (r *passwordHistoryRepository) ListRecent(ctx context.Context, userID uint, limit int) ([]model.PasswordHistory, error) {
+	var list []model.PasswordHistory
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// Prune 只保留用户最近的 keep 条历史密码
+func (r *passwordHistoryRepository) Prune(ctx context.Context, userID uint, keep int) error {
+	var ids []uint
+	if err := r.db.WithContext(ctx).Model(&model.PasswordHistory{}).Where("user_id = ?", userID).
+		Order("id DESC").Offset(keep).Limit(1000).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.PasswordHistory{}).Error
+}
+
+func (r *passwordHistoryRepository) DeleteByUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.PasswordHistory{}).Error
+}
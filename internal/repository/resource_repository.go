@@ -1,19 +1,24 @@
 package repository
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"template-backend/internal/model"
 )
 
 type ResourceRepository interface {
-	Create(resource *model.Resource) error
-	GetByID(id int64) (*model.Resource, error)
-	GetByPermissionCode(code string) (*model.Resource, error)
-	Update(id int64, updates map[string]interface{}) error
-	Delete(id int64) error
-	List(query *ResourceQuery) ([]model.Resource, int64, error)
-	ExistsByPermissionCode(code string, excludeID int64) bool
-	QueryAll(query *ResourceQuery) ([]model.Resource, error)
+	Create(ctx context.Context, resource *model.Resource) error
+	GetByID(ctx context.Context, id int64) (*model.Resource, error)
+	GetByPermissionCode(ctx context.Context, code string) (*model.Resource, error)
+	Update(ctx context.Context, id int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context, query *ResourceQuery) ([]model.Resource, int64, error)
+	ExistsByPermissionCode(ctx context.Context, code string, excludeID int64) bool
+	QueryAll(ctx context.Context, query *ResourceQuery) ([]model.Resource, error)
+	ListByType(ctx context.Context, resourceType string) ([]model.Resource, error)
+	ListByTypes(ctx context.Context, types []string) ([]model.Resource, error)
+	Save(ctx context.Context, resource *model.Resource) error
+	GetByLegacyMenuID(ctx context.Context, menuID uint) (*model.Resource, error)
 }
 
 type ResourceQuery struct {
@@ -38,41 +43,41 @@ func NewResourceRepository(db *gorm.DB) ResourceRepository {
 	return &resourceRepository{db: db}
 }
 
-func (r *resourceRepository) Create(resource *model.Resource) error {
-	return r.db.Create(resource).Error
+func (r *resourceRepository) Create(ctx context.Context, resource *model.Resource) error {
+	return r.db.WithContext(ctx).Create(resource).Error
 }
 
-func (r *resourceRepository) GetByID(id int64) (*model.Resource, error) {
+func (r *resourceRepository) GetByID(ctx context.Context, id int64) (*model.Resource, error) {
 	var resource model.Resource
-	err := r.db.Where("id = ?", id).First(&resource).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&resource).Error
 	if err != nil {
 		return nil, err
 	}
 	return &resource, nil
 }
 
-func (r *resourceRepository) GetByPermissionCode(code string) (*model.Resource, error) {
+func (r *resourceRepository) GetByPermissionCode(ctx context.Context, code string) (*model.Resource, error) {
 	var resource model.Resource
-	err := r.db.Where("permission_code = ?", code).First(&resource).Error
+	err := r.db.WithContext(ctx).Where("permission_code = ?", code).First(&resource).Error
 	if err != nil {
 		return nil, err
 	}
 	return &resource, nil
 }
 
-func (r *resourceRepository) Update(id int64, updates map[string]interface{}) error {
-	return r.db.Model(&model.Resource{}).Where("id = ?", id).Updates(updates).Error
+func (r *resourceRepository) Update(ctx context.Context, id int64, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.Resource{}).Where("id = ?", id).Updates(updates).Error
 }
 
-func (r *resourceRepository) Delete(id int64) error {
-	return r.db.Where("id = ?", id).Delete(&model.Resource{}).Error
+func (r *resourceRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Resource{}).Error
 }
 
-func (r *resourceRepository) List(query *ResourceQuery) ([]model.Resource, int64, error) {
+func (r *resourceRepository) List(ctx context.Context, query *ResourceQuery) ([]model.Resource, int64, error) {
 	var resources []model.Resource
 	var total int64
 
-	db := r.db.Model(&model.Resource{})
+	db := r.db.WithContext(ctx).Model(&model.Resource{})
 
 	// 构建查询条件
 	if query.ID != 0 {
@@ -118,10 +123,10 @@ func (r *resourceRepository) List(query *ResourceQuery) ([]model.Resource, int64
 	return resources, total, err
 }
 
-func (r *resourceRepository) QueryAll(query *ResourceQuery) ([]model.Resource, error) {
+func (r *resourceRepository) QueryAll(ctx context.Context, query *ResourceQuery) ([]model.Resource, error) {
 	var resources []model.Resource
 
-	db := r.db.Model(&model.Resource{})
+	db := r.db.WithContext(ctx).Model(&model.Resource{})
 
 	// 构建查询条件
 	if query.ID != 0 {
@@ -160,9 +165,38 @@ func (r *resourceRepository) QueryAll(query *ResourceQuery) ([]model.Resource, e
 	return resources, err
 }
 
-func (r *resourceRepository) ExistsByPermissionCode(code string, excludeID int64) bool {
+// ListByType 查询指定类型的全部资源
+func (r *resourceRepository) ListByType(ctx context.Context, resourceType string) ([]model.Resource, error) {
+	var resources []model.Resource
+	err := r.db.WithContext(ctx).Where("type = ?", resourceType).Order("sort ASC, id ASC").Find(&resources).Error
+	return resources, err
+}
+
+// ListByTypes 查询多种类型的全部资源
+func (r *resourceRepository) ListByTypes(ctx context.Context, types []string) ([]model.Resource, error) {
+	var resources []model.Resource
+	err := r.db.WithContext(ctx).Where("type IN ?", types).Order("sort ASC, id ASC").Find(&resources).Error
+	return resources, err
+}
+
+// Save 保存资源的全部字段
+func (r *resourceRepository) Save(ctx context.Context, resource *model.Resource) error {
+	return r.db.WithContext(ctx).Save(resource).Error
+}
+
+// GetByLegacyMenuID 查询由指定菜单合并而来的资源
+func (r *resourceRepository) GetByLegacyMenuID(ctx context.Context, menuID uint) (*model.Resource, error) {
+	var resource model.Resource
+	err := r.db.WithContext(ctx).Where("legacy_menu_id = ?", menuID).First(&resource).Error
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+func (r *resourceRepository) ExistsByPermissionCode(ctx context.Context, code string, excludeID int64) bool {
 	var count int64
-	query := r.db.Model(&model.Resource{}).Where("permission_code = ?", code)
+	query := r.db.WithContext(ctx).Model(&model.Resource{}).Where("permission_code = ?", code)
 	if excludeID > 0 {
 		query = query.Where("id != ?", excludeID)
 	}
@@ -2,24 +2,38 @@
 package repository
 
 import (
+	"context"
 	"template-backend/internal/model"
 
 	"gorm.io/gorm"
 )
 
-type RoleRepository struct {
+type RoleRepository interface {
+	GetList(ctx context.Context, page, size int, filters map[string]interface{}) ([]model.Role, int64, error)
+	Create(ctx context.Context, role *model.Role) error
+	Update(ctx context.Context, role *model.Role) error
+	Delete(ctx context.Context, id uint) error
+	BatchDelete(ctx context.Context, ids []uint) error
+	GetByID(ctx context.Context, id uint) (*model.Role, error)
+	GetByCode(ctx context.Context, code string) (*model.Role, error)
+	GetPermissions(ctx context.Context, roleID uint) ([]model.Resource, error)
+	UpdatePermissions(ctx context.Context, roleID uint, permissionIds []uint) error
+	GetAllRoleResources(ctx context.Context) ([]model.RoleResource, error)
+}
+
+type roleRepository struct {
 	db *gorm.DB
 }
 
-func NewRoleRepository(db *gorm.DB) *RoleRepository {
-	return &RoleRepository{db: db}
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
 }
 
-func (r *RoleRepository) GetList(page, size int, filters map[string]interface{}) ([]model.Role, int64, error) {
+func (r *roleRepository) GetList(ctx context.Context, page, size int, filters map[string]interface{}) ([]model.Role, int64, error) {
 	var roles []model.Role
 	var total int64
 
-	query := r.db.Model(&model.Role{})
+	query := r.db.WithContext(ctx).Model(&model.Role{})
 
 	if roleName, ok := filters["roleName"]; ok {
 		query = query.Where("role_name LIKE ?", "%"+roleName.(string)+"%")
@@ -40,48 +54,64 @@ func (r *RoleRepository) GetList(page, size int, filters map[string]interface{})
 	return roles, total, err
 }
 
-func (r *RoleRepository) Create(role *model.Role) error {
-	return r.db.Create(role).Error
+func (r *roleRepository) Create(ctx context.Context, role *model.Role) error {
+	return r.db.WithContext(ctx).Create(role).Error
 }
 
-func (r *RoleRepository) Update(role *model.Role) error {
-	return r.db.Save(role).Error
+func (r *roleRepository) Update(ctx context.Context, role *model.Role) error {
+	return r.db.WithContext(ctx).Save(role).Error
 }
 
-func (r *RoleRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Role{}, id).Error
+func (r *roleRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Role{}, id).Error
 }
 
-func (r *RoleRepository) BatchDelete(ids []uint) error {
-	return r.db.Delete(&model.Role{}, ids).Error
+func (r *roleRepository) BatchDelete(ctx context.Context, ids []uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Role{}, ids).Error
+}
+
+func (r *roleRepository) GetByID(ctx context.Context, id uint) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.WithContext(ctx).First(&role, id).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
 }
 
-func (r *RoleRepository) GetByID(id uint) (*model.Role, error) {
+// GetByCode 按角色编码查询角色
+func (r *roleRepository) GetByCode(ctx context.Context, code string) (*model.Role, error) {
 	var role model.Role
-	if err := r.db.First(&role, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("role_code = ?", code).First(&role).Error; err != nil {
 		return nil, err
 	}
 	return &role, nil
 }
 
-func (r *RoleRepository) GetPermissions(roleID uint) ([]model.Resource, error) {
+func (r *roleRepository) GetPermissions(ctx context.Context, roleID uint) ([]model.Resource, error) {
 	var role model.Role
-	if err := r.db.Preload("Resources").First(&role, roleID).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Resources").First(&role, roleID).Error; err != nil {
 		return nil, err
 	}
 	return role.Resources, nil
 }
 
-func (r *RoleRepository) UpdatePermissions(roleID uint, permissionIds []uint) error {
+func (r *roleRepository) UpdatePermissions(ctx context.Context, roleID uint, permissionIds []uint) error {
 	var role model.Role
-	if err := r.db.First(&role, roleID).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&role, roleID).Error; err != nil {
 		return err
 	}
 
 	var permissions []model.Resource
-	if err := r.db.Where("id IN ?", permissionIds).Find(&permissions).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id IN ?", permissionIds).Find(&permissions).Error; err != nil {
 		return err
 	}
 
-	return r.db.Model(&role).Association("Resources").Replace(permissions)
+	return r.db.WithContext(ctx).Model(&role).Association("Resources").Replace(permissions)
+}
+
+// GetAllRoleResources 获取全部角色与资源的关联关系
+func (r *roleRepository) GetAllRoleResources(ctx context.Context) ([]model.RoleResource, error) {
+	var roleResources []model.RoleResource
+	err := r.db.WithContext(ctx).Find(&roleResources).Error
+	return roleResources, err
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"template-backend/internal/dto"
 	"template-backend/internal/model"
 
@@ -8,11 +9,11 @@ import (
 )
 
 type SchoolAdmissionRepository interface {
-	Create(info *model.SchoolAdmissionInfo) error
-	GetByID(id int) (*model.SchoolAdmissionInfo, error)
-	List(req *dto.SchoolAdmissionQueryRequest) ([]model.SchoolAdmissionInfo, int64, error)
-	Update(info *model.SchoolAdmissionInfo) error
-	Delete(id int) error
+	Create(ctx context.Context, info *model.SchoolAdmissionInfo) error
+	GetByID(ctx context.Context, id int) (*model.SchoolAdmissionInfo, error)
+	List(ctx context.Context, req *dto.SchoolAdmissionQueryRequest) ([]model.SchoolAdmissionInfo, int64, error)
+	Update(ctx context.Context, info *model.SchoolAdmissionInfo) error
+	Delete(ctx context.Context, id int) error
 }
 
 type schoolAdmissionRepository struct {
@@ -23,22 +24,22 @@ func NewSchoolAdmissionRepository(db *gorm.DB) SchoolAdmissionRepository {
 	return &schoolAdmissionRepository{db: db}
 }
 
-func (r *schoolAdmissionRepository) Create(info *model.SchoolAdmissionInfo) error {
-	return r.db.Create(info).Error
+func (r *schoolAdmissionRepository) Create(ctx context.Context, info *model.SchoolAdmissionInfo) error {
+	return r.db.WithContext(ctx).Create(info).Error
 }
 
-func (r *schoolAdmissionRepository) GetByID(id int) (*model.SchoolAdmissionInfo, error) {
+func (r *schoolAdmissionRepository) GetByID(ctx context.Context, id int) (*model.SchoolAdmissionInfo, error) {
 	var info model.SchoolAdmissionInfo
-	err := r.db.First(&info, id).Error
+	err := r.db.WithContext(ctx).First(&info, id).Error
 	return &info, err
 }
 
 // 分页 + 搜索查询
-func (r *schoolAdmissionRepository) List(req *dto.SchoolAdmissionQueryRequest) ([]model.SchoolAdmissionInfo, int64, error) {
+func (r *schoolAdmissionRepository) List(ctx context.Context, req *dto.SchoolAdmissionQueryRequest) ([]model.SchoolAdmissionInfo, int64, error) {
 	var list []model.SchoolAdmissionInfo
 	var total int64
 
-	query := r.db.Model(&model.SchoolAdmissionInfo{})
+	query := r.db.WithContext(ctx).Model(&model.SchoolAdmissionInfo{})
 	if req.SchoolName != "" {
 		query = query.Where("school_name LIKE ?", "%"+req.SchoolName+"%")
 	}
@@ -63,10 +64,10 @@ func (r *schoolAdmissionRepository) List(req *dto.SchoolAdmissionQueryRequest) (
 	return list, total, nil
 }
 
-func (r *schoolAdmissionRepository) Update(info *model.SchoolAdmissionInfo) error {
-	return r.db.Save(info).Error
+func (r *schoolAdmissionRepository) Update(ctx context.Context, info *model.SchoolAdmissionInfo) error {
+	return r.db.WithContext(ctx).Save(info).Error
 }
 
-func (r *schoolAdmissionRepository) Delete(id int) error {
-	return r.db.Delete(&model.SchoolAdmissionInfo{}, id).Error
+func (r *schoolAdmissionRepository) Delete(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&model.SchoolAdmissionInfo{}, id).Error
 }
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"template-backend/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	GetByID(ctx context.Context, id string) (*model.Session, error)
+	List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.Session, int64, error)
+	ListActiveByUser(ctx context.Context, userID uint, now time.Time) ([]model.Session, error)
+	Touch(ctx context.Context, id string, ip string, now time.Time) error
+	Extend(ctx context.Context, id string, ip string, now, expiresAt time.Time) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *model.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id string) (*model.Session, error) {
+	var session model.Session
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// List 分页查询当前有效的会话
+func (r *sessionRepository) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.Session, int64, error) {
+	var sessions []model.Session
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.Session{}).Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+	if username, ok := filters["username"]; ok {
+		query = query.Where("username LIKE ?", "%"+username.(string)+"%")
+	}
+	if userID, ok := filters["userId"]; ok {
+		query = query.Where("user_id = ?", userID)
+	}
+	if ip, ok := filters["ip"]; ok {
+		query = query.Where("ip = ?", ip)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("last_seen_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&sessions).Error
+	return sessions, total, err
+}
+
+func (r *sessionRepository) ListActiveByUser(ctx context.Context, userID uint, now time.Time) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// Touch 更新会话最后活跃时间
+func (r *sessionRepository) Touch(ctx context.Context, id string, ip string, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.Session{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_seen_at": now, "ip": ip}).Error
+}
+
+// Extend 刷新令牌轮换后延长会话有效期
+func (r *sessionRepository) Extend(ctx context.Context, id string, ip string, now, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.Session{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_seen_at": now, "ip": ip, "expires_at": expiresAt}).Error
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"template-backend/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+	GetRefreshToken(ctx context.Context, jti string) (*model.RefreshToken, error)
+	ReplaceRefreshToken(ctx context.Context, jti, replacedBy string, now time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string, now time.Time) ([]model.RefreshToken, error)
+	AddRevokedTokens(ctx context.Context, tokens []model.RevokedToken) error
+	ClaimToken(ctx context.Context, token *model.RevokedToken) (bool, error)
+	ListRevokedTokens(ctx context.Context, now time.Time) ([]model.RevokedToken, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+type tokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) TokenRepository {
+	return &tokenRepository{db: db}
+}
+
+func (r *tokenRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *tokenRepository) GetRefreshToken(ctx context.Context, jti string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := r.db.WithContext(ctx).Where("jti = ?", jti).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ReplaceRefreshToken 将未吊销的刷新令牌标记为已轮换，返回是否抢占成功（并发重复使用时只有一个请求成功）
+func (r *tokenRepository) ReplaceRefreshToken(ctx context.Context, jti, replacedBy string, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("jti = ? AND revoked_at IS NULL", jti).
+		Updates(map[string]interface{}{"revoked_at": now, "replaced_by": replacedBy})
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokeFamily 吊销同一家族下的全部刷新令牌并结束对应会话，返回该家族的令牌用于吊销对应的访问令牌
+func (r *tokenRepository) RevokeFamily(ctx context.Context, familyID string, now time.Time) ([]model.RefreshToken, error) {
+	var tokens []model.RefreshToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("family_id = ?", familyID).Find(&tokens).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Session{}).
+			Where("id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error
+	})
+	return tokens, err
+}
+
+func (r *tokenRepository) AddRevokedTokens(ctx context.Context, tokens []model.RevokedToken) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&tokens).Error
+}
+
+// ClaimToken 将一次性令牌的 jti 写入吊销表，返回是否由本次写入；jti 已存在说明令牌已被使用
+func (r *tokenRepository) ClaimToken(ctx context.Context, token *model.RevokedToken) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(token)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *tokenRepository) ListRevokedTokens(ctx context.Context, now time.Time) ([]model.RevokedToken, error) {
+	var tokens []model.RevokedToken
+	err := r.db.WithContext(ctx).Where("expires_at > ?", now).Find(&tokens).Error
+	return tokens, err
+}
+
+// DeleteExpired 清理已过期的吊销记录和刷新令牌
+func (r *tokenRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	if err := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&model.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&model.RefreshToken{}).Error
+}
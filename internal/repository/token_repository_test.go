@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"template-backend/internal/model"
+	"template-backend/internal/testdb"
+	"testing"
+	"time"
+)
+
+func TestReplaceRefreshTokenOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTokenRepository(testdb.Open(t))
+	now := time.Now()
+
+	if err := repo.CreateRefreshToken(ctx, &model.RefreshToken{JTI: "r1", FamilyID: "f1", UserID: 1, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for i, want := range []bool{true, false} {
+		ok, err := repo.ReplaceRefreshToken(ctx, "r1", "r2", now)
+		if err != nil {
+			t.Fatalf("replace #%d: %v", i, err)
+		}
+		if ok != want {
+			t.Fatalf("replace #%d = %v, want %v", i, ok, want)
+		}
+	}
+	token, err := repo.GetRefreshToken(ctx, "r1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if token.RevokedAt == nil || token.ReplacedBy != "r2" {
+		t.Fatalf("token = %+v, want revoked and replaced by r2", token)
+	}
+}
+
+func TestRevokeFamily(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.Open(t)
+	repo, sessions := NewTokenRepository(db), NewSessionRepository(db)
+	now := time.Now()
+	expires := now.Add(time.Hour)
+
+	if err := sessions.Create(ctx, &model.Session{ID: "f1", UserID: 1, LastSeenAt: now, ExpiresAt: expires}); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for _, token := range []*model.RefreshToken{
+		{JTI: "r1", FamilyID: "f1", UserID: 1, ExpiresAt: expires},
+		{JTI: "r2", FamilyID: "f1", UserID: 1, ExpiresAt: expires},
+		{JTI: "other", FamilyID: "f2", UserID: 1, ExpiresAt: expires},
+	} {
+		if err := repo.CreateRefreshToken(ctx, token); err != nil {
+			t.Fatalf("create %s: %v", token.JTI, err)
+		}
+	}
+
+	family, err := repo.RevokeFamily(ctx, "f1", now)
+	if err != nil {
+		t.Fatalf("revoke family: %v", err)
+	}
+	if len(family) != 2 {
+		t.Fatalf("family = %d tokens, want 2", len(family))
+	}
+
+	tests := []struct {
+		jti     string
+		revoked bool
+	}{
+		{"r1", true},
+		{"r2", true},
+		{"other", false},
+	}
+	for _, tt := range tests {
+		token, err := repo.GetRefreshToken(ctx, tt.jti)
+		if err != nil {
+			t.Fatalf("get %s: %v", tt.jti, err)
+		}
+		if got := token.RevokedAt != nil; got != tt.revoked {
+			t.Errorf("%s revoked = %v, want %v", tt.jti, got, tt.revoked)
+		}
+	}
+
+	active, err := sessions.ListActiveByUser(ctx, 1, now)
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(active) != 0 {
+		t.Fatalf("active sessions = %+v, want none", active)
+	}
+}
+
+func TestClaimTokenOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTokenRepository(testdb.Open(t))
+	expires := time.Now().Add(time.Minute)
+
+	for i, want := range []bool{true, false} {
+		ok, err := repo.ClaimToken(ctx, &model.RevokedToken{JTI: "challenge", UserID: 1, ExpiresAt: expires})
+		if err != nil {
+			t.Fatalf("claim #%d: %v", i, err)
+		}
+		if ok != want {
+			t.Fatalf("claim #%d = %v, want %v", i, ok, want)
+		}
+	}
+}
+
+func TestDeleteExpiredTokens(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTokenRepository(testdb.Open(t))
+	now := time.Now()
+
+	err := repo.AddRevokedTokens(ctx, []model.RevokedToken{
+		{JTI: "expired", ExpiresAt: now.Add(-time.Minute)},
+		{JTI: "live", ExpiresAt: now.Add(time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("add revoked: %v", err)
+	}
+	if err := repo.CreateRefreshToken(ctx, &model.RefreshToken{JTI: "old", FamilyID: "f1", ExpiresAt: now.Add(-time.Minute)}); err != nil {
+		t.Fatalf("create refresh: %v", err)
+	}
+
+	if err := repo.DeleteExpired(ctx, now); err != nil {
+		t.Fatalf("delete expired: %v", err)
+	}
+	revoked, err := repo.ListRevokedTokens(ctx, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("list revoked: %v", err)
+	}
+	if len(revoked) != 1 || revoked[0].JTI != "live" {
+		t.Fatalf("revoked = %+v, want [live]", revoked)
+	}
+	if _, err := repo.GetRefreshToken(ctx, "old"); err == nil {
+		t.Fatal("expired refresh token still exists")
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"template-backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userID uint) (*model.UserTwoFactor, error)
+	Save(ctx context.Context, tf *model.UserTwoFactor) error
+	UpdateLastUsedStep(ctx context.Context, userID uint, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, old, codes []string) (bool, error)
+	Delete(ctx context.Context, userID uint) error
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+// Get 获取两步验证配置，不存在时返回 nil
+func (r *twoFactorRepository) Get(ctx context.Context, userID uint) (*model.UserTwoFactor, error) {
+	var tf model.UserTwoFactor
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&tf).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+func (r *twoFactorRepository) Save(ctx context.Context, tf *model.UserTwoFactor) error {
+	return r.db.WithContext(ctx).Save(tf).Error
+}
+
+// UpdateLastUsedStep 仅当时间步大于已使用的时间步时更新，返回是否更新成功
+func (r *twoFactorRepository) UpdateLastUsedStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.UserTwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ReplaceRecoveryCodes 仅当恢复码仍为 old 时替换为 codes，返回是否更新成功；
+// 并发消耗同一恢复码时只有一个请求成功
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, old, codes []string) (bool, error) {
+	// 与字段的 json 序列化器保持一致，按序列化后的文本比较
+	oldJSON, err := json.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+	newJSON, err := json.Marshal(codes)
+	if err != nil {
+		return false, err
+	}
+	result := r.db.WithContext(ctx).Model(&model.UserTwoFactor{}).
+		Where("user_id = ? AND enabled = ? AND recovery_codes = ?", userID, true, string(oldJSON)).
+		Update("recovery_codes", string(newJSON))
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *twoFactorRepository) Delete(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.UserTwoFactor{}).Error
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"template-backend/internal/model"
+	"template-backend/internal/testdb"
+	"testing"
+)
+
+func TestReplaceRecoveryCodes(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTwoFactorRepository(testdb.Open(t))
+
+	codes := []string{"a", "b", "c"}
+	if err := repo.Save(ctx, &model.UserTwoFactor{UserID: 1, Secret: "s", Enabled: true, RecoveryCodes: codes}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		old  []string
+		new  []string
+		ok   bool
+	}{
+		{"消耗 a", codes, []string{"b", "c"}, true},
+		{"并发请求读到旧值", codes, []string{"a", "c"}, false},
+		{"消耗 b", []string{"b", "c"}, []string{"c"}, true},
+	}
+	for _, tt := range tests {
+		ok, err := repo.ReplaceRecoveryCodes(ctx, 1, tt.old, tt.new)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+
+	tf, err := repo.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tf.RecoveryCodes, []string{"c"}) {
+		t.Fatalf("recovery codes = %v, want [c]", tf.RecoveryCodes)
+	}
+}
+
+func TestUpdateLastUsedStep(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTwoFactorRepository(testdb.Open(t))
+	if err := repo.Save(ctx, &model.UserTwoFactor{UserID: 1, Secret: "s", Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		step int64
+		ok   bool
+	}{
+		{100, true},
+		{100, false}, // 同一时间步的验证码不能重复使用
+		{99, false},
+		{101, true},
+	}
+	for _, tt := range tests {
+		ok, err := repo.UpdateLastUsedStep(ctx, 1, tt.step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.ok {
+			t.Errorf("step %d: ok = %v, want %v", tt.step, ok, tt.ok)
+		}
+	}
+}
@@ -1,24 +1,36 @@
 package repository
 
 import (
+	"context"
 	"template-backend/internal/model"
 
 	"gorm.io/gorm"
 )
 
-type UserRepository struct {
+type UserRepository interface {
+	GetList(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.User, int64, error)
+	GetByID(ctx context.Context, id uint) (*model.User, error)
+	Create(ctx context.Context, user *model.User) error
+	Update(ctx context.Context, user *model.User) error
+	Delete(ctx context.Context, id uint) error
+	AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error
+	GetUserRoles(ctx context.Context, userID uint) ([]model.Role, error)
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+}
+
+type userRepository struct {
 	db *gorm.DB
 }
 
-func NewUserRepository(db *gorm.DB) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
 }
 
 // 查询列表（带分页和筛选）
-func (d *UserRepository) GetList(page, pageSize int, filters map[string]interface{}) ([]model.User, int64, error) {
+func (d *userRepository) GetList(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
-	query := d.db.Model(&model.User{})
+	query := d.db.WithContext(ctx).Model(&model.User{})
 
 	// 动态条件
 	if username, ok := filters["username"]; ok {
@@ -41,7 +53,7 @@ func (d *UserRepository) GetList(page, pageSize int, filters map[string]interfac
 
 	err = query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&users).Error
 	for i := range users {
-		roles, err := d.GetUserRoles(users[i].ID)
+		roles, err := d.GetUserRoles(ctx, users[i].ID)
 		if err != nil {
 			continue
 		}
@@ -50,12 +62,12 @@ func (d *UserRepository) GetList(page, pageSize int, filters map[string]interfac
 	return users, total, err
 }
 
-func (d *UserRepository) GetByID(id uint) (*model.User, error) {
+func (d *userRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
-	if err := d.db.First(&user, id).Error; err != nil {
+	if err := d.db.WithContext(ctx).First(&user, id).Error; err != nil {
 		return nil, err
 	}
-	roles, err := d.GetUserRoles(user.ID)
+	roles, err := d.GetUserRoles(ctx, user.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -63,22 +75,22 @@ func (d *UserRepository) GetByID(id uint) (*model.User, error) {
 	return &user, nil
 }
 
-func (d *UserRepository) Create(user *model.User) error {
-	return d.db.Create(user).Error
+func (d *userRepository) Create(ctx context.Context, user *model.User) error {
+	return d.db.WithContext(ctx).Create(user).Error
 }
 
-func (d *UserRepository) Update(user *model.User) error {
-	return d.db.Save(user).Error
+func (d *userRepository) Update(ctx context.Context, user *model.User) error {
+	return d.db.WithContext(ctx).Save(user).Error
 }
 
-func (d *UserRepository) Delete(id uint) error {
-	return d.db.Delete(&model.User{}, id).Error
+func (d *userRepository) Delete(ctx context.Context, id uint) error {
+	return d.db.WithContext(ctx).Delete(&model.User{}, id).Error
 }
 
 // 为用户分配角色
-func (d *UserRepository) AssignRoles(userID uint, roleIDs []uint) error {
+func (d *userRepository) AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error {
 	// 先删除用户现有的所有角色
-	if err := d.db.Where("user_id = ?", userID).Delete(&model.UserRole{}).Error; err != nil {
+	if err := d.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.UserRole{}).Error; err != nil {
 		return err
 	}
 
@@ -91,17 +103,26 @@ func (d *UserRepository) AssignRoles(userID uint, roleIDs []uint) error {
 				RoleID: roleID,
 			})
 		}
-		return d.db.Create(&userRoles).Error
+		return d.db.WithContext(ctx).Create(&userRoles).Error
 	}
 
 	return nil
 }
 
 // 获取用户的角色
-func (d *UserRepository) GetUserRoles(userID uint) ([]model.Role, error) {
+func (d *userRepository) GetUserRoles(ctx context.Context, userID uint) ([]model.Role, error) {
 	var user model.User
-	if err := d.db.Preload("Roles").First(&user, userID).Error; err != nil {
+	if err := d.db.WithContext(ctx).Preload("Roles").First(&user, userID).Error; err != nil {
 		return nil, err
 	}
 	return user.Roles, nil
 }
+
+// GetByUsername 按用户名精确查询用户
+func (d *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	var user model.User
+	if err := d.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
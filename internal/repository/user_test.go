@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"template-backend/internal/model"
+	"template-backend/internal/testdb"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestUserRolesAndPermissions(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.Open(t)
+	users, roles, resources := NewUserRepository(db), NewRoleRepository(db), NewResourceRepository(db)
+
+	user := &model.User{Username: "alice"}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	admin := &model.Role{RoleName: "管理员", RoleCode: "admin"}
+	auditor := &model.Role{RoleName: "审计员", RoleCode: "auditor"}
+	for _, role := range []*model.Role{admin, auditor} {
+		if err := roles.Create(ctx, role); err != nil {
+			t.Fatalf("create role: %v", err)
+		}
+	}
+
+	if err := users.AssignRoles(ctx, user.ID, []uint{admin.ID, auditor.ID}); err != nil {
+		t.Fatalf("assign roles: %v", err)
+	}
+	// 再次分配会替换原有角色
+	if err := users.AssignRoles(ctx, user.ID, []uint{auditor.ID}); err != nil {
+		t.Fatalf("reassign roles: %v", err)
+	}
+	got, err := users.GetByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatalf("get by username: %v", err)
+	}
+	got, err = users.GetByID(ctx, got.ID)
+	if err != nil {
+		t.Fatalf("get by id: %v", err)
+	}
+	if len(got.Roles) != 1 || got.Roles[0].RoleCode != "auditor" {
+		t.Fatalf("roles = %+v, want [auditor]", got.Roles)
+	}
+
+	list := &model.Resource{ResourceName: "日志列表", PermissionCode: "log:list", Type: model.ResourceTypeAPI}
+	export := &model.Resource{ResourceName: "日志导出", PermissionCode: "log:export", Type: model.ResourceTypeAPI}
+	for _, resource := range []*model.Resource{list, export} {
+		if err := resources.Create(ctx, resource); err != nil {
+			t.Fatalf("create resource: %v", err)
+		}
+	}
+	if err := roles.UpdatePermissions(ctx, auditor.ID, []uint{uint(list.ID), uint(export.ID)}); err != nil {
+		t.Fatalf("update permissions: %v", err)
+	}
+	if err := roles.UpdatePermissions(ctx, auditor.ID, []uint{uint(list.ID)}); err != nil {
+		t.Fatalf("replace permissions: %v", err)
+	}
+	permissions, err := roles.GetPermissions(ctx, auditor.ID)
+	if err != nil {
+		t.Fatalf("get permissions: %v", err)
+	}
+	if len(permissions) != 1 || permissions[0].PermissionCode != "log:list" {
+		t.Fatalf("permissions = %+v, want [log:list]", permissions)
+	}
+
+	if err := users.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if _, err := users.GetByID(ctx, user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("get deleted user err = %v, want ErrRecordNotFound", err)
+	}
+}
@@ -1,12 +1,14 @@
 package router
 
 import (
+	"template-backend/internal/app"
+
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
+// RouteRegistrar 路由模块，从应用容器中获取所需的服务
 type RouteRegistrar interface {
-	Register(rg *gin.RouterGroup, db *gorm.DB)
+	Register(rg *gin.RouterGroup, a *app.App)
 }
 
 var registrars []RouteRegistrar
@@ -15,9 +17,9 @@ func RegisterRouteModule(module RouteRegistrar) {
 	registrars = append(registrars, module)
 }
 
-func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
+func RegisterRoutes(r *gin.Engine, a *app.App) {
 	api := r.Group("/api")
 	for _, m := range registrars {
-		m.Register(api, db)
+		m.Register(api, a)
 	}
 }
@@ -1,41 +1,71 @@
 package service
 
 import (
+	"context"
 	"go.uber.org/zap"
 	"template-backend/internal/model"
 	"template-backend/internal/repository"
 	"template-backend/pkg/logger"
+	"template-backend/pkg/tracing"
 )
 
-type AdmissionPlanService struct {
-	repo *repository.AdmissionPlanRepo
+type AdmissionPlanService interface {
+	List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.HighSchoolAdmissionPlan, int64, error)
+	GetByID(ctx context.Context, id int) (*model.HighSchoolAdmissionPlan, error)
+	Create(ctx context.Context, plan *model.HighSchoolAdmissionPlan) error
+	Update(ctx context.Context, id int, plan *model.HighSchoolAdmissionPlan) error
+	Delete(ctx context.Context, id int) error
 }
 
-func NewAdmissionPlanService(repo *repository.AdmissionPlanRepo) *AdmissionPlanService {
-	return &AdmissionPlanService{repo: repo}
+type admissionPlanService struct {
+	repo repository.AdmissionPlanRepository
 }
 
-func (s *AdmissionPlanService) List(page, pageSize int, filters map[string]interface{}) ([]model.HighSchoolAdmissionPlan, int64, error) {
-	logger.Logger().Info("List 服务层调用", zap.Int("page", page), zap.Int("pageSize", pageSize), zap.Any("filters", filters))
-	return s.repo.List(page, pageSize, filters)
+func NewAdmissionPlanService(repo repository.AdmissionPlanRepository) AdmissionPlanService {
+	return &admissionPlanService{repo: repo}
 }
 
-func (s *AdmissionPlanService) GetByID(id int) (*model.HighSchoolAdmissionPlan, error) {
-	logger.Logger().Info("GetByID 服务层调用", zap.Int("id", id))
-	return s.repo.GetByID(id)
+func (s *admissionPlanService) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.HighSchoolAdmissionPlan, int64, error) {
+	ctx, span := tracing.Start(ctx, "AdmissionPlanService.List")
+	defer span.End()
+	logger.FromContext(ctx).Info("List 服务层调用", zap.Int("page", page), zap.Int("pageSize", pageSize), zap.Any("filters", filters))
+	return s.repo.List(ctx, page, pageSize, filters)
 }
 
-func (s *AdmissionPlanService) Create(plan *model.HighSchoolAdmissionPlan) error {
-	logger.Logger().Info("Create 服务层调用", zap.Any("plan", plan))
-	return s.repo.Create(plan)
+func (s *admissionPlanService) GetByID(ctx context.Context, id int) (*model.HighSchoolAdmissionPlan, error) {
+	ctx, span := tracing.Start(ctx, "AdmissionPlanService.GetByID")
+	defer span.End()
+	logger.FromContext(ctx).Info("GetByID 服务层调用", zap.Int("id", id))
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *AdmissionPlanService) Update(id int, plan *model.HighSchoolAdmissionPlan) error {
-	logger.Logger().Info("Update 服务层调用", zap.Int("id", id), zap.Any("plan", plan))
-	return s.repo.Update(id, plan)
+func (s *admissionPlanService) Create(ctx context.Context, plan *model.HighSchoolAdmissionPlan) error {
+	ctx, span := tracing.Start(ctx, "AdmissionPlanService.Create")
+	defer span.End()
+	logger.FromContext(ctx).Info("Create 服务层调用", zap.Any("plan", plan))
+	plan.CreatedBy = 0 // 由数据范围插件填充为当前用户
+	return s.repo.Create(ctx, plan)
 }
 
-func (s *AdmissionPlanService) Delete(id int) error {
-	logger.Logger().Info("Delete 服务层调用", zap.Int("id", id))
-	return s.repo.Delete(id)
+func (s *admissionPlanService) Update(ctx context.Context, id int, plan *model.HighSchoolAdmissionPlan) error {
+	ctx, span := tracing.Start(ctx, "AdmissionPlanService.Update")
+	defer span.End()
+	logger.FromContext(ctx).Info("Update 服务层调用", zap.Int("id", id), zap.Any("plan", plan))
+	// 先在数据范围内查询，范围外的记录按不存在处理
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	plan.CreatedBy = existing.CreatedBy
+	return s.repo.Update(ctx, id, plan)
+}
+
+func (s *admissionPlanService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "AdmissionPlanService.Delete")
+	defer span.End()
+	logger.FromContext(ctx).Info("Delete 服务层调用", zap.Int("id", id))
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
 }
@@ -0,0 +1,214 @@
+// internal/service/api_sync_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APIRoute 已注册但没有对应接口资源的路由
+type APIRoute struct {
+	Method         string `json:"method"`
+	Path           string `json:"path"`
+	Handler        string `json:"handler"`
+	PermissionCode string `json:"permissionCode"` // 生成的权限标识
+}
+
+// APISyncReport 路由与接口资源的差异，Created 为本次新建的资源
+type APISyncReport struct {
+	Matched  int              `json:"matched"`
+	Missing  []APIRoute       `json:"missing"`
+	Orphaned []model.Resource `json:"orphaned"` // 路由已不存在的接口资源，只提示不删除
+	Created  []model.Resource `json:"created"`
+}
+
+// APISyncService 按 HTTP 方法 + 路由模板比对 gin 路由和接口资源
+type APISyncService interface {
+	Diff(ctx context.Context, routes gin.RoutesInfo) (*APISyncReport, error)
+	Sync(ctx context.Context, routes gin.RoutesInfo, public []string) (*APISyncReport, error)
+}
+
+type apiSyncService struct {
+	resourceRepo repository.ResourceRepository
+	skipAuthURLs []string
+	permCache    *PermissionCache
+}
+
+func NewAPISyncService(resourceRepo repository.ResourceRepository, skipAuthURLs []string, permCache *PermissionCache) APISyncService {
+	return &apiSyncService{resourceRepo: resourceRepo, skipAuthURLs: skipAuthURLs, permCache: permCache}
+}
+
+// Diff 只比对不写入
+func (s *apiSyncService) Diff(ctx context.Context, routes gin.RoutesInfo) (*APISyncReport, error) {
+	resources, err := s.resourceRepo.ListByType(ctx, model.ResourceTypeAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		if resource.ResourcePath != nil && resource.HTTPMethod != nil {
+			existing[apiKey(*resource.HTTPMethod, *resource.ResourcePath)] = true
+		}
+	}
+
+	report := &APISyncReport{}
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		key := apiKey(route.Method, route.Path)
+		registered[key] = true
+		// 兼容登记时未带 /api 前缀的资源路径
+		registered[apiKey(route.Method, strings.TrimPrefix(route.Path, "/api"))] = true
+		if existing[key] || existing[apiKey(route.Method, strings.TrimPrefix(route.Path, "/api"))] {
+			report.Matched++
+			continue
+		}
+		report.Missing = append(report.Missing, APIRoute{
+			Method:         route.Method,
+			Path:           route.Path,
+			Handler:        handlerName(route.Handler),
+			PermissionCode: APIPermissionCode(route.Method, route.Path),
+		})
+	}
+	sort.Slice(report.Missing, func(i, j int) bool {
+		if report.Missing[i].Path != report.Missing[j].Path {
+			return report.Missing[i].Path < report.Missing[j].Path
+		}
+		return report.Missing[i].Method < report.Missing[j].Method
+	})
+
+	for _, resource := range resources {
+		if resource.ResourcePath == nil || resource.HTTPMethod == nil {
+			continue
+		}
+		if !registered[apiKey(*resource.HTTPMethod, *resource.ResourcePath)] {
+			report.Orphaned = append(report.Orphaned, resource)
+		}
+	}
+	return report, nil
+}
+
+// Sync 比对后为缺少的路由创建接口资源，按第一级路径挂到分组节点下。
+// public 为路径前缀，匹配的接口只要求登录、不校验角色授权
+func (s *apiSyncService) Sync(ctx context.Context, routes gin.RoutesInfo, public []string) (*APISyncReport, error) {
+	report, err := s.Diff(ctx, routes)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*model.Resource)
+	for _, route := range report.Missing {
+		group, created, err := s.apiGroup(ctx, route.Path, groups)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			report.Created = append(report.Created, *group)
+		}
+
+		// 权限标识被其他资源占用（例如路由改名前的失效资源）时追加序号
+		code := route.PermissionCode
+		for n := 2; s.resourceRepo.ExistsByPermissionCode(ctx, code, 0); n++ {
+			code = fmt.Sprintf("%s#%d", route.PermissionCode, n)
+		}
+		method, path := route.Method, route.Path
+		resource := &model.Resource{
+			ResourceName:   truncateRunes(route.Handler, 50),
+			PermissionCode: code,
+			Type:           model.ResourceTypeAPI,
+			ResourcePath:   &path,
+			HTTPMethod:     &method,
+			ParentID:       &group.ID,
+			Status:         1,
+			RequiresAuth:   1,
+		}
+		if s.isPublic(path, public) {
+			resource.RequiresAuth = 0
+		}
+		if err := insertPublicAware(ctx, s.resourceRepo, resource); err != nil {
+			return nil, fmt.Errorf("创建接口 %s %s 失败: %w", method, path, err)
+		}
+		report.Created = append(report.Created, *resource)
+	}
+
+	if len(report.Created) > 0 {
+		s.permCache.Invalidate()
+	}
+	return report, nil
+}
+
+// apiGroup 获取或创建接口分组节点，分组节点没有路径和方法，不参与鉴权匹配
+func (s *apiSyncService) apiGroup(ctx context.Context, path string, groups map[string]*model.Resource) (*model.Resource, bool, error) {
+	segment := strings.SplitN(strings.TrimPrefix(path, "/api/"), "/", 2)[0]
+	if group, ok := groups[segment]; ok {
+		return group, false, nil
+	}
+
+	code := "api:" + segment
+	created := false
+	group, err := s.resourceRepo.GetByPermissionCode(ctx, code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		group = &model.Resource{
+			ResourceName:   truncateRunes(segment+" 接口", 50),
+			PermissionCode: code,
+			Type:           model.ResourceTypeAPI,
+			Status:         1,
+			RequiresAuth:   1,
+		}
+		err = s.resourceRepo.Create(ctx, group)
+		created = err == nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("创建接口分组 %s 失败: %w", code, err)
+	}
+	groups[segment] = group
+	return group, created, nil
+}
+
+func (s *apiSyncService) isPublic(path string, public []string) bool {
+	for _, prefix := range public {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	// 与 JWT 中间件的免登录地址保持一致
+	_, ok := utils.MatchPath(s.skipAuthURLs, path)
+	return ok
+}
+
+// APIPermissionCode 接口资源的权限标识，例如 api:GET:/api/users/:id
+func APIPermissionCode(method, path string) string {
+	return "api:" + strings.ToUpper(method) + ":" + path
+}
+
+// handlerName 取处理函数名的最后一段，例如 (*UserHandler).GetList
+func handlerName(handler string) string {
+	handler = strings.TrimSuffix(handler, "-fm")
+	if i := strings.LastIndex(handler, "/"); i >= 0 {
+		handler = handler[i+1:]
+	}
+	if i := strings.Index(handler, "."); i >= 0 {
+		handler = handler[i+1:]
+	}
+	return handler
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
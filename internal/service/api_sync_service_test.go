@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/internal/testdb"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSyncPersistsRequiresAuth(t *testing.T) {
+	db := testdb.Open(t)
+	svc := NewAPISyncService(repository.NewResourceRepository(db), []string{"/api/auth/login"}, NewPermissionCache())
+
+	routes := gin.RoutesInfo{
+		{Method: "GET", Path: "/api/users", Handler: "handler.(*UserHandler).GetList-fm"},
+		{Method: "GET", Path: "/api/public/ping", Handler: "handler.Ping"},
+		{Method: "POST", Path: "/api/auth/login", Handler: "handler.(*AuthHandler).Login-fm"},
+	}
+	if _, err := svc.Sync(context.Background(), routes, []string{"/api/public/"}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	tests := []struct {
+		method, path string
+		requiresAuth int8
+	}{
+		{"GET", "/api/users", 1},
+		{"GET", "/api/public/ping", 0},
+		{"POST", "/api/auth/login", 0},
+	}
+	for _, tt := range tests {
+		// 直接从数据库读取，确认写入的是 0 而不是列默认值
+		var resource model.Resource
+		if err := db.Where("http_method = ? AND resource_path = ?", tt.method, tt.path).First(&resource).Error; err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+		}
+		if resource.RequiresAuth != tt.requiresAuth {
+			t.Errorf("%s %s requires_auth = %d, want %d", tt.method, tt.path, resource.RequiresAuth, tt.requiresAuth)
+		}
+	}
+}
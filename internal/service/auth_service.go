@@ -2,158 +2,351 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"template-backend/internal/model"
 	"template-backend/internal/repository"
+	"template-backend/pkg/tracing"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-var jwtSecret = []byte("secret123")
+// ErrChallengeInvalid 两步验证挑战令牌无效或已过期，需要重新输入密码
+var ErrChallengeInvalid = errors.New("登录已过期，请重新登录")
+
+// dummyPasswordHash 用户不存在时同样做一次 bcrypt 比较，响应耗时与密码错误一致，避免据此枚举用户名。
+// 成本与保存密码时的 bcrypt.DefaultCost 相同，预先生成以免首次比较多一次哈希计算
+var dummyPasswordHash = []byte("$2a$10$8CpPcQYdIdzQZn/2yCAy/e/q0tGtcuBQtDYSh08tcwoYgufFzmR.m")
+
+type AuthService interface {
+	Login(ctx context.Context, form *model.LoginForm, client ClientInfo) (*model.LoginResponse, error)
+	VerifyTwoFactor(ctx context.Context, form *model.TwoFactorLoginForm, client ClientInfo) (*model.LoginResponse, error)
+	SetupTwoFactorByChallenge(ctx context.Context, challengeToken string) (*model.TwoFactorSetup, error)
+	SetupTwoFactor(ctx context.Context, userID uint) (*model.TwoFactorSetup, error)
+	EnableTwoFactor(ctx context.Context, userID uint, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	GenerateCaptcha() (*model.CaptchaResponse, error)
+	GetUserInfo(ctx context.Context, userID uint) (model.UserInfo, error)
+	RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*model.TokenResponse, error)
+	Logout(ctx context.Context, userID uint, jti string, expiresAt time.Time, familyID string) error
+	ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error
+}
 
-type AuthService struct {
-	userRepo *repository.UserRepository
-	roleRepo *repository.RoleRepository
+type authService struct {
+	userRepo       repository.UserRepository
+	roleRepo       repository.RoleRepository
+	tokenService   TokenService
+	sessionService SessionService
+	loginGuard     LoginGuardService
+	twoFactor      TwoFactorService
+	passwords      PasswordService
 }
 
-func NewAuthService(userRepo *repository.UserRepository, roleRepo *repository.RoleRepository) *AuthService {
-	return &AuthService{userRepo: userRepo, roleRepo: roleRepo}
+func NewAuthService(userRepo repository.UserRepository, roleRepo repository.RoleRepository,
+	tokenService TokenService, sessionService SessionService, loginGuard LoginGuardService,
+	twoFactor TwoFactorService, passwords PasswordService) AuthService {
+	return &authService{
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		tokenService:   tokenService,
+		sessionService: sessionService,
+		loginGuard:     loginGuard,
+		twoFactor:      twoFactor,
+		passwords:      passwords,
+	}
 }
 
 // Login 用户登录验证
-func (s *AuthService) Login(username, password string) (*model.LoginResponse, error) {
+func (s *authService) Login(ctx context.Context, form *model.LoginForm, client ClientInfo) (*model.LoginResponse, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer span.End()
+	username := form.Username
+
+	// 用户名或 IP 被临时锁定
+	if err := s.loginGuard.CheckLocked(ctx, username, client.IP); err != nil {
+		s.loginGuard.Audit(ctx, username, 0, client, false, LoginReasonLocked)
+		return nil, err
+	}
+
+	// 近期失败次数过多时需要验证码
+	if s.loginGuard.NeedCaptcha(ctx, username, client.IP) {
+		if form.CaptchaID == "" {
+			s.loginGuard.Audit(ctx, username, 0, client, false, LoginReasonCaptchaRequired)
+			return nil, ErrCaptchaRequired
+		}
+		if !s.loginGuard.VerifyCaptcha(form.CaptchaID, form.CaptchaCode) {
+			s.loginGuard.Audit(ctx, username, 0, client, false, LoginReasonCaptchaInvalid)
+			return nil, ErrCaptchaInvalid
+		}
+	}
+
 	// 根据用户名查找用户
-	users, _, err := s.userRepo.GetList(1, 1, map[string]interface{}{"username": username})
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(form.Password))
+		s.loginGuard.RecordFailure(ctx, username, client.IP)
+		s.loginGuard.Audit(ctx, username, 0, client, false, LoginReasonUserNotFound)
+		return nil, ErrBadCredentials
+	}
+
+	// 验证密码
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(form.Password)); err != nil {
+		s.loginGuard.RecordFailure(ctx, username, client.IP)
+		s.loginGuard.Audit(ctx, username, user.ID, client, false, LoginReasonBadPassword)
+		return nil, ErrBadCredentials
+	}
+
+	if user.Status == 0 {
+		s.loginGuard.Audit(ctx, username, user.ID, client, false, LoginReasonDisabled)
+		return nil, errors.New("账号已被禁用")
+	}
+
+	// 已启用两步验证或所属角色强制要求时，先返回挑战令牌
+	_, roles, err := s.getUserRoles(ctx, user.ID)
 	if err != nil {
 		return nil, err
 	}
+	enabled, err := s.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled || s.twoFactor.Enforced(roles) {
+		challenge, err := s.tokenService.IssueChallengeToken(ctx, user, !enabled)
+		if err != nil {
+			return nil, err
+		}
+		return &model.LoginResponse{
+			TwoFactorRequired:      enabled,
+			TwoFactorSetupRequired: !enabled,
+			ChallengeToken:         challenge,
+		}, nil
+	}
 
-	if len(users) == 0 {
-		return nil, errors.New("用户不存在")
+	return s.completeLogin(ctx, user, client)
+}
+
+// VerifyTwoFactor 校验挑战令牌和验证码（或恢复码）后完成登录；处于绑定流程时同时启用两步验证
+func (s *authService) VerifyTwoFactor(ctx context.Context, form *model.TwoFactorLoginForm, client ClientInfo) (*model.LoginResponse, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.VerifyTwoFactor")
+	defer span.End()
+	user, setup, err := s.challengeUser(ctx, form.ChallengeToken, true)
+	if err != nil {
+		return nil, err
 	}
 
-	user := users[0]
+	if err := s.loginGuard.CheckLocked(ctx, user.Username, client.IP); err != nil {
+		s.loginGuard.Audit(ctx, user.Username, user.ID, client, false, LoginReasonLocked)
+		return nil, err
+	}
 
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, errors.New("密码错误")
+	var recoveryCodes []string
+	switch {
+	case setup:
+		recoveryCodes, err = s.twoFactor.Enable(ctx, user.ID, form.Code)
+	case form.RecoveryCode != "":
+		err = s.twoFactor.VerifyRecoveryCode(ctx, user.ID, form.RecoveryCode)
+	default:
+		err = s.twoFactor.Verify(ctx, user.ID, form.Code)
+	}
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorInvalidCode) {
+			s.loginGuard.RecordFailure(ctx, user.Username, client.IP)
+			s.loginGuard.Audit(ctx, user.Username, user.ID, client, false, LoginReasonTwoFactorInvalid)
+		}
+		return nil, err
 	}
 
-	// 生成 token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId":   user.ID,
-		"username": user.Username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(), // token 有效期24小时
-	})
-	tokenStr, err := token.SignedString(jwtSecret)
+	resp, err := s.completeLogin(ctx, user, client)
 	if err != nil {
 		return nil, err
 	}
+	resp.RecoveryCodes = recoveryCodes
+	return resp, nil
+}
 
-	// 获取用户角色
-	roleNames, roles, err := s.getUserRoles(user.ID)
+// SetupTwoFactorByChallenge 强制启用两步验证的用户在登录过程中绑定认证器
+func (s *authService) SetupTwoFactorByChallenge(ctx context.Context, challengeToken string) (*model.TwoFactorSetup, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.SetupTwoFactorByChallenge")
+	defer span.End()
+	user, setup, err := s.challengeUser(ctx, challengeToken, false)
 	if err != nil {
 		return nil, err
 	}
+	if !setup {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	return s.twoFactor.BeginSetup(ctx, user)
+}
 
-	// 构建用户信息
-	userInfo := model.UserInfo{
-		ID:          user.ID,
-		Username:    user.Username,
-		Email:       user.Email,
-		Nickname:    user.Nickname,
-		Roles:       roleNames,
-		Permissions: s.getUserPermissions(roles), // 根据角色获取权限
-		CreatedAt:   user.CreatedAt.Format(time.DateTime),
-		UpdatedAt:   user.UpdatedAt.Format(time.DateTime),
+// SetupTwoFactor 已登录用户开始绑定认证器
+func (s *authService) SetupTwoFactor(ctx context.Context, userID uint) (*model.TwoFactorSetup, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.SetupTwoFactor")
+	defer span.End()
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
 	}
+	return s.twoFactor.BeginSetup(ctx, user)
+}
 
-	return &model.LoginResponse{
-		Token:     tokenStr,
-		UserInfo:  userInfo,
-		ExpiresIn: 24 * 3600, // 24小时
-	}, nil
+// EnableTwoFactor 校验验证码后启用两步验证，返回恢复码
+func (s *authService) EnableTwoFactor(ctx context.Context, userID uint, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.EnableTwoFactor")
+	defer span.End()
+	return s.twoFactor.Enable(ctx, userID, code)
 }
 
-// GetUserInfo 获取用户信息
-func (s *AuthService) GetUserInfo(userID uint) (model.UserInfo, error) {
-	// 根据ID获取用户
-	user, err := s.userRepo.GetByID(userID)
+// DisableTwoFactor 关闭两步验证，所属角色强制启用时不允许关闭
+func (s *authService) DisableTwoFactor(ctx context.Context, userID uint, code string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.DisableTwoFactor")
+	defer span.End()
+	_, roles, err := s.getUserRoles(ctx, userID)
 	if err != nil {
-		return model.UserInfo{}, err
+		return err
 	}
+	if s.twoFactor.Enforced(roles) {
+		return ErrTwoFactorEnforced
+	}
+	return s.twoFactor.Disable(ctx, userID, code)
+}
 
-	// 获取用户角色
-	roleNames, roles, err := s.getUserRoles(user.ID)
+// RegenerateRecoveryCodes 重新生成恢复码
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RegenerateRecoveryCodes")
+	defer span.End()
+	return s.twoFactor.RegenerateRecoveryCodes(ctx, userID, code)
+}
+
+// completeLogin 登录校验全部通过后签发令牌、记录会话并返回用户信息
+func (s *authService) completeLogin(ctx context.Context, user *model.User, client ClientInfo) (*model.LoginResponse, error) {
+	s.loginGuard.RecordSuccess(ctx, user.Username)
+	s.loginGuard.Audit(ctx, user.Username, user.ID, client, true, LoginReasonSuccess)
+
+	// 生成访问令牌和刷新令牌
+	pair, err := s.tokenService.IssueTokenPair(ctx, user, client)
 	if err != nil {
-		return model.UserInfo{}, err
+		return nil, err
+	}
+	// 记录登录会话
+	if err := s.sessionService.Start(ctx, user, pair, client); err != nil {
+		return nil, err
 	}
 
-	return model.UserInfo{
-		ID:          user.ID,
-		Username:    user.Username,
-		Email:       user.Email,
-		Nickname:    user.Nickname,
-		Roles:       roleNames,
-		Permissions: s.getUserPermissions(roles),
-		CreatedAt:   user.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   user.UpdatedAt.Format(time.RFC3339),
+	userInfo, err := s.buildUserInfo(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginResponse{
+		Token:              pair.AccessToken,
+		RefreshToken:       pair.RefreshToken,
+		UserInfo:           userInfo,
+		ExpiresIn:          pair.AccessExpiresIn,
+		RefreshExpiresIn:   pair.RefreshExpiresIn,
+		MustChangePassword: s.passwords.MustChange(user),
 	}, nil
 }
 
-// RefreshToken 刷新token
-func (s *AuthService) RefreshToken(oldToken string) (string, error) {
-	tok, err := jwt.Parse(oldToken, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+// challengeUser 解析挑战令牌并加载用户，consume 为 true 时令牌随之失效
+func (s *authService) challengeUser(ctx context.Context, challengeToken string, consume bool) (*model.User, bool, error) {
+	userID, setup, err := s.tokenService.ParseChallengeToken(ctx, challengeToken, consume)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidToken) && !errors.Is(err, ErrTokenRevoked) {
+			return nil, false, err
 		}
-		return jwtSecret, nil
-	})
-	if err != nil || !tok.Valid {
-		return "", errors.New("invalid token")
+		return nil, false, ErrChallengeInvalid
 	}
-
-	claims, ok := tok.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", errors.New("invalid claims")
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, false, ErrChallengeInvalid
+	}
+	if user.Status == 0 {
+		return nil, false, errors.New("账号已被禁用")
 	}
+	return user, setup, nil
+}
+
+// GenerateCaptcha 生成登录验证码
+func (s *authService) GenerateCaptcha() (*model.CaptchaResponse, error) {
+	return s.loginGuard.GenerateCaptcha()
+}
 
-	userID, ok := claims["userID"].(float64)
-	if !ok {
-		return "", errors.New("invalid user ID in token")
+// GetUserInfo 获取用户信息
+func (s *authService) GetUserInfo(ctx context.Context, userID uint) (model.UserInfo, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.GetUserInfo")
+	defer span.End()
+	// 根据ID获取用户
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return model.UserInfo{}, err
 	}
+	return s.buildUserInfo(ctx, user)
+}
 
-	username, ok := claims["username"].(string)
-	if !ok {
-		return "", errors.New("invalid username in token")
+// buildUserInfo 构建登录响应和用户信息接口共用的用户信息，时间统一使用 RFC3339 格式
+func (s *authService) buildUserInfo(ctx context.Context, user *model.User) (model.UserInfo, error) {
+	// 获取用户角色
+	roleNames, roles, err := s.getUserRoles(ctx, user.ID)
+	if err != nil {
+		return model.UserInfo{}, err
 	}
 
-	// 验证用户是否存在
-	_, err = s.userRepo.GetByID(uint(userID))
+	twoFactorEnabled, err := s.twoFactor.IsEnabled(ctx, user.ID)
 	if err != nil {
-		return "", errors.New("user not found")
+		return model.UserInfo{}, err
 	}
 
-	// 生成新token
-	newTok := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID":   uint(userID),
-		"username": username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
-	})
-	newTokenStr, err := newTok.SignedString(jwtSecret)
+	return model.UserInfo{
+		ID:               user.ID,
+		Username:         user.Username,
+		Email:            user.Email,
+		Nickname:         user.Nickname,
+		Roles:            roleNames,
+		Permissions:      s.getUserPermissions(ctx, roles), // 根据角色获取权限
+		TwoFactorEnabled: twoFactorEnabled,
+		CreatedAt:        user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        user.UpdatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// RefreshToken 使用刷新令牌换取新的令牌对，旧的刷新令牌随即失效
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*model.TokenResponse, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RefreshToken")
+	defer span.End()
+	pair, err := s.tokenService.Refresh(ctx, refreshToken, client)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	if err := s.sessionService.Refreshed(ctx, pair, client); err != nil {
+		return nil, err
+	}
+	return &model.TokenResponse{
+		Token:            pair.AccessToken,
+		RefreshToken:     pair.RefreshToken,
+		ExpiresIn:        pair.AccessExpiresIn,
+		RefreshExpiresIn: pair.RefreshExpiresIn,
+	}, nil
+}
 
-	return newTokenStr, nil
+// Logout 吊销当前访问令牌及其所属登录的刷新令牌
+func (s *authService) Logout(ctx context.Context, userID uint, jti string, expiresAt time.Time, familyID string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.Logout")
+	defer span.End()
+	return s.tokenService.Revoke(ctx, userID, jti, expiresAt, familyID)
 }
 
 // ChangePassword 修改密码
-func (s *AuthService) ChangePassword(userID uint, oldPassword, newPassword string) error {
+func (s *authService) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.ChangePassword")
+	defer span.End()
 	// 获取用户信息
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return errors.New("用户不存在")
 	}
@@ -163,20 +356,17 @@ func (s *AuthService) ChangePassword(userID uint, oldPassword, newPassword strin
 		return errors.New("旧密码错误")
 	}
 
-	// 加密新密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
-	if err != nil {
+	// 按密码策略校验并更新密码
+	if err := s.passwords.Change(ctx, user, newPassword); err != nil {
 		return err
 	}
-
-	// 更新密码
-	user.Password = string(hashedPassword)
-	return s.userRepo.Update(user)
+	// 修改密码后结束该用户的全部会话
+	return s.sessionService.RevokeUserSessions(ctx, userID)
 }
 
 // getUserRoles 获取用户角色
-func (s *AuthService) getUserRoles(userID uint) ([]string, []model.Role, error) {
-	roles, err := s.userRepo.GetUserRoles(userID)
+func (s *authService) getUserRoles(ctx context.Context, userID uint) ([]string, []model.Role, error) {
+	roles, err := s.userRepo.GetUserRoles(ctx, userID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -189,11 +379,11 @@ func (s *AuthService) getUserRoles(userID uint) ([]string, []model.Role, error)
 }
 
 // getUserPermissions 根据角色获取权限
-func (s *AuthService) getUserPermissions(roles []model.Role) []string {
+func (s *authService) getUserPermissions(ctx context.Context, roles []model.Role) []string {
 	permissions := make([]string, 0)
 
 	for _, role := range roles {
-		getPermissions, err := s.roleRepo.GetPermissions(role.ID)
+		getPermissions, err := s.roleRepo.GetPermissions(ctx, role.ID)
 		if err != nil {
 			continue
 		}
@@ -1,16 +1,17 @@
 package service
 
 import (
+	"context"
 	"template-backend/internal/model"
 	"template-backend/internal/repository"
 )
 
 type ConfigService interface {
-	GetList(params map[string]interface{}) ([]model.Config, int64, error)
-	GetByID(id int64) (*model.Config, error)
-	Create(config *model.Config) error
-	Update(config *model.Config) error
-	Delete(id int64) error
+	GetList(ctx context.Context, params map[string]interface{}) ([]model.Config, int64, error)
+	GetByID(ctx context.Context, id int64) (*model.Config, error)
+	Create(ctx context.Context, config *model.Config) error
+	Update(ctx context.Context, config *model.Config) error
+	Delete(ctx context.Context, id int64) error
 }
 
 type configService struct {
@@ -21,22 +22,22 @@ func NewConfigService(repo repository.ConfigRepository) ConfigService {
 	return &configService{repo: repo}
 }
 
-func (s *configService) GetList(params map[string]interface{}) ([]model.Config, int64, error) {
-	return s.repo.GetList(params)
+func (s *configService) GetList(ctx context.Context, params map[string]interface{}) ([]model.Config, int64, error) {
+	return s.repo.GetList(ctx, params)
 }
 
-func (s *configService) GetByID(id int64) (*model.Config, error) {
-	return s.repo.GetByID(id)
+func (s *configService) GetByID(ctx context.Context, id int64) (*model.Config, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *configService) Create(config *model.Config) error {
-	return s.repo.Create(config)
+func (s *configService) Create(ctx context.Context, config *model.Config) error {
+	return s.repo.Create(ctx, config)
 }
 
-func (s *configService) Update(config *model.Config) error {
-	return s.repo.Update(config)
+func (s *configService) Update(ctx context.Context, config *model.Config) error {
+	return s.repo.Update(ctx, config)
 }
 
-func (s *configService) Delete(id int64) error {
-	return s.repo.Delete(id)
+func (s *configService) Delete(ctx context.Context, id int64) error {
+	return s.repo.Delete(ctx, id)
 }
@@ -0,0 +1,616 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	LogExportCSV    = "csv"
+	LogExportXLSX   = "xlsx"
+	LogExportNDJSON = "ndjson"
+)
+
+const (
+	LogExportPending = "pending"
+	LogExportRunning = "running"
+	LogExportDone    = "done"
+	LogExportFailed  = "failed"
+)
+
+// xlsxMaxRows 单个工作表最多 1048576 行，其中一行是表头
+const xlsxMaxRows = 1048575
+
+var (
+	ErrLogExportFormat  = errors.New("不支持的导出格式")
+	ErrLogExportColumn  = errors.New("不支持的导出列")
+	ErrLogExportTooMany = errors.New("导出行数超过 xlsx 单表上限，请缩小范围或使用 csv/ndjson")
+	ErrLogExportBusy    = errors.New("后台导出任务已达上限，请稍后再试")
+	ErrLogExportClosed  = errors.New("服务正在停止，不再接受导出任务")
+)
+
+// LogLocation 日志查询条件、导出和统计的时间使用东八区，时区数据缺失时退回固定的 UTC+8
+var LogLocation = func() *time.Location {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return time.FixedZone("CST", 8*3600)
+	}
+	return loc
+}()
+
+// logColumn 可导出的列，key 与日志列表返回的 JSON 字段一致
+type logColumn struct {
+	key   string
+	title string
+	value func(*model.Log) interface{}
+}
+
+var logColumns = []logColumn{
+	{"id", "ID", func(l *model.Log) interface{} { return l.ID }},
+	{"timestamp", "时间", func(l *model.Log) interface{} { return l.Timestamp.In(LogLocation).Format(time.DateTime) }},
+	{"method", "请求方法", func(l *model.Log) interface{} { return l.Method }},
+	{"path", "路径", func(l *model.Log) interface{} { return l.Path }},
+	{"query", "查询参数", func(l *model.Log) interface{} { return l.Query }},
+	{"ip", "IP", func(l *model.Log) interface{} { return l.IP }},
+	{"userAgent", "User-Agent", func(l *model.Log) interface{} { return l.UserAgent }},
+	{"status", "状态码", func(l *model.Log) interface{} { return l.Status }},
+	{"latency", "耗时", func(l *model.Log) interface{} { return l.Latency }},
+	{"handler", "处理函数", func(l *model.Log) interface{} { return l.Handler }},
+	{"request", "请求内容", func(l *model.Log) interface{} { return l.Request }},
+	{"response", "响应内容", func(l *model.Log) interface{} { return l.Response }},
+	{"errors", "错误", func(l *model.Log) interface{} { return l.Errors }},
+	{"contentLength", "响应大小", func(l *model.Log) interface{} { return l.ContentLength }},
+	{"truncated", "内容已截断", func(l *model.Log) interface{} { return l.Truncated }},
+	{"traceId", "Trace ID", func(l *model.Log) interface{} { return l.TraceID }},
+	{"requestId", "请求 ID", func(l *model.Log) interface{} { return l.RequestID }},
+}
+
+// defaultLogColumns 未指定列时导出的列，请求和响应内容体积较大需显式指定
+var defaultLogColumns = []string{"id", "timestamp", "method", "path", "status", "latency", "ip", "handler", "requestId", "traceId"}
+
+// LogExportOptions 导出参数，Conditions 与日志列表的查询条件相同
+type LogExportOptions struct {
+	Format     string
+	Columns    []string
+	Conditions map[string]interface{}
+}
+
+// LogExportJob 后台导出任务
+type LogExportJob struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Format     string     `json:"format"`
+	Total      int64      `json:"total"` // 提交时符合条件的行数
+	Rows       int64      `json:"rows"`  // 已写入的行数
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+
+	file string
+}
+
+// FileName 下载时使用的文件名
+func (j *LogExportJob) FileName() string {
+	return LogExportFileName(j.CreatedAt, j.Format)
+}
+
+// LogExportFileName 导出文件名，包含东八区的导出时间
+func LogExportFileName(t time.Time, format string) string {
+	return "logs-" + t.In(LogLocation).Format("20060102150405") + "." + format
+}
+
+// LogExportService 流式导出请求日志：小结果集直接写入响应，
+// 超过 syncLimit 的导出在后台写入 dir 下的文件，完成后通过任务 ID 下载
+type LogExportService interface {
+	Validate(opts LogExportOptions) (LogExportOptions, error)
+	Count(ctx context.Context, opts LogExportOptions) (int64, error)
+	NeedsJob(total int64) bool
+	ContentType(format string) string
+	Export(ctx context.Context, w io.Writer, opts LogExportOptions) (int64, error)
+	Submit(ctx context.Context, opts LogExportOptions, total int64) (*LogExportJob, error)
+	Job(id string) (*LogExportJob, bool)
+	Open(id string) (*os.File, *LogExportJob, error)
+	Cleanup()
+	Start()
+	Stop(ctx context.Context) error
+}
+
+type logExportService struct {
+	repo      repository.LogRepository
+	dir       string
+	syncLimit int64
+	fileTTL   time.Duration
+	slots     chan struct{}
+
+	mu     sync.Mutex
+	jobs   map[string]*LogExportJob
+	closed bool
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewLogExportService 后台任务和过期文件清理的日志输出到 log
+func NewLogExportService(repo repository.LogRepository, dir string, syncLimit int, fileTTL time.Duration, maxJobs int,
+	log *zap.Logger) LogExportService {
+	if maxJobs <= 0 {
+		maxJobs = 1
+	}
+	ctx, cancel := context.WithCancel(logger.WithContext(context.Background(), log))
+	return &logExportService{
+		repo:      repo,
+		dir:       dir,
+		syncLimit: int64(syncLimit),
+		fileTTL:   fileTTL,
+		slots:     make(chan struct{}, maxJobs),
+		jobs:      make(map[string]*LogExportJob),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Validate 检查格式和列并返回规范化后的参数，未指定格式时为 csv，未指定列时使用默认列
+func (s *logExportService) Validate(opts LogExportOptions) (LogExportOptions, error) {
+	switch opts.Format {
+	case "":
+		opts.Format = LogExportCSV
+	case LogExportCSV, LogExportXLSX, LogExportNDJSON:
+	default:
+		return opts, fmt.Errorf("%w: %s", ErrLogExportFormat, opts.Format)
+	}
+	if len(opts.Columns) == 0 {
+		opts.Columns = defaultLogColumns
+	}
+	for _, key := range opts.Columns {
+		if findLogColumn(key) == nil {
+			return opts, fmt.Errorf("%w: %s", ErrLogExportColumn, key)
+		}
+	}
+	return opts, nil
+}
+
+// Count 符合条件的行数，xlsx 超过单表上限时返回 ErrLogExportTooMany
+func (s *logExportService) Count(ctx context.Context, opts LogExportOptions) (int64, error) {
+	total, err := s.repo.Count(ctx, opts.Conditions)
+	if err != nil {
+		return 0, err
+	}
+	if opts.Format == LogExportXLSX && total > xlsxMaxRows {
+		return total, ErrLogExportTooMany
+	}
+	return total, nil
+}
+
+// NeedsJob 行数超过同步导出上限时需要转为后台任务
+func (s *logExportService) NeedsJob(total int64) bool {
+	return s.syncLimit > 0 && total > s.syncLimit
+}
+
+// ContentType 导出格式对应的 Content-Type
+func (s *logExportService) ContentType(format string) string {
+	switch format {
+	case LogExportXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case LogExportNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Export 逐行读取日志写入 w，opts 需先经过 Validate；返回写入的行数
+func (s *logExportService) Export(ctx context.Context, w io.Writer, opts LogExportOptions) (int64, error) {
+	return s.export(ctx, w, opts, nil)
+}
+
+func (s *logExportService) export(ctx context.Context, w io.Writer, opts LogExportOptions, progress func(int64)) (int64, error) {
+	columns := make([]*logColumn, len(opts.Columns))
+	for i, key := range opts.Columns {
+		columns[i] = findLogColumn(key)
+	}
+
+	var out logRowWriter
+	var err error
+	switch opts.Format {
+	case LogExportXLSX:
+		out, err = newXLSXRowWriter(w, columns)
+	case LogExportNDJSON:
+		out = newNDJSONRowWriter(w, columns)
+	default:
+		out, err = newCSVRowWriter(w, columns)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	err = s.repo.Each(ctx, opts.Conditions, func(log *model.Log) error {
+		if err := out.Write(log); err != nil {
+			return err
+		}
+		rows++
+		if progress != nil && rows%1000 == 0 {
+			progress(rows)
+		}
+		return nil
+	})
+	if err != nil {
+		// 出错时不再写入 xlsx 的结尾，只释放 excelize 的临时文件
+		if xw, ok := out.(*xlsxRowWriter); ok {
+			_ = xw.file.Close()
+		}
+		return rows, err
+	}
+	return rows, out.Close()
+}
+
+// Submit 创建后台导出任务，opts 需先经过 Validate，total 为 Count 的结果；
+// 任务不受 ctx 取消影响，只沿用其中的请求级 logger
+func (s *logExportService) Submit(ctx context.Context, opts LogExportOptions, total int64) (*LogExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrLogExportClosed
+	}
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		return nil, ErrLogExportBusy
+	}
+
+	job := &LogExportJob{
+		ID:        uuid.NewString(),
+		Status:    LogExportPending,
+		Format:    opts.Format,
+		Total:     total,
+		CreatedAt: time.Now(),
+	}
+	job.file = filepath.Join(s.dir, job.ID+"."+job.Format)
+	s.jobs[job.ID] = job
+	snapshot := *job
+
+	s.wg.Add(1)
+	go s.run(logger.FromContext(ctx), job, opts)
+	return &snapshot, nil
+}
+
+// Job 任务当前状态的副本
+func (s *logExportService) Job(id string) (*LogExportJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	snapshot := *job
+	return &snapshot, true
+}
+
+// Open 打开已完成任务的导出文件
+func (s *logExportService) Open(id string) (*os.File, *LogExportJob, error) {
+	job, ok := s.Job(id)
+	if !ok {
+		return nil, nil, os.ErrNotExist
+	}
+	if job.Status != LogExportDone {
+		return nil, job, fmt.Errorf("导出任务未完成: %s", job.Status)
+	}
+	f, err := os.Open(job.file)
+	return f, job, err
+}
+
+func (s *logExportService) run(log *zap.Logger, job *LogExportJob, opts LogExportOptions) {
+	defer s.wg.Done()
+	defer func() { <-s.slots }()
+
+	s.update(job, func(j *LogExportJob) { j.Status = LogExportRunning })
+	rows, err := s.writeFile(job, opts)
+
+	now := time.Now()
+	s.update(job, func(j *LogExportJob) {
+		j.Rows = rows
+		j.FinishedAt = &now
+		if err != nil {
+			j.Status = LogExportFailed
+			j.Error = err.Error()
+			return
+		}
+		j.Status = LogExportDone
+		if s.fileTTL > 0 {
+			expires := now.Add(s.fileTTL)
+			j.ExpiresAt = &expires
+		}
+	})
+	if err != nil {
+		log.Error("Log export job failed", zap.String("job", job.ID), zap.Error(err))
+		return
+	}
+	log.Info("Log export job finished", zap.String("job", job.ID), zap.Int64("rows", rows))
+}
+
+// writeFile 先写临时文件，成功后再改名，失败时删除
+func (s *logExportService) writeFile(job *LogExportJob, opts LogExportOptions) (int64, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return 0, err
+	}
+	tmp := job.file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	buf := bufio.NewWriter(f)
+	rows, err := s.export(s.ctx, buf, opts, func(n int64) {
+		s.update(job, func(j *LogExportJob) { j.Rows = n })
+	})
+	if err == nil {
+		err = buf.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, job.file)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return rows, err
+}
+
+func (s *logExportService) update(job *LogExportJob, fn func(*LogExportJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(job)
+}
+
+// Cleanup 删除过期的导出文件和任务记录
+func (s *logExportService) Cleanup() {
+	now := time.Now()
+	var expired []*LogExportJob
+	s.mu.Lock()
+	for id, job := range s.jobs {
+		if job.FinishedAt == nil {
+			continue
+		}
+		// 失败的任务没有文件，保留到同样的时长后移除记录
+		if s.fileTTL > 0 && now.Sub(*job.FinishedAt) > s.fileTTL {
+			expired = append(expired, job)
+			delete(s.jobs, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, job := range expired {
+		if err := os.Remove(job.file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.FromContext(s.ctx).Warn("Failed to remove expired log export", zap.String("file", job.file), zap.Error(err))
+		}
+	}
+}
+
+// Start 启动过期文件清理，清理间隔为保留时长的十分之一，最短一分钟
+func (s *logExportService) Start() {
+	if s.fileTTL <= 0 {
+		return
+	}
+	interval := s.fileTTL / 10
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Cleanup()
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop 不再接受新任务，取消进行中的导出并等待其删除临时文件
+func (s *logExportService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("后台导出任务未退出: %w", ctx.Err())
+	}
+}
+
+// LogExportColumns 可导出的列，按默认顺序排列，供前端选择
+func LogExportColumns() []map[string]string {
+	result := make([]map[string]string, len(logColumns))
+	for i, col := range logColumns {
+		result[i] = map[string]string{"key": col.key, "title": col.title}
+	}
+	return result
+}
+
+func findLogColumn(key string) *logColumn {
+	for i := range logColumns {
+		if logColumns[i].key == key {
+			return &logColumns[i]
+		}
+	}
+	return nil
+}
+
+// logRowWriter 按格式写入单行日志，Close 写入结尾并刷新缓冲
+type logRowWriter interface {
+	Write(log *model.Log) error
+	Close() error
+}
+
+type csvRowWriter struct {
+	w       *csv.Writer
+	columns []*logColumn
+	record  []string
+}
+
+func newCSVRowWriter(w io.Writer, columns []*logColumn) (*csvRowWriter, error) {
+	// UTF-8 BOM，Excel 直接打开时中文不乱码
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, err
+	}
+	cw := &csvRowWriter{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+	for i, col := range columns {
+		cw.record[i] = col.title
+	}
+	return cw, cw.w.Write(cw.record)
+}
+
+func (cw *csvRowWriter) Write(log *model.Log) error {
+	for i, col := range cw.columns {
+		cw.record[i] = cellText(col.value(log))
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvRowWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type ndjsonRowWriter struct {
+	enc     *json.Encoder
+	columns []*logColumn
+}
+
+func newNDJSONRowWriter(w io.Writer, columns []*logColumn) *ndjsonRowWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &ndjsonRowWriter{enc: enc, columns: columns}
+}
+
+func (nw *ndjsonRowWriter) Write(log *model.Log) error {
+	row := make(map[string]interface{}, len(nw.columns))
+	for _, col := range nw.columns {
+		row[col.key] = col.value(log)
+	}
+	return nw.enc.Encode(row)
+}
+
+func (nw *ndjsonRowWriter) Close() error {
+	return nil
+}
+
+// xlsxRowWriter 使用 excelize 的流式写入，行数据超过内存阈值后暂存到临时文件
+type xlsxRowWriter struct {
+	out     io.Writer
+	file    *excelize.File
+	stream  *excelize.StreamWriter
+	columns []*logColumn
+	row     int
+}
+
+func newXLSXRowWriter(w io.Writer, columns []*logColumn) (*xlsxRowWriter, error) {
+	f := excelize.NewFile()
+	stream, err := f.NewStreamWriter("Sheet1")
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	header := make([]interface{}, len(columns))
+	for i, col := range columns {
+		header[i] = col.title
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &xlsxRowWriter{out: w, file: f, stream: stream, columns: columns, row: 1}, nil
+}
+
+func (xw *xlsxRowWriter) Write(log *model.Log) error {
+	xw.row++
+	cells := make([]interface{}, len(xw.columns))
+	for i, col := range xw.columns {
+		// 字符串一律作为文本写入，excelize 不会把它们当作公式
+		switch v := col.value(log).(type) {
+		case int, int64, uint, bool:
+			cells[i] = v
+		default:
+			cells[i] = cellText(v)
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	return xw.stream.SetRow(cell, cells)
+}
+
+func (xw *xlsxRowWriter) Close() error {
+	defer xw.file.Close()
+	if err := xw.stream.Flush(); err != nil {
+		return err
+	}
+	return xw.file.Write(xw.out)
+}
+
+// cellText 表格单元格中的文本，请求和响应内容序列化为 JSON
+func cellText(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(val)
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case uint:
+		return strconv.FormatUint(uint64(val), 10)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return escapeFormula(fmt.Sprint(val))
+		}
+		return escapeFormula(string(data))
+	}
+}
+
+// escapeFormula 路径、User-Agent 等来自请求方，以 = + - @ 制表符或回车开头时加 ' 前缀，
+// 避免在 Excel 中打开时被当作公式执行
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
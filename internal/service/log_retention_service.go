@@ -0,0 +1,315 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	LogPurgeSchedule = "schedule"
+	LogPurgeManual   = "manual"
+
+	LogRetentionDelete  = "delete"
+	LogRetentionArchive = "archive"
+)
+
+var (
+	ErrLogPurgeRunning = errors.New("日志清理正在执行，请稍后再试")
+	ErrLogPurgeStopped = errors.New("服务正在停止，不再执行日志清理")
+)
+
+// LogRetentionService 按保留策略定时物理删除过期的请求日志，archive 模式下删除前先写入归档文件。
+// 每次执行依次处理已软删除的日志、2xx/3xx 日志和 4xx/5xx 日志，每批删除 BatchSize 行，避免长时间锁表
+type LogRetentionService interface {
+	Settings() config.LogRetentionConfig
+	Running() bool
+	LastPurge(ctx context.Context) (*model.LogPurge, error)
+	Run(ctx context.Context, trigger string) (*model.LogPurge, error)
+	Trigger(ctx context.Context, trigger string) error
+	Start() error
+	Stop(ctx context.Context) error
+}
+
+type logRetentionService struct {
+	cfg     config.LogRetentionConfig
+	repo    repository.LogRepository
+	purges  repository.LogPurgeRepository
+	running atomic.Bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewLogRetentionService 定时清理的日志输出到 log
+func NewLogRetentionService(appConfig *config.AppConfig, repo repository.LogRepository, purges repository.LogPurgeRepository,
+	log *zap.Logger) LogRetentionService {
+	cfg := appConfig.LogRetention
+	if cfg.Mode == "" {
+		cfg.Mode = LogRetentionDelete
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 3600
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	ctx, cancel := context.WithCancel(logger.WithContext(context.Background(), log))
+	return &logRetentionService{cfg: cfg, repo: repo, purges: purges, ctx: ctx, cancel: cancel}
+}
+
+// Settings 生效的保留策略（已填充默认值）
+func (s *logRetentionService) Settings() config.LogRetentionConfig {
+	return s.cfg
+}
+
+// Running 是否正在清理
+func (s *logRetentionService) Running() bool {
+	return s.running.Load()
+}
+
+// LastPurge 最近一次清理结果，从未执行过时为 nil
+func (s *logRetentionService) LastPurge(ctx context.Context) (*model.LogPurge, error) {
+	return s.purges.Latest(ctx)
+}
+
+// Run 同步执行一次清理并保存结果，已有清理在执行时返回 ErrLogPurgeRunning
+func (s *logRetentionService) Run(ctx context.Context, trigger string) (*model.LogPurge, error) {
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, ErrLogPurgeRunning
+	}
+	defer s.running.Store(false)
+	return s.execute(ctx, trigger)
+}
+
+// Trigger 在后台执行一次清理，结果通过 LastPurge 查询；
+// 清理随服务停止而中断，不受 ctx 取消影响，只沿用其中的请求级 logger
+func (s *logRetentionService) Trigger(ctx context.Context, trigger string) error {
+	if s.ctx.Err() != nil {
+		return ErrLogPurgeStopped
+	}
+	if !s.running.CompareAndSwap(false, true) {
+		return ErrLogPurgeRunning
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.running.Store(false)
+		_, _ = s.execute(logger.WithContext(s.ctx, logger.FromContext(ctx)), trigger)
+	}()
+	return nil
+}
+
+func (s *logRetentionService) execute(ctx context.Context, trigger string) (*model.LogPurge, error) {
+	purge := &model.LogPurge{Trigger: trigger, Mode: s.cfg.Mode, StartedAt: time.Now(), Files: []string{}}
+	err := s.purge(ctx, purge)
+	purge.FinishedAt = time.Now()
+
+	fields := []zap.Field{
+		zap.String("trigger", trigger),
+		zap.Int64("softDeleted", purge.SoftDeleted),
+		zap.Int64("success", purge.Success),
+		zap.Int64("failure", purge.Failure),
+		zap.Int64("archived", purge.Archived),
+		zap.Duration("elapsed", purge.FinishedAt.Sub(purge.StartedAt)),
+	}
+	if err != nil {
+		purge.Error = err.Error()
+		logger.FromContext(ctx).Error("Log purge failed", append(fields, zap.Error(err))...)
+	} else {
+		logger.FromContext(ctx).Info("Log purge finished", fields...)
+	}
+
+	if createErr := s.purges.Create(ctx, purge); createErr != nil {
+		logger.FromContext(ctx).Error("Failed to save log purge result", zap.Error(createErr))
+	}
+	return purge, err
+}
+
+func (s *logRetentionService) purge(ctx context.Context, purge *model.LogPurge) error {
+	// 已软删除的日志是用户主动删除的，不归档
+	n, err := s.deleteBatches(ctx, repository.LogPurgeScope{SoftDeleted: true}, nil)
+	purge.SoftDeleted = n
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	classes := []struct {
+		name  string
+		rule  config.LogRetentionRule
+		scope repository.LogPurgeScope
+		count *int64
+	}{
+		{"success", s.cfg.Success, repository.LogPurgeScope{MaxStatus: 400}, &purge.Success},
+		{"failure", s.cfg.Failure, repository.LogPurgeScope{MinStatus: 400}, &purge.Failure},
+	}
+	for _, class := range classes {
+		scope := class.scope
+		if class.rule.MaxAge > 0 {
+			scope.Before = now.AddDate(0, 0, -class.rule.MaxAge)
+		}
+		if class.rule.MaxRows > 0 {
+			if scope.MaxID, err = s.repo.CutoffID(ctx, scope, class.rule.MaxRows); err != nil {
+				return err
+			}
+		}
+		if scope.Before.IsZero() && scope.MaxID == 0 {
+			continue
+		}
+
+		var archive *logArchive
+		if s.cfg.Mode == LogRetentionArchive {
+			archive = &logArchive{path: filepath.Join(s.cfg.ArchiveDir,
+				fmt.Sprintf("logs-%s-%s.ndjson.gz", class.name, now.Format("20060102-150405")))}
+		}
+		n, err := s.deleteBatches(ctx, scope, archive)
+		*class.count = n
+		if archive != nil && archive.file != nil {
+			if closeErr := archive.Close(); err == nil {
+				err = closeErr
+			}
+			purge.Files = append(purge.Files, archive.path)
+			purge.Archived += archive.rows
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBatches 逐批删除范围内的日志，archive 不为 nil 时每批先写入归档文件并落盘再删除
+func (s *logRetentionService) deleteBatches(ctx context.Context, scope repository.LogPurgeScope, archive *logArchive) (int64, error) {
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		ids, err := s.repo.ExpiredIDs(ctx, scope, s.cfg.BatchSize)
+		if err != nil || len(ids) == 0 {
+			return total, err
+		}
+		if archive != nil {
+			logs, err := s.repo.FindByIDs(ctx, ids)
+			if err != nil {
+				return total, err
+			}
+			if err := archive.Write(logs); err != nil {
+				return total, fmt.Errorf("写入归档文件失败: %w", err)
+			}
+		}
+		n, err := s.repo.HardDelete(ctx, ids)
+		total += n
+		if err != nil || len(ids) < s.cfg.BatchSize {
+			return total, err
+		}
+	}
+}
+
+// Start 校验清理方式并启动定时清理，未启用时只校验
+func (s *logRetentionService) Start() error {
+	switch s.cfg.Mode {
+	case LogRetentionDelete:
+	case LogRetentionArchive:
+		if s.cfg.ArchiveDir == "" {
+			return errors.New("日志归档目录未配置")
+		}
+	default:
+		return fmt.Errorf("不支持的日志清理方式: %s", s.cfg.Mode)
+	}
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(time.Duration(s.cfg.Interval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.Run(s.ctx, LogPurgeSchedule); errors.Is(err, ErrLogPurgeRunning) {
+					logger.FromContext(s.ctx).Info("Skip scheduled log purge, previous purge still running")
+				}
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop 停止定时清理并中断进行中的清理，已删除的批次不会回滚
+func (s *logRetentionService) Stop(ctx context.Context) error {
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("日志清理未退出: %w", ctx.Err())
+	}
+}
+
+// logArchive gzip 压缩的 NDJSON 归档文件，第一次写入时创建
+type logArchive struct {
+	path string
+	file *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+	rows int64
+}
+
+// Write 写入一批日志并落盘，保证删除前归档已持久化
+func (a *logArchive) Write(logs []model.Log) error {
+	if a.file == nil {
+		if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(a.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		a.file = f
+		a.gz = gzip.NewWriter(f)
+		a.enc = json.NewEncoder(a.gz)
+		a.enc.SetEscapeHTML(false)
+	}
+	for i := range logs {
+		if err := a.enc.Encode(&logs[i]); err != nil {
+			return err
+		}
+	}
+	if err := a.gz.Flush(); err != nil {
+		return err
+	}
+	if err := a.file.Sync(); err != nil {
+		return err
+	}
+	a.rows += int64(len(logs))
+	return nil
+}
+
+func (a *logArchive) Close() error {
+	return errors.Join(a.gz.Close(), a.file.Close())
+}
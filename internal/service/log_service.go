@@ -1,21 +1,25 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"go.uber.org/zap"
-	"template-backend/internal/global"
+	"sync"
+	"template-backend/config"
+	"template-backend/internal/logsink"
 	"template-backend/internal/model"
 	"template-backend/internal/repository"
 	"template-backend/pkg/logger"
-	"time"
 )
 
 type LogService interface {
-	CreateLog(log *model.Log) error
-	GetLogByID(id uint) (*model.Log, error)
-	GetLogList(pageNum, pageSize int, conditions map[string]interface{}) ([]model.Log, int64, error)
-	DeleteLog(id uint) error
-	DeleteLogs(ids []uint) error
-	CleanLogs() error
+	CreateLog(ctx context.Context, log *model.Log) error
+	GetLogByID(ctx context.Context, id uint) (*model.Log, error)
+	GetLogList(ctx context.Context, pageNum, pageSize int, conditions map[string]interface{}) ([]model.Log, int64, error)
+	DeleteLog(ctx context.Context, id uint) error
+	DeleteLogs(ctx context.Context, ids []uint) error
+	CleanLogs(ctx context.Context) error
 }
 
 type logService struct {
@@ -23,131 +27,159 @@ type logService struct {
 }
 
 func NewLogService(repo repository.LogRepository) LogService {
-	service := &logService{repo: repo}
-	global.InitLogChan(10000)
-	service.startLogConsumer(100)
-	return service
-}
-func (s *logService) CreateInBatches(logs []*model.Log) error {
-	logger.Logger().Info("Creating log entry in batches", zap.Any("log", logs))
-	err := s.repo.CreateInBatches(logs)
-	if err != nil {
-		logger.Logger().Error("Failed to create log entry", zap.Error(err))
-		return err
-	}
-	logger.Logger().Info("Successfully created log entry")
-	return nil
+	return &logService{repo: repo}
 }
 
-func (s *logService) CreateLog(log *model.Log) error {
-	logger.Logger().Info("Creating log entry", zap.Any("log", log))
-	err := s.repo.Create(log)
+func (s *logService) CreateLog(ctx context.Context, log *model.Log) error {
+	logger.FromContext(ctx).Info("Creating log entry", zap.Any("log", log))
+	err := s.repo.Create(ctx, log)
 	if err != nil {
-		logger.Logger().Error("Failed to create log entry", zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to create log entry", zap.Error(err))
 		return err
 	}
-	logger.Logger().Info("Successfully created log entry", zap.Uint("id", log.ID))
+	logger.FromContext(ctx).Info("Successfully created log entry", zap.Uint("id", log.ID))
 	return nil
 }
 
-func (s *logService) GetLogByID(id uint) (*model.Log, error) {
-	logger.Logger().Info("Fetching log by ID", zap.Uint("id", id))
-	log, err := s.repo.GetByID(id)
+func (s *logService) GetLogByID(ctx context.Context, id uint) (*model.Log, error) {
+	logger.FromContext(ctx).Info("Fetching log by ID", zap.Uint("id", id))
+	log, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		logger.Logger().Error("Failed to fetch log by ID", zap.Uint("id", id), zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to fetch log by ID", zap.Uint("id", id), zap.Error(err))
 		return nil, err
 	}
-	logger.Logger().Info("Successfully fetched log by ID", zap.Uint("id", id))
+	logger.FromContext(ctx).Info("Successfully fetched log by ID", zap.Uint("id", id))
 	return log, nil
 }
 
-func (s *logService) GetLogList(pageNum, pageSize int, conditions map[string]interface{}) ([]model.Log, int64, error) {
-	logger.Logger().Info("Fetching log list",
+func (s *logService) GetLogList(ctx context.Context, pageNum, pageSize int, conditions map[string]interface{}) ([]model.Log, int64, error) {
+	logger.FromContext(ctx).Info("Fetching log list",
 		zap.Int("pageNum", pageNum),
 		zap.Int("pageSize", pageSize),
 		zap.Any("conditions", conditions))
 
-	logs, total, err := s.repo.List(pageNum, pageSize, conditions)
+	logs, total, err := s.repo.List(ctx, pageNum, pageSize, conditions)
 	if err != nil {
-		logger.Logger().Error("Failed to fetch log list", zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to fetch log list", zap.Error(err))
 		return nil, 0, err
 	}
 
-	logger.Logger().Info("Successfully fetched log list",
+	logger.FromContext(ctx).Info("Successfully fetched log list",
 		zap.Int("count", len(logs)),
 		zap.Int64("total", total))
 	return logs, total, nil
 }
 
-func (s *logService) DeleteLog(id uint) error {
-	logger.Logger().Info("Deleting log", zap.Uint("id", id))
-	err := s.repo.Delete(id)
+func (s *logService) DeleteLog(ctx context.Context, id uint) error {
+	logger.FromContext(ctx).Info("Deleting log", zap.Uint("id", id))
+	err := s.repo.Delete(ctx, id)
 	if err != nil {
-		logger.Logger().Error("Failed to delete log", zap.Uint("id", id), zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to delete log", zap.Uint("id", id), zap.Error(err))
 		return err
 	}
-	logger.Logger().Info("Successfully deleted log", zap.Uint("id", id))
+	logger.FromContext(ctx).Info("Successfully deleted log", zap.Uint("id", id))
 	return nil
 }
 
-func (s *logService) DeleteLogs(ids []uint) error {
-	logger.Logger().Info("Batch deleting logs", zap.Any("ids", ids))
-	err := s.repo.DeleteBatch(ids)
+func (s *logService) DeleteLogs(ctx context.Context, ids []uint) error {
+	logger.FromContext(ctx).Info("Batch deleting logs", zap.Any("ids", ids))
+	err := s.repo.DeleteBatch(ctx, ids)
 	if err != nil {
-		logger.Logger().Error("Failed to batch delete logs", zap.Any("ids", ids), zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to batch delete logs", zap.Any("ids", ids), zap.Error(err))
 		return err
 	}
-	logger.Logger().Info("Successfully batch deleted logs", zap.Int("count", len(ids)))
+	logger.FromContext(ctx).Info("Successfully batch deleted logs", zap.Int("count", len(ids)))
 	return nil
 }
 
-func (s *logService) CleanLogs() error {
-	logger.Logger().Info("Cleaning all logs")
-	err := s.repo.Clean()
+func (s *logService) CleanLogs(ctx context.Context) error {
+	logger.FromContext(ctx).Info("Cleaning all logs")
+	err := s.repo.Clean(ctx)
 	if err != nil {
-		logger.Logger().Error("Failed to clean logs", zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to clean logs", zap.Error(err))
 		return err
 	}
-	logger.Logger().Info("Successfully cleaned all logs")
+	logger.FromContext(ctx).Info("Successfully cleaned all logs")
 	return nil
 }
 
-func (s *logService) startLogConsumer(batchSize int) {
-	go func() {
-		logBuffer := make([]*model.Log, 0, batchSize)
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case logEntry, ok := <-global.GetLogChan():
-				if !ok {
-					// 通道已关闭，处理剩余日志并退出
-					if len(logBuffer) > 0 {
-						s.CreateInBatches(logBuffer)
-					}
-					return
-				}
-
-				logBuffer = append(logBuffer, logEntry)
-
-				// 达到批次大小时批量处理
-				if len(logBuffer) >= batchSize {
-					if err := s.CreateInBatches(logBuffer); err != nil {
-						logger.Logger().Error("Failed to batch create logs", zap.Error(err))
-					}
-					logBuffer = make([]*model.Log, 0, batchSize)
-				}
-
-			case <-ticker.C:
-				// 定时刷新缓冲区
-				if len(logBuffer) > 0 {
-					if err := s.CreateInBatches(logBuffer); err != nil {
-						logger.Logger().Error("Failed to batch create logs", zap.Error(err))
-					}
-					logBuffer = make([]*model.Log, 0, 100)
-				}
-			}
+// LogWriter 异步分发请求日志：中间件投递的每条日志复制给所有输出目标，各目标由独立的
+// logsink.Worker 缓冲、批量写入和重试，一个目标积压或故障不影响其他目标
+type LogWriter interface {
+	Sinks() []*logsink.Worker
+	Enqueue(log *model.Log) bool
+	Start() error
+	Stop(ctx context.Context) error
+}
+
+type logWriter struct {
+	sinks []*logsink.Worker
+	err   error // 配置错误，Start 时返回
+}
+
+// NewLogWriter 按配置创建输出目标，未配置时只写入数据库；配置错误在 Start 时返回
+func NewLogWriter(repo repository.LogRepository, sinks []config.LogSinkConfig, log *zap.Logger) LogWriter {
+	if len(sinks) == 0 {
+		sinks = []config.LogSinkConfig{{Type: logsink.TypeDatabase}}
+	}
+	w := &logWriter{}
+	names := make(map[string]bool, len(sinks))
+	for _, cfg := range sinks {
+		sink, err := logsink.New(cfg, repo, log)
+		if err != nil {
+			w.err = errors.Join(w.err, err)
+			continue
+		}
+		if names[sink.Name()] {
+			w.err = errors.Join(w.err, fmt.Errorf("请求日志输出名称重复: %s，同类型配置多个时需指定 name", sink.Name()))
+			continue
 		}
-	}()
+		names[sink.Name()] = true
+		w.sinks = append(w.sinks, sink)
+	}
+	return w
+}
+
+// Sinks 全部输出目标
+func (w *logWriter) Sinks() []*logsink.Worker {
+	return w.sinks
+}
+
+// Enqueue 把日志投递给每个输出目标，各目标拿到独立的副本；任一目标缓冲区已满时丢弃该目标的这条日志并返回 false
+func (w *logWriter) Enqueue(log *model.Log) bool {
+	ok := true
+	for _, sink := range w.sinks {
+		entry := *log
+		if !sink.Enqueue(&entry) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Start 启动全部输出目标，配置有误时不启动并返回错误
+func (w *logWriter) Start() error {
+	if w.err != nil {
+		return w.err
+	}
+	for _, sink := range w.sinks {
+		sink.Start()
+	}
+	return nil
+}
+
+// Stop 并行停止全部输出目标，等待各自写完剩余日志，ctx 结束时返回未写完的目标；
+// Stop 之后的 Enqueue 会被丢弃；只能在 Start 之后调用
+func (w *logWriter) Stop(ctx context.Context) error {
+	errs := make([]error, len(w.sinks))
+	var wg sync.WaitGroup
+	for i, sink := range w.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = sink.Stop(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
 }
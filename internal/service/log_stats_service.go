@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"template-backend/internal/repository"
+	"time"
+)
+
+const (
+	LogStatsMinute = "minute"
+	LogStatsHour   = "hour"
+	LogStatsDay    = "day"
+)
+
+// logStatsMaxBuckets 趋势图最多的时间段数
+const logStatsMaxBuckets = 1440
+
+var logStatsIntervals = map[string]time.Duration{
+	LogStatsMinute: time.Minute,
+	LogStatsHour:   time.Hour,
+	LogStatsDay:    24 * time.Hour,
+}
+
+var (
+	ErrLogStatsRange    = errors.New("统计时间范围无效")
+	ErrLogStatsInterval = errors.New("不支持的统计粒度，可选 minute、hour、day")
+)
+
+// LogTimelinePoint 一个时间段的请求数，Time 为时间段起点（东八区）
+type LogTimelinePoint struct {
+	Time        string  `json:"time"`
+	Total       int64   `json:"total"`
+	Success     int64   `json:"success"`
+	ClientError int64   `json:"clientError"`
+	ServerError int64   `json:"serverError"`
+	ErrorRate   float64 `json:"errorRate"` // (4xx + 5xx) / total
+}
+
+// LogTimeline 请求数趋势，没有请求的时间段补 0
+type LogTimeline struct {
+	Interval string             `json:"interval"`
+	Points   []LogTimelinePoint `json:"points"`
+}
+
+// LogStatusClass 一类状态码的请求数和占比
+type LogStatusClass struct {
+	Class string  `json:"class"` // 2xx、3xx、4xx、5xx
+	Count int64   `json:"count"`
+	Rate  float64 `json:"rate"`
+}
+
+// LogStatusSummary 按状态码分类的请求数
+type LogStatusSummary struct {
+	Total     int64                       `json:"total"`
+	ErrorRate float64                     `json:"errorRate"` // 状态码 >= 400 的占比
+	Classes   []LogStatusClass            `json:"classes"`
+	Statuses  []repository.LogStatusCount `json:"statuses"`
+}
+
+type logStatsEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// LogStatsService 请求日志统计，相同参数的结果缓存 ttl，避免看板刷新时重复聚合
+type LogStatsService interface {
+	DefaultRange() (time.Time, time.Time)
+	Timeline(ctx context.Context, start, end time.Time, interval string) (*LogTimeline, error)
+	Status(ctx context.Context, start, end time.Time) (*LogStatusSummary, error)
+	Latency(ctx context.Context, start, end time.Time, limit int) ([]repository.LogHandlerLatency, error)
+	TopPaths(ctx context.Context, start, end time.Time, limit int) ([]repository.LogPathCount, error)
+	TopIPs(ctx context.Context, start, end time.Time, limit int) ([]repository.LogIPCount, error)
+	Slowest(ctx context.Context, start, end time.Time, limit int) ([]repository.LogSlowRequest, error)
+}
+
+type logStatsService struct {
+	repo repository.LogStatsRepository
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]logStatsEntry
+}
+
+func NewLogStatsService(repo repository.LogStatsRepository, ttl time.Duration) LogStatsService {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &logStatsService{repo: repo, ttl: ttl, cache: make(map[string]logStatsEntry)}
+}
+
+// DefaultRange 未指定范围时统计最近 24 小时，结束时间取整到分钟以便命中缓存
+func (s *logStatsService) DefaultRange() (time.Time, time.Time) {
+	end := time.Now().Truncate(time.Minute).Add(time.Minute)
+	return end.Add(-24 * time.Hour), end
+}
+
+// Timeline 按时间段统计请求数，interval 为空时按范围长度选择粒度
+func (s *logStatsService) Timeline(ctx context.Context, start, end time.Time, interval string) (*LogTimeline, error) {
+	if !end.After(start) {
+		return nil, ErrLogStatsRange
+	}
+	if interval == "" {
+		switch span := end.Sub(start); {
+		case span <= 6*time.Hour:
+			interval = LogStatsMinute
+		case span <= 7*24*time.Hour:
+			interval = LogStatsHour
+		default:
+			interval = LogStatsDay
+		}
+	}
+	size, ok := logStatsIntervals[interval]
+	if !ok {
+		return nil, ErrLogStatsInterval
+	}
+	// 按东八区划分时间段，按天统计时从当地零点开始
+	_, offset := start.In(LogLocation).Zone()
+	seconds := int64(size / time.Second)
+	first := (start.Unix() + int64(offset)) / seconds
+	last := (end.Unix() - 1 + int64(offset)) / seconds
+	if last-first+1 > logStatsMaxBuckets {
+		return nil, fmt.Errorf("%w：按 %s 统计最多 %d 个时间段，请缩小范围或增大粒度", ErrLogStatsRange, interval, logStatsMaxBuckets)
+	}
+
+	key := fmt.Sprintf("timeline:%d:%d:%s", start.Unix(), end.Unix(), interval)
+	return cachedStats(s, key, func() (*LogTimeline, error) {
+		buckets, err := s.repo.Timeline(ctx, start, end, size, int64(offset))
+		if err != nil {
+			return nil, err
+		}
+		byBucket := make(map[int64]repository.LogBucket, len(buckets))
+		for _, b := range buckets {
+			byBucket[b.Bucket] = b
+		}
+
+		timeline := &LogTimeline{Interval: interval, Points: make([]LogTimelinePoint, 0, last-first+1)}
+		for i := first; i <= last; i++ {
+			b := byBucket[i]
+			point := LogTimelinePoint{
+				Time:        time.Unix(i*seconds-int64(offset), 0).In(LogLocation).Format(time.DateTime),
+				Total:       b.Total,
+				Success:     b.Success,
+				ClientError: b.ClientError,
+				ServerError: b.ServerError,
+			}
+			point.ErrorRate = rate(b.ClientError+b.ServerError, b.Total)
+			timeline.Points = append(timeline.Points, point)
+		}
+		return timeline, nil
+	})
+}
+
+// Status 按状态码分类统计请求数和错误率
+func (s *logStatsService) Status(ctx context.Context, start, end time.Time) (*LogStatusSummary, error) {
+	if !end.After(start) {
+		return nil, ErrLogStatsRange
+	}
+	key := fmt.Sprintf("status:%d:%d", start.Unix(), end.Unix())
+	return cachedStats(s, key, func() (*LogStatusSummary, error) {
+		counts, err := s.repo.StatusCounts(ctx, start, end)
+		if err != nil {
+			return nil, err
+		}
+		summary := &LogStatusSummary{Statuses: counts}
+		classes := make(map[string]int64)
+		var failed int64
+		for _, c := range counts {
+			summary.Total += c.Count
+			classes[fmt.Sprintf("%dxx", c.Status/100)] += c.Count
+			if c.Status >= 400 {
+				failed += c.Count
+			}
+		}
+		summary.ErrorRate = rate(failed, summary.Total)
+		// 2xx 到 5xx 始终返回便于前端画图，1xx 只在出现时返回
+		for _, class := range []string{"1xx", "2xx", "3xx", "4xx", "5xx"} {
+			if count, ok := classes[class]; ok || class != "1xx" {
+				summary.Classes = append(summary.Classes, LogStatusClass{Class: class, Count: count, Rate: rate(count, summary.Total)})
+			}
+		}
+		return summary, nil
+	})
+}
+
+// Latency 各处理函数的 p50/p95/p99 耗时，按 p95 从高到低取前 limit 个
+func (s *logStatsService) Latency(ctx context.Context, start, end time.Time, limit int) ([]repository.LogHandlerLatency, error) {
+	if !end.After(start) {
+		return nil, ErrLogStatsRange
+	}
+	key := fmt.Sprintf("latency:%d:%d:%d", start.Unix(), end.Unix(), limit)
+	return cachedStats(s, key, func() ([]repository.LogHandlerLatency, error) {
+		return s.repo.HandlerLatency(ctx, start, end, limit)
+	})
+}
+
+// TopPaths 请求数最多的路径
+func (s *logStatsService) TopPaths(ctx context.Context, start, end time.Time, limit int) ([]repository.LogPathCount, error) {
+	if !end.After(start) {
+		return nil, ErrLogStatsRange
+	}
+	key := fmt.Sprintf("paths:%d:%d:%d", start.Unix(), end.Unix(), limit)
+	return cachedStats(s, key, func() ([]repository.LogPathCount, error) {
+		return s.repo.TopPaths(ctx, start, end, limit)
+	})
+}
+
+// TopIPs 请求数最多的客户端 IP
+func (s *logStatsService) TopIPs(ctx context.Context, start, end time.Time, limit int) ([]repository.LogIPCount, error) {
+	if !end.After(start) {
+		return nil, ErrLogStatsRange
+	}
+	key := fmt.Sprintf("ips:%d:%d:%d", start.Unix(), end.Unix(), limit)
+	return cachedStats(s, key, func() ([]repository.LogIPCount, error) {
+		return s.repo.TopIPs(ctx, start, end, limit)
+	})
+}
+
+// Slowest 耗时最长的请求
+func (s *logStatsService) Slowest(ctx context.Context, start, end time.Time, limit int) ([]repository.LogSlowRequest, error) {
+	if !end.After(start) {
+		return nil, ErrLogStatsRange
+	}
+	key := fmt.Sprintf("slowest:%d:%d:%d", start.Unix(), end.Unix(), limit)
+	return cachedStats(s, key, func() ([]repository.LogSlowRequest, error) {
+		return s.repo.Slowest(ctx, start, end, limit)
+	})
+}
+
+// cachedStats 命中未过期的缓存时直接返回，否则调用 load 并缓存成功的结果
+func cachedStats[T any](s *logStatsService, key string, load func() (T, error)) (T, error) {
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.value.(T), nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 查询参数组合不多，写入时顺带清理过期项即可
+	for k, e := range s.cache {
+		if now.After(e.expires) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = logStatsEntry{value: value, expires: now.Add(s.ttl)}
+	return value, nil
+}
+
+func rate(count, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
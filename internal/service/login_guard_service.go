@@ -0,0 +1,236 @@
+// internal/service/login_guard_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/pkg/logger"
+	"time"
+
+	"github.com/mojocn/base64Captcha"
+	"go.uber.org/zap"
+)
+
+// 登录审计原因
+const (
+	LoginReasonSuccess          = "success"
+	LoginReasonUserNotFound     = "user_not_found"
+	LoginReasonBadPassword      = "bad_password"
+	LoginReasonDisabled         = "user_disabled"
+	LoginReasonLocked           = "locked"
+	LoginReasonCaptchaRequired  = "captcha_required"
+	LoginReasonCaptchaInvalid   = "captcha_invalid"
+	LoginReasonTwoFactorInvalid = "2fa_invalid"
+)
+
+var (
+	// ErrBadCredentials 用户不存在和密码错误统一返回，避免枚举账号
+	ErrBadCredentials  = errors.New("用户名或密码错误")
+	ErrCaptchaRequired = errors.New("请输入验证码")
+	ErrCaptchaInvalid  = errors.New("验证码错误")
+)
+
+// LoginLockedError 登录被临时锁定
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("登录失败次数过多，请 %d 秒后重试", int(e.RetryAfter.Seconds()))
+}
+
+type LoginGuardService interface {
+	CheckLocked(ctx context.Context, username, ip string) error
+	NeedCaptcha(ctx context.Context, username, ip string) bool
+	GenerateCaptcha() (*model.CaptchaResponse, error)
+	VerifyCaptcha(id, code string) bool
+	RecordFailure(ctx context.Context, username, ip string)
+	RecordSuccess(ctx context.Context, username string)
+	Audit(ctx context.Context, username string, userID uint, client ClientInfo, success bool, reason string)
+	ListAudits(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.LoginAudit, int64, error)
+	ListFailures(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.LoginFailure, int64, error)
+	ClearFailure(ctx context.Context, id uint) error
+}
+
+type loginGuardService struct {
+	repo    repository.LoginSecurityRepository
+	captcha *base64Captcha.Captcha
+
+	captchaThreshold  int
+	userLockThreshold int
+	ipLockThreshold   int
+	lockDuration      time.Duration
+	maxLockDuration   time.Duration
+	failureWindow     time.Duration
+}
+
+// NewLoginGuardService 验证码保存在 captchaStore 中，使用内存存储时多实例部署需要会话保持
+func NewLoginGuardService(appConfig *config.AppConfig, repo repository.LoginSecurityRepository,
+	captchaStore base64Captcha.Store) LoginGuardService {
+	cfg := appConfig.LoginSecurity
+	s := &loginGuardService{
+		repo:              repo,
+		captcha:           base64Captcha.NewCaptcha(base64Captcha.NewDriverDigit(60, 200, 4, 0.6, 60), captchaStore),
+		captchaThreshold:  cfg.CaptchaThreshold,
+		userLockThreshold: cfg.UserLockThreshold,
+		ipLockThreshold:   cfg.IPLockThreshold,
+		lockDuration:      time.Duration(cfg.LockDuration) * time.Second,
+		maxLockDuration:   time.Duration(cfg.MaxLockDuration) * time.Second,
+		failureWindow:     time.Duration(cfg.FailureWindow) * time.Second,
+	}
+	if s.lockDuration <= 0 {
+		s.lockDuration = time.Minute
+	}
+	if s.maxLockDuration < s.lockDuration {
+		s.maxLockDuration = s.lockDuration
+	}
+	if s.failureWindow <= 0 {
+		s.failureWindow = 15 * time.Minute
+	}
+	return s
+}
+
+// CheckLocked 检查用户名或 IP 是否处于锁定状态
+func (s *loginGuardService) CheckLocked(ctx context.Context, username, ip string) error {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, f := range s.failures(ctx, username, ip) {
+		if f != nil && f.LockedUntil != nil && f.LockedUntil.After(now) {
+			if d := f.LockedUntil.Sub(now); d > retryAfter {
+				retryAfter = d
+			}
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter.Round(time.Second)}
+	}
+	return nil
+}
+
+// NeedCaptcha 用户名或 IP 近期失败次数达到阈值时需要验证码
+func (s *loginGuardService) NeedCaptcha(ctx context.Context, username, ip string) bool {
+	if s.captchaThreshold <= 0 {
+		return false
+	}
+	for _, f := range s.failures(ctx, username, ip) {
+		if f != nil && s.activeFailures(f, time.Now()) >= s.captchaThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateCaptcha 生成图片验证码
+func (s *loginGuardService) GenerateCaptcha() (*model.CaptchaResponse, error) {
+	id, image, _, err := s.captcha.Generate()
+	if err != nil {
+		return nil, err
+	}
+	return &model.CaptchaResponse{CaptchaID: id, Image: image}, nil
+}
+
+// VerifyCaptcha 校验验证码，无论成功与否验证码都只能使用一次
+func (s *loginGuardService) VerifyCaptcha(id, code string) bool {
+	if id == "" || code == "" {
+		return false
+	}
+	return s.captcha.Verify(id, code, true)
+}
+
+// RecordFailure 累加用户名和 IP 的失败次数，达到阈值时按指数退避锁定
+func (s *loginGuardService) RecordFailure(ctx context.Context, username, ip string) {
+	s.recordFailure(ctx, model.LoginScopeUser, username, s.userLockThreshold)
+	s.recordFailure(ctx, model.LoginScopeIP, ip, s.ipLockThreshold)
+}
+
+// RecordSuccess 登录成功后清除该用户名的失败计数
+func (s *loginGuardService) RecordSuccess(ctx context.Context, username string) {
+	if err := s.repo.DeleteFailure(ctx, model.LoginScopeUser, username); err != nil {
+		logger.FromContext(ctx).Error("clear login failures failed", zap.String("username", username), zap.Error(err))
+	}
+}
+
+// Audit 记录登录审计
+func (s *loginGuardService) Audit(ctx context.Context, username string, userID uint, client ClientInfo, success bool, reason string) {
+	audit := &model.LoginAudit{
+		Username:  username,
+		UserID:    userID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Success:   success,
+		Reason:    reason,
+	}
+	if err := s.repo.CreateAudit(ctx, audit); err != nil {
+		logger.FromContext(ctx).Error("create login audit failed", zap.String("username", username), zap.Error(err))
+	}
+}
+
+func (s *loginGuardService) ListAudits(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.LoginAudit, int64, error) {
+	return s.repo.ListAudits(ctx, page, pageSize, filters)
+}
+
+func (s *loginGuardService) ListFailures(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.LoginFailure, int64, error) {
+	return s.repo.ListFailures(ctx, page, pageSize, filters)
+}
+
+// ClearFailure 管理员解除锁定
+func (s *loginGuardService) ClearFailure(ctx context.Context, id uint) error {
+	return s.repo.DeleteFailureByID(ctx, id)
+}
+
+func (s *loginGuardService) recordFailure(ctx context.Context, scope, subject string, lockThreshold int) {
+	if subject == "" {
+		return
+	}
+	now := time.Now()
+	f, err := s.repo.IncrementFailure(ctx, scope, subject, now, now.Add(-s.failureWindow))
+	if err != nil {
+		logger.FromContext(ctx).Error("record login failure failed", zap.String("scope", scope), zap.Error(err))
+		return
+	}
+	if lockThreshold <= 0 || f.Failures < lockThreshold {
+		return
+	}
+
+	duration := s.lockDuration << f.LockCount
+	if duration <= 0 || duration > s.maxLockDuration {
+		duration = s.maxLockDuration
+	}
+	locked, err := s.repo.LockFailure(ctx, f, now.Add(duration))
+	if err != nil {
+		logger.FromContext(ctx).Error("lock login failed", zap.String("scope", scope), zap.Error(err))
+		return
+	}
+	if locked {
+		logger.FromContext(ctx).Warn("login locked", zap.String("scope", scope), zap.String("subject", subject),
+			zap.Duration("duration", duration))
+	}
+}
+
+// activeFailures 统计窗口内的失败次数；被锁定过的主体在窗口内持续要求验证码
+func (s *loginGuardService) activeFailures(f *model.LoginFailure, now time.Time) int {
+	if now.Sub(f.LastFailedAt) > s.failureWindow {
+		return 0
+	}
+	if f.LockCount > 0 {
+		return f.Failures + s.captchaThreshold
+	}
+	return f.Failures
+}
+
+func (s *loginGuardService) failures(ctx context.Context, username, ip string) []*model.LoginFailure {
+	var result []*model.LoginFailure
+	for _, key := range [][2]string{{model.LoginScopeUser, username}, {model.LoginScopeIP, ip}} {
+		f, err := s.repo.GetFailure(ctx, key[0], key[1])
+		if err != nil {
+			logger.FromContext(ctx).Error("load login failures failed", zap.String("scope", key[0]), zap.Error(err))
+			continue
+		}
+		result = append(result, f)
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+// internal/service/menu_migration_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// MenuMigrationReport 菜单合并结果
+type MenuMigrationReport struct {
+	Created int `json:"created"` // 新建的资源数
+	Merged  int `json:"merged"`  // 合并到已有同权限标识资源的菜单数
+	Skipped int `json:"skipped"` // 之前已迁移过的菜单数
+	Linked  int `json:"linked"`  // 重新关联父节点的资源数
+}
+
+// MenuMigrationService 将旧版 menus 表合并到 resources 表，可重复执行
+type MenuMigrationService struct {
+	menuRepo     repository.MenuRepository
+	resourceRepo repository.ResourceRepository
+	permCache    *PermissionCache
+}
+
+func NewMenuMigrationService(menuRepo repository.MenuRepository, resourceRepo repository.ResourceRepository,
+	permCache *PermissionCache) *MenuMigrationService {
+	return &MenuMigrationService{menuRepo: menuRepo, resourceRepo: resourceRepo, permCache: permCache}
+}
+
+// Migrate 第一遍为每个菜单创建或合并资源，第二遍按菜单的父子关系设置资源的 ParentID
+func (s *MenuMigrationService) Migrate(ctx context.Context) (*MenuMigrationReport, error) {
+	menus, err := s.menuRepo.ListAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("读取菜单失败: %w", err)
+	}
+
+	report := &MenuMigrationReport{}
+	resources := make(map[uint]*model.Resource, len(menus))
+	for _, menu := range menus {
+		menu.UnMarshalMeta()
+		resource, err := s.migrateMenu(ctx, menu, report)
+		if err != nil {
+			return nil, fmt.Errorf("迁移菜单 %d 失败: %w", menu.ID, err)
+		}
+		resources[menu.ID] = resource
+	}
+
+	for _, menu := range menus {
+		resource := resources[menu.ID]
+		var parentID *int64
+		if menu.ParentID != nil {
+			if parent, ok := resources[*menu.ParentID]; ok {
+				parentID = &parent.ID
+			}
+		}
+		if sameParent(resource.ParentID, parentID) {
+			continue
+		}
+		resource.ParentID = parentID
+		if err := s.resourceRepo.Save(ctx, resource); err != nil {
+			return nil, fmt.Errorf("关联菜单 %d 的父节点失败: %w", menu.ID, err)
+		}
+		report.Linked++
+	}
+
+	s.permCache.Invalidate()
+	return report, nil
+}
+
+// migrateMenu 已迁移的菜单直接返回对应资源；权限标识已被非接口资源占用时合并路由信息，否则新建资源
+func (s *MenuMigrationService) migrateMenu(ctx context.Context, menu *model.Menu, report *MenuMigrationReport) (*model.Resource, error) {
+	resource, err := s.resourceRepo.GetByLegacyMenuID(ctx, menu.ID)
+	if err == nil {
+		report.Skipped++
+		return resource, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	resourceType, ok := menuTypeToResourceType(menu.Type)
+	if !ok {
+		return nil, fmt.Errorf("不支持的菜单类型: %d", menu.Type)
+	}
+
+	permission := ""
+	if menu.Permission != nil {
+		permission = strings.TrimSpace(*menu.Permission)
+	}
+	if permission != "" {
+		existing, err := s.resourceRepo.GetByPermissionCode(ctx, permission)
+		if err == nil && existing.Type != model.ResourceTypeAPI && existing.LegacyMenuID == nil {
+			fillMenuFields(existing, menu)
+			if err := s.resourceRepo.Save(ctx, existing); err != nil {
+				return nil, err
+			}
+			report.Merged++
+			return existing, nil
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	code := permission
+	if code == "" || s.resourceRepo.ExistsByPermissionCode(ctx, code, 0) {
+		code = "menu:" + menu.Name
+		if menu.Name == "" || s.resourceRepo.ExistsByPermissionCode(ctx, code, 0) {
+			code = fmt.Sprintf("menu:%d", menu.ID)
+		}
+	}
+
+	resource = &model.Resource{
+		ResourceName:   menu.Name,
+		PermissionCode: code,
+		Type:           resourceType,
+		Status:         1,
+	}
+	if menu.Meta.Title != "" {
+		resource.ResourceName = menu.Meta.Title
+	}
+	// 旧版菜单没有权限标识时对所有登录用户可见
+	if permission == "" {
+		resource.RequiresAuth = 0
+	} else {
+		resource.RequiresAuth = 1
+	}
+	fillMenuFields(resource, menu)
+	if err := insertPublicAware(ctx, s.resourceRepo, resource); err != nil {
+		return nil, err
+	}
+	report.Created++
+	return resource, nil
+}
+
+// fillMenuFields 复制菜单的路由信息，并记录来源菜单
+func fillMenuFields(resource *model.Resource, menu *model.Menu) {
+	path := menu.Path
+	meta := menu.Meta
+	legacyID := menu.ID
+
+	resource.ResourcePath = &path
+	resource.Name = menu.Name
+	resource.Component = menu.Component
+	resource.Redirect = menu.Redirect
+	resource.Visible = menu.Visible
+	resource.Meta = &meta
+	resource.LegacyMenuID = &legacyID
+	if menu.Sort != nil {
+		resource.Sort = *menu.Sort
+	}
+}
+
+func sameParent(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
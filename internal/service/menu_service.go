@@ -2,34 +2,429 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"template-backend/internal/model"
 	"template-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// 菜单类型（兼容旧版菜单接口）
+const (
+	MenuTypeDirectory = 1
+	MenuTypeMenu      = 2
+	MenuTypeButton    = 3
 )
 
-type MenuService struct {
-	repo *repository.MenuRepository
+// menuResourceTypes 组成前端路由树的资源类型
+var menuResourceTypes = []string{model.ResourceTypeDirectory, model.ResourceTypeMenu, model.ResourceTypeButton}
+
+var ErrMenuNotFound = errors.New("菜单不存在")
+
+// MenuService 菜单已合并到资源树，这里以旧版菜单结构读写目录/菜单/按钮类资源
+type MenuService interface {
+	GetMenuTree(ctx context.Context, filter model.Menu) ([]*model.Menu, error)
+	CreateMenu(ctx context.Context, menu *model.Menu) error
+	UpdateMenu(ctx context.Context, menu *model.Menu) error
+	DeleteMenu(ctx context.Context, id uint) error
+	GetByID(ctx context.Context, id uint) (*model.Menu, error)
+	GetUserRoutes(ctx context.Context, userID uint) ([]*model.Menu, error)
+}
+
+type menuService struct {
+	resourceRepo repository.ResourceRepository
+	userRepo     repository.UserRepository
+	roleRepo     repository.RoleRepository
+	superRoles   map[string]bool
+	permCache    *PermissionCache
+
+	// routeCache 按角色集合缓存的用户菜单树，权限缓存版本变化时整体失效
+	routeCache struct {
+		sync.Mutex
+		version int64
+		entries map[string][]*model.Menu
+	}
+}
+
+func NewMenuService(resourceRepo repository.ResourceRepository, userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository, superRoles []string, permCache *PermissionCache) MenuService {
+	roles := make(map[string]bool, len(superRoles))
+	for _, code := range superRoles {
+		roles[code] = true
+	}
+	s := &menuService{resourceRepo: resourceRepo, userRepo: userRepo, roleRepo: roleRepo, superRoles: roles, permCache: permCache}
+	s.routeCache.entries = make(map[string][]*model.Menu)
+	return s
+}
+
+// GetMenuTree 按类型、名称和是否显示筛选后构建菜单树
+func (s *menuService) GetMenuTree(ctx context.Context, filter model.Menu) ([]*model.Menu, error) {
+	resources, err := s.resourceRepo.ListByTypes(ctx, menuResourceTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	var menus []*model.Menu
+	for i := range resources {
+		m := ResourceToMenu(&resources[i])
+		if filter.Type != 0 && m.Type != filter.Type {
+			continue
+		}
+		if filter.Visible != nil && (m.Visible == nil || *m.Visible != *filter.Visible) {
+			continue
+		}
+		if filter.Name != "" && !strings.Contains(m.Name, filter.Name) {
+			continue
+		}
+		menus = append(menus, m)
+	}
+	return buildMenuTree(menus), nil
+}
+
+func (s *menuService) CreateMenu(ctx context.Context, menu *model.Menu) error {
+	resource := &model.Resource{Status: 1}
+	if err := s.applyMenu(ctx, resource, menu); err != nil {
+		return err
+	}
+	if err := insertPublicAware(ctx, s.resourceRepo, resource); err != nil {
+		return err
+	}
+	menu.ID = uint(resource.ID)
+	menu.Permission = &resource.PermissionCode
+	s.permCache.Invalidate()
+	return nil
+}
+
+func (s *menuService) UpdateMenu(ctx context.Context, menu *model.Menu) error {
+	resource, err := s.getMenuResource(ctx, menu.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.applyMenu(ctx, resource, menu); err != nil {
+		return err
+	}
+	if err := s.resourceRepo.Save(ctx, resource); err != nil {
+		return err
+	}
+	s.permCache.Invalidate()
+	return nil
+}
+
+func (s *menuService) DeleteMenu(ctx context.Context, id uint) error {
+	if _, err := s.getMenuResource(ctx, id); err != nil {
+		return err
+	}
+	if err := s.resourceRepo.Delete(ctx, int64(id)); err != nil {
+		return err
+	}
+	s.permCache.Invalidate()
+	return nil
+}
+
+func (s *menuService) GetByID(ctx context.Context, id uint) (*model.Menu, error) {
+	resource, err := s.getMenuResource(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ResourceToMenu(resource), nil
+}
+
+// GetUserRoutes 返回当前用户有权访问的目录、菜单和按钮，
+// 前端可直接据此生成路由；结果按用户的有效角色集合缓存
+func (s *menuService) GetUserRoutes(ctx context.Context, userID uint) ([]*model.Menu, error) {
+	roles, err := s.userRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var active []model.Role
+	for _, role := range roles {
+		if role.Status == 1 {
+			active = append(active, role)
+		}
+	}
+
+	key := roleSetKey(active)
+	version := s.permCache.Version()
+	s.routeCache.Lock()
+	if s.routeCache.version != version {
+		s.routeCache.entries = make(map[string][]*model.Menu)
+		s.routeCache.version = version
+	}
+	cached, ok := s.routeCache.entries[key]
+	s.routeCache.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	routes, err := s.buildRoutes(ctx, active)
+	if err != nil {
+		return nil, err
+	}
+
+	s.routeCache.Lock()
+	if s.routeCache.version == version {
+		s.routeCache.entries[key] = routes
+	}
+	s.routeCache.Unlock()
+	return routes, nil
+}
+
+// buildRoutes 按角色授权的资源、meta.roles 和 meta.permissions 过滤菜单并构建树
+func (s *menuService) buildRoutes(ctx context.Context, roles []model.Role) ([]*model.Menu, error) {
+	resources, err := s.resourceRepo.ListByTypes(ctx, menuResourceTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	super := false
+	roleCodes := make(map[string]bool, len(roles))
+	granted := make(map[int64]bool)
+	permissions := make(map[string]bool)
+	for _, role := range roles {
+		roleCodes[role.RoleCode] = true
+		if s.superRoles[role.RoleCode] {
+			super = true
+			continue
+		}
+		roleResources, err := s.roleRepo.GetPermissions(ctx, role.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range roleResources {
+			if resource.Status == 1 {
+				granted[resource.ID] = true
+				permissions[resource.PermissionCode] = true
+			}
+		}
+	}
+
+	var menus []*model.Menu
+	for i := range resources {
+		resource := &resources[i]
+		if resource.Status != 1 {
+			continue
+		}
+		if !super && resource.RequiresAuth != 0 && !granted[resource.ID] {
+			continue
+		}
+		m := ResourceToMenu(resource)
+		if !super && !metaAllowed(m.Meta, roleCodes, permissions) {
+			continue
+		}
+		menus = append(menus, m)
+	}
+	// 父节点无权访问时整棵子树不可见
+	return pruneMenus(buildMenuTree(menus)), nil
+}
+
+// applyMenu 将旧版菜单字段写入资源
+func (s *menuService) applyMenu(ctx context.Context, resource *model.Resource, menu *model.Menu) error {
+	resourceType, ok := menuTypeToResourceType(menu.Type)
+	if !ok {
+		return fmt.Errorf("不支持的菜单类型: %d", menu.Type)
+	}
+
+	code := ""
+	if menu.Permission != nil {
+		code = strings.TrimSpace(*menu.Permission)
+	}
+	if code == "" {
+		code = resource.PermissionCode
+	}
+	if code == "" {
+		code = "menu:" + menu.Name
+		if menu.Name == "" || s.resourceRepo.ExistsByPermissionCode(ctx, code, resource.ID) {
+			code = "menu:" + strings.Trim(strings.ReplaceAll(menu.Path, "/", ":"), ":")
+		}
+	}
+	if s.resourceRepo.ExistsByPermissionCode(ctx, code, resource.ID) {
+		return errors.New("权限标识码已存在: " + code)
+	}
+
+	var parentID *int64
+	if menu.ParentID != nil && *menu.ParentID != 0 {
+		id := int64(*menu.ParentID)
+		parentID = &id
+	}
+	path := menu.Path
+	meta := menu.Meta
+
+	resource.ResourceName = menu.Name
+	if menu.Meta.Title != "" {
+		resource.ResourceName = menu.Meta.Title
+	}
+	resource.PermissionCode = code
+	resource.Type = resourceType
+	resource.ResourcePath = &path
+	resource.ParentID = parentID
+	resource.Name = menu.Name
+	resource.Component = menu.Component
+	resource.Redirect = menu.Redirect
+	resource.Visible = menu.Visible
+	resource.Meta = &meta
+	resource.Sort = 0
+	if menu.Sort != nil {
+		resource.Sort = *menu.Sort
+	}
+	// 未设置权限标识的菜单所有登录用户可见
+	resource.RequiresAuth = 0
+	if menu.Permission != nil && strings.TrimSpace(*menu.Permission) != "" {
+		resource.RequiresAuth = 1
+	}
+	return nil
+}
+
+func (s *menuService) getMenuResource(ctx context.Context, id uint) (*model.Resource, error) {
+	resource, err := s.resourceRepo.GetByID(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMenuNotFound
+		}
+		return nil, err
+	}
+	if _, ok := resourceTypeToMenuType(resource.Type); !ok {
+		return nil, ErrMenuNotFound
+	}
+	return resource, nil
+}
+
+// ResourceToMenu 将目录/菜单/按钮类资源转换为旧版菜单结构
+func ResourceToMenu(resource *model.Resource) *model.Menu {
+	menuType, _ := resourceTypeToMenuType(resource.Type)
+	code := resource.PermissionCode
+	sortValue := resource.Sort
+	m := &model.Menu{
+		ID:         uint(resource.ID),
+		Name:       resource.Name,
+		Component:  resource.Component,
+		Type:       menuType,
+		Redirect:   resource.Redirect,
+		Permission: &code,
+		Visible:    resource.Visible,
+		Sort:       &sortValue,
+	}
+	if resource.ResourcePath != nil {
+		m.Path = *resource.ResourcePath
+	}
+	if resource.ParentID != nil {
+		parentID := uint(*resource.ParentID)
+		m.ParentID = &parentID
+	}
+	if resource.Meta != nil {
+		m.Meta = *resource.Meta
+	}
+	if m.Meta.Title == "" {
+		m.Meta.Title = resource.ResourceName
+	}
+	return m
 }
 
-func NewMenuService(repo *repository.MenuRepository) *MenuService {
-	return &MenuService{repo: repo}
+func menuTypeToResourceType(menuType int) (string, bool) {
+	switch menuType {
+	case MenuTypeDirectory:
+		return model.ResourceTypeDirectory, true
+	case MenuTypeMenu:
+		return model.ResourceTypeMenu, true
+	case MenuTypeButton:
+		return model.ResourceTypeButton, true
+	}
+	return "", false
+}
+
+func resourceTypeToMenuType(resourceType string) (int, bool) {
+	switch resourceType {
+	case model.ResourceTypeDirectory:
+		return MenuTypeDirectory, true
+	case model.ResourceTypeMenu:
+		return MenuTypeMenu, true
+	case model.ResourceTypeButton:
+		return MenuTypeButton, true
+	}
+	return 0, false
+}
+
+// buildMenuTree 构建菜单树，父节点不在列表中的非根节点会被丢弃
+func buildMenuTree(menus []*model.Menu) []*model.Menu {
+	menuMap := make(map[uint]*model.Menu, len(menus))
+	for _, m := range menus {
+		menuMap[m.ID] = m
+	}
+
+	var roots []*model.Menu
+	for _, m := range menus {
+		if m.ParentID == nil || *m.ParentID == 0 {
+			roots = append(roots, m)
+			continue
+		}
+		if parent, ok := menuMap[*m.ParentID]; ok {
+			parent.Children = append(parent.Children, m)
+		}
+	}
+	return roots
 }
 
-func (s *MenuService) GetMenuTree(menu model.Menu) ([]*model.Menu, error) {
-	return s.repo.GetMenuTree(menu)
+// metaAllowed meta.roles 和 meta.permissions 均满足时可见，未配置的条件不限制
+func metaAllowed(meta model.Meta, roleCodes, permissions map[string]bool) bool {
+	if len(meta.Roles) > 0 && !anyOf(meta.Roles, roleCodes) {
+		return false
+	}
+	if len(meta.Permissions) > 0 && !anyOf(meta.Permissions, permissions) {
+		return false
+	}
+	return true
 }
 
-func (s *MenuService) CreateMenu(menu *model.Menu) error {
-	return s.repo.Create(menu)
+// pruneMenus 按 Sort 排序并去掉没有子节点的目录
+func pruneMenus(menus []*model.Menu) []*model.Menu {
+	result := make([]*model.Menu, 0, len(menus))
+	for _, m := range menus {
+		m.Children = pruneMenus(m.Children)
+		if m.Type == MenuTypeDirectory && len(m.Children) == 0 {
+			continue
+		}
+		if len(m.Children) == 0 {
+			m.Children = nil
+		}
+		result = append(result, m)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return menuSort(result[i]) < menuSort(result[j])
+	})
+	return result
 }
 
-func (s *MenuService) UpdateMenu(menu *model.Menu) error {
-	return s.repo.Update(menu)
+func menuSort(m *model.Menu) int {
+	if m.Sort == nil {
+		return 0
+	}
+	return *m.Sort
 }
 
-func (s *MenuService) DeleteMenu(id uint) error {
-	return s.repo.Delete(id)
+func anyOf(values []string, set map[string]bool) bool {
+	for _, v := range values {
+		if set[v] {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *MenuService) GetByID(id uint) (*model.Menu, error) {
-	return s.repo.GetByID(id)
+// roleSetKey 角色集合的缓存键
+func roleSetKey(roles []model.Role) string {
+	ids := make([]int, 0, len(roles))
+	for _, role := range roles {
+		ids = append(ids, int(role.ID))
+	}
+	sort.Ints(ids)
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
 }
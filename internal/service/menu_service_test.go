@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/internal/testdb"
+	"testing"
+)
+
+func TestCreateMenuPersistsRequiresAuth(t *testing.T) {
+	db := testdb.Open(t)
+	svc := NewMenuService(repository.NewResourceRepository(db), repository.NewUserRepository(db), repository.NewRoleRepository(db), nil, NewPermissionCache())
+
+	permission := "system:log"
+	tests := []struct {
+		name         string
+		menu         model.Menu
+		requiresAuth int8
+	}{
+		{"未设置权限标识", model.Menu{Name: "Home", Path: "/home", Type: 2}, 0},
+		{"设置权限标识", model.Menu{Name: "Log", Path: "/log", Type: 2, Permission: &permission}, 1},
+	}
+	for _, tt := range tests {
+		menu := tt.menu
+		if err := svc.CreateMenu(context.Background(), &menu); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		// 直接从数据库读取，确认写入的是 0 而不是列默认值
+		var resource model.Resource
+		if err := db.First(&resource, menu.ID).Error; err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resource.RequiresAuth != tt.requiresAuth {
+			t.Errorf("%s: requires_auth = %d, want %d", tt.name, resource.RequiresAuth, tt.requiresAuth)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// internal/service/password_service.go
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/pkg/logger"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultPasswordMinLength = 6
+	tempPasswordLength       = 12
+)
+
+var ErrPasswordReused = errors.New("不能使用最近使用过的密码")
+
+// PasswordPolicyError 密码不符合策略，Violations 为全部不满足的规则
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "密码不符合要求：" + strings.Join(e.Violations, "；")
+}
+
+type PasswordService interface {
+	Validate(username, password string) error
+	Change(ctx context.Context, user *model.User, password string) error
+	Reset(ctx context.Context, user *model.User) (string, error)
+	Remember(ctx context.Context, user *model.User)
+	MustChange(user *model.User) bool
+	Expired(user *model.User) bool
+	DeleteHistory(ctx context.Context, userID uint) error
+}
+
+type passwordService struct {
+	userRepo    repository.UserRepository
+	historyRepo repository.PasswordHistoryRepository
+
+	minLength     int
+	requireUpper  bool
+	requireLower  bool
+	requireDigit  bool
+	requireSymbol bool
+	banned        map[string]bool
+	historySize   int
+	maxAge        time.Duration
+}
+
+func NewPasswordService(appConfig *config.AppConfig, userRepo repository.UserRepository,
+	historyRepo repository.PasswordHistoryRepository) PasswordService {
+	cfg := appConfig.PasswordPolicy
+	s := &passwordService{
+		userRepo:      userRepo,
+		historyRepo:   historyRepo,
+		minLength:     cfg.MinLength,
+		requireUpper:  cfg.RequireUpper,
+		requireLower:  cfg.RequireLower,
+		requireDigit:  cfg.RequireDigit,
+		requireSymbol: cfg.RequireSymbol,
+		banned:        make(map[string]bool, len(cfg.Banned)),
+		historySize:   cfg.HistorySize,
+		maxAge:        time.Duration(cfg.MaxAge) * 24 * time.Hour,
+	}
+	if s.minLength <= 0 {
+		s.minLength = defaultPasswordMinLength
+	}
+	for _, p := range cfg.Banned {
+		s.banned[strings.ToLower(p)] = true
+	}
+	return s
+}
+
+// Validate 按密码策略校验密码
+func (s *passwordService) Validate(username, password string) error {
+	var violations []string
+	if len([]rune(password)) < s.minLength {
+		violations = append(violations, fmt.Sprintf("长度不能少于 %d 位", s.minLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if s.requireUpper && !hasUpper {
+		violations = append(violations, "必须包含大写字母")
+	}
+	if s.requireLower && !hasLower {
+		violations = append(violations, "必须包含小写字母")
+	}
+	if s.requireDigit && !hasDigit {
+		violations = append(violations, "必须包含数字")
+	}
+	if s.requireSymbol && !hasSymbol {
+		violations = append(violations, "必须包含特殊字符")
+	}
+
+	lower := strings.ToLower(password)
+	if s.banned[lower] {
+		violations = append(violations, "密码过于简单")
+	}
+	if len(username) >= 3 && strings.Contains(lower, strings.ToLower(username)) {
+		violations = append(violations, "不能包含用户名")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Change 用户修改密码：校验策略和历史密码后保存
+func (s *passwordService) Change(ctx context.Context, user *model.User, password string) error {
+	if err := s.Validate(user.Username, password); err != nil {
+		return err
+	}
+	if s.reused(ctx, user, password) {
+		return ErrPasswordReused
+	}
+	return s.apply(ctx, user, password, false)
+}
+
+// Reset 管理员重置密码：生成一次性临时密码，用户下次登录后必须修改
+func (s *passwordService) Reset(ctx context.Context, user *model.User) (string, error) {
+	password, err := generateTempPassword()
+	if err != nil {
+		return "", err
+	}
+	if err := s.apply(ctx, user, password, true); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+// Remember 记录新用户的初始密码，user.Password 为加密后的密码
+func (s *passwordService) Remember(ctx context.Context, user *model.User) {
+	s.addHistory(ctx, user.ID, user.Password)
+}
+
+// MustChange 用户是否需要修改密码：管理员重置过或密码已过期
+func (s *passwordService) MustChange(user *model.User) bool {
+	return user.MustChangePassword || s.Expired(user)
+}
+
+// Expired 密码是否超过最长有效期，从未修改过密码的用户按创建时间计算
+func (s *passwordService) Expired(user *model.User) bool {
+	if s.maxAge <= 0 {
+		return false
+	}
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return time.Since(changedAt) > s.maxAge
+}
+
+// DeleteHistory 删除用户的历史密码
+func (s *passwordService) DeleteHistory(ctx context.Context, userID uint) error {
+	return s.historyRepo.DeleteByUser(ctx, userID)
+}
+
+func (s *passwordService) apply(ctx context.Context, user *model.User, password string, mustChange bool) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	user.Password = string(hashed)
+	user.PasswordChangedAt = &now
+	user.MustChangePassword = mustChange
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	s.addHistory(ctx, user.ID, user.Password)
+	return nil
+}
+
+// reused 新密码是否与当前密码或最近 historySize 次的密码相同
+func (s *passwordService) reused(ctx context.Context, user *model.User, password string) bool {
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil {
+		return true
+	}
+	if s.historySize <= 0 {
+		return false
+	}
+	history, err := s.historyRepo.ListRecent(ctx, user.ID, s.historySize)
+	if err != nil {
+		logger.FromContext(ctx).Error("load password history failed", zap.Uint("userId", user.ID), zap.Error(err))
+		return false
+	}
+	for _, h := range history {
+		if bcrypt.CompareHashAndPassword([]byte(h.Password), []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *passwordService) addHistory(ctx context.Context, userID uint, hashed string) {
+	if s.historySize <= 0 {
+		return
+	}
+	if err := s.historyRepo.Create(ctx, &model.PasswordHistory{UserID: userID, Password: hashed}); err != nil {
+		logger.FromContext(ctx).Error("save password history failed", zap.Uint("userId", userID), zap.Error(err))
+		return
+	}
+	if err := s.historyRepo.Prune(ctx, userID, s.historySize); err != nil {
+		logger.FromContext(ctx).Error("prune password history failed", zap.Uint("userId", userID), zap.Error(err))
+	}
+}
+
+// generateTempPassword 生成包含大小写字母、数字和特殊字符的临时密码
+func generateTempPassword() (string, error) {
+	const (
+		upper  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+		lower  = "abcdefghijkmnpqrstuvwxyz"
+		digit  = "23456789"
+		symbol = "!@#$%^&*-_"
+	)
+	classes := []string{upper, lower, digit, symbol}
+	all := upper + lower + digit + symbol
+
+	buf := make([]byte, tempPasswordLength)
+	for i := range buf {
+		charset := all
+		if i < len(classes) {
+			// 前几位保证每类字符至少出现一次
+			charset = classes[i]
+		}
+		c, err := randomChar(charset)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = c
+	}
+	// 打乱顺序
+	for i := len(buf) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		buf[i], buf[j.Int64()] = buf[j.Int64()], buf[i]
+	}
+	return string(buf), nil
+}
+
+func randomChar(charset string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+	if err != nil {
+		return 0, err
+	}
+	return charset[n.Int64()], nil
+}
@@ -0,0 +1,236 @@
+// internal/service/permission_service.go
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/pkg/datascope"
+	"template-backend/pkg/tracing"
+	"time"
+)
+
+// ResourceTypeAPI 接口类型的资源
+const ResourceTypeAPI = model.ResourceTypeAPI
+
+// PermissionCache 权限缓存版本号，角色、资源或用户角色变更时递增。
+// 由容器创建后注入修改授权数据的服务和 PermissionService、MenuService，共享同一实例的服务之间传递失效信号
+type PermissionCache struct {
+	version atomic.Int64
+}
+
+func NewPermissionCache() *PermissionCache {
+	return &PermissionCache{}
+}
+
+// Invalidate 使共享该实例的权限缓存和菜单缓存失效
+func (c *PermissionCache) Invalidate() {
+	c.version.Add(1)
+}
+
+// Version 当前版本号
+func (c *PermissionCache) Version() int64 {
+	return c.version.Load()
+}
+
+type PermissionService interface {
+	MatchAPI(ctx context.Context, method, path string) (*model.Resource, error)
+	HasPermission(ctx context.Context, userID uint, resourceID int64) (bool, error)
+	DataScope(ctx context.Context, userID uint) (*datascope.Scope, error)
+}
+
+type permissionService struct {
+	resourceRepo repository.ResourceRepository
+	roleRepo     repository.RoleRepository
+	userRepo     repository.UserRepository
+	superRoles   map[string]bool
+	ttl          time.Duration
+	permCache    *PermissionCache
+
+	mu            sync.RWMutex
+	version       int64
+	loadedAt      time.Time
+	apiResources  map[string]*model.Resource // key: METHOD + " " + path
+	roleResources map[uint]map[int64]bool
+	userRoles     map[uint][]model.Role
+}
+
+func NewPermissionService(resourceRepo repository.ResourceRepository, roleRepo repository.RoleRepository,
+	userRepo repository.UserRepository, superRoles []string, ttl time.Duration, permCache *PermissionCache) PermissionService {
+	roles := make(map[string]bool, len(superRoles))
+	for _, code := range superRoles {
+		roles[code] = true
+	}
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &permissionService{
+		resourceRepo: resourceRepo,
+		roleRepo:     roleRepo,
+		userRepo:     userRepo,
+		superRoles:   roles,
+		ttl:          ttl,
+		permCache:    permCache,
+	}
+}
+
+// MatchAPI 根据 HTTP 方法和路由模板查找对应的 API 资源，未登记时返回 nil
+func (s *permissionService) MatchAPI(ctx context.Context, method, path string) (*model.Resource, error) {
+	ctx, span := tracing.Start(ctx, "PermissionService.MatchAPI")
+	defer span.End()
+	if err := s.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if resource, ok := s.apiResources[apiKey(method, path)]; ok {
+		return resource, nil
+	}
+	// 兼容登记时未带 /api 前缀的资源路径
+	if trimmed := strings.TrimPrefix(path, "/api"); trimmed != path {
+		if resource, ok := s.apiResources[apiKey(method, trimmed)]; ok {
+			return resource, nil
+		}
+	}
+	return nil, nil
+}
+
+// HasPermission 判断用户的有效角色是否授予了指定资源
+func (s *permissionService) HasPermission(ctx context.Context, userID uint, resourceID int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "PermissionService.HasPermission")
+	defer span.End()
+	roles, err := s.getUserRoles(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, role := range roles {
+		if role.Status != 1 {
+			continue
+		}
+		if s.superRoles[role.RoleCode] {
+			return true, nil
+		}
+		if s.roleResources[role.ID][resourceID] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DataScope 根据用户的有效角色计算数据范围，多个角色取并集
+func (s *permissionService) DataScope(ctx context.Context, userID uint) (*datascope.Scope, error) {
+	ctx, span := tracing.Start(ctx, "PermissionService.DataScope")
+	defer span.End()
+	roles, err := s.getUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := &datascope.Scope{UserID: userID}
+	for _, role := range roles {
+		if role.Status != 1 {
+			continue
+		}
+		if s.superRoles[role.RoleCode] {
+			scope.All = true
+			continue
+		}
+		switch role.DataScope {
+		case datascope.Custom:
+			for _, rule := range role.DataScopeRules {
+				scope.AddRule(rule)
+			}
+		case datascope.Own:
+			scope.Own = true
+		default:
+			scope.All = true
+		}
+	}
+	return scope, nil
+}
+
+// getUserRoles 获取用户角色，结果按用户缓存
+func (s *permissionService) getUserRoles(ctx context.Context, userID uint) ([]model.Role, error) {
+	if err := s.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	roles, ok := s.userRoles[userID]
+	s.mu.RUnlock()
+	if ok {
+		return roles, nil
+	}
+
+	roles, err := s.userRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.userRoles[userID] = roles
+	s.mu.Unlock()
+	return roles, nil
+}
+
+// ensureLoaded 缓存过期或版本变化时重新加载接口资源与角色授权
+func (s *permissionService) ensureLoaded(ctx context.Context) error {
+	version := s.permCache.Version()
+
+	s.mu.RLock()
+	fresh := s.apiResources != nil && s.version == version && time.Since(s.loadedAt) < s.ttl
+	s.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	resources, err := s.resourceRepo.ListByType(ctx, ResourceTypeAPI)
+	if err != nil {
+		return err
+	}
+	roleResources, err := s.roleRepo.GetAllRoleResources(ctx)
+	if err != nil {
+		return err
+	}
+
+	apis := make(map[string]*model.Resource, len(resources))
+	for i := range resources {
+		resource := &resources[i]
+		if resource.ResourcePath == nil || resource.HTTPMethod == nil {
+			continue
+		}
+		apis[apiKey(*resource.HTTPMethod, *resource.ResourcePath)] = resource
+	}
+
+	grants := make(map[uint]map[int64]bool)
+	for _, rr := range roleResources {
+		if grants[rr.RoleID] == nil {
+			grants[rr.RoleID] = make(map[int64]bool)
+		}
+		grants[rr.RoleID][int64(rr.ResourceID)] = true
+	}
+
+	s.mu.Lock()
+	s.apiResources = apis
+	s.roleResources = grants
+	s.userRoles = make(map[uint][]model.Role)
+	s.version = version
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func apiKey(method, path string) string {
+	path = strings.TrimSpace(path)
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return strings.ToUpper(strings.TrimSpace(method)) + " " + path
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
@@ -10,27 +12,29 @@ import (
 )
 
 type ResourceService interface {
-	CreateResource(req *dto.CreateResourceRequest) (*dto.ResourceResponse, error)
-	GetResourceByID(id int64) (*dto.ResourceResponse, error)
-	UpdateResource(id int64, req *dto.UpdateResourceRequest) (*dto.ResourceResponse, error)
-	DeleteResource(id int64) error
-	ListResources(req *dto.ResourceQueryRequest) (*dto.PagedResponse, error)
-	ResourcesTree(d *dto.ResourceQueryRequest) ([]dto.ResourceResponse, error)
+	CreateResource(ctx context.Context, req *dto.CreateResourceRequest) (*dto.ResourceResponse, error)
+	GetResourceByID(ctx context.Context, id int64) (*dto.ResourceResponse, error)
+	UpdateResource(ctx context.Context, id int64, req *dto.UpdateResourceRequest) (*dto.ResourceResponse, error)
+	DeleteResource(ctx context.Context, id int64) error
+	ListResources(ctx context.Context, req *dto.ResourceQueryRequest) (*dto.PagedResponse, error)
+	ResourcesTree(ctx context.Context, d *dto.ResourceQueryRequest) ([]dto.ResourceResponse, error)
 }
 
 type resourceService struct {
 	resourceRepo repository.ResourceRepository
+	permCache    *PermissionCache
 }
 
-func NewResourceService(resourceRepo repository.ResourceRepository) ResourceService {
+func NewResourceService(resourceRepo repository.ResourceRepository, permCache *PermissionCache) ResourceService {
 	return &resourceService{
 		resourceRepo: resourceRepo,
+		permCache:    permCache,
 	}
 }
 
-func (s *resourceService) CreateResource(req *dto.CreateResourceRequest) (*dto.ResourceResponse, error) {
+func (s *resourceService) CreateResource(ctx context.Context, req *dto.CreateResourceRequest) (*dto.ResourceResponse, error) {
 	// 检查权限标识码是否已存在
-	if s.resourceRepo.ExistsByPermissionCode(req.PermissionCode, 0) {
+	if s.resourceRepo.ExistsByPermissionCode(ctx, req.PermissionCode, 0) {
 		return nil, errors.New("权限标识码已存在")
 	}
 
@@ -46,19 +50,25 @@ func (s *resourceService) CreateResource(req *dto.CreateResourceRequest) (*dto.R
 		Sort:           req.Sort,
 		RequiresAuth:   req.RequiresAuth,
 		Remark:         req.Remark,
+		Name:           req.Name,
+		Component:      req.Component,
+		Redirect:       req.Redirect,
+		Visible:        req.Visible,
+		Meta:           req.Meta,
 		CreatedBy:      req.CreatedBy,
 		Status:         1, // 默认启用
 	}
 
-	if err := s.resourceRepo.Create(resource); err != nil {
+	if err := s.resourceRepo.Create(ctx, resource); err != nil {
 		return nil, fmt.Errorf("创建资源失败: %w", err)
 	}
+	s.permCache.Invalidate()
 
 	return s.modelToResponse(resource), nil
 }
 
-func (s *resourceService) GetResourceByID(id int64) (*dto.ResourceResponse, error) {
-	resource, err := s.resourceRepo.GetByID(id)
+func (s *resourceService) GetResourceByID(ctx context.Context, id int64) (*dto.ResourceResponse, error) {
+	resource, err := s.resourceRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("资源不存在")
@@ -69,9 +79,9 @@ func (s *resourceService) GetResourceByID(id int64) (*dto.ResourceResponse, erro
 	return s.modelToResponse(resource), nil
 }
 
-func (s *resourceService) UpdateResource(id int64, req *dto.UpdateResourceRequest) (*dto.ResourceResponse, error) {
+func (s *resourceService) UpdateResource(ctx context.Context, id int64, req *dto.UpdateResourceRequest) (*dto.ResourceResponse, error) {
 	// 检查资源是否存在
-	existingResource, err := s.resourceRepo.GetByID(id)
+	existingResource, err := s.resourceRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("资源不存在")
@@ -81,7 +91,7 @@ func (s *resourceService) UpdateResource(id int64, req *dto.UpdateResourceReques
 
 	// 如果要更新权限标识码，检查是否重复
 	if req.PermissionCode != nil && *req.PermissionCode != existingResource.PermissionCode {
-		if s.resourceRepo.ExistsByPermissionCode(*req.PermissionCode, id) {
+		if s.resourceRepo.ExistsByPermissionCode(ctx, *req.PermissionCode, id) {
 			return nil, errors.New("权限标识码已存在")
 		}
 	}
@@ -121,22 +131,43 @@ func (s *resourceService) UpdateResource(id int64, req *dto.UpdateResourceReques
 	if req.Remark != nil {
 		updates["remark"] = *req.Remark
 	}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Component != nil {
+		updates["component"] = *req.Component
+	}
+	if req.Redirect != nil {
+		updates["redirect"] = *req.Redirect
+	}
+	if req.Visible != nil {
+		updates["visible"] = *req.Visible
+	}
+	if req.Meta != nil {
+		// map 更新不会经过字段的 serializer，需要手动序列化
+		meta, err := json.Marshal(req.Meta)
+		if err != nil {
+			return nil, fmt.Errorf("更新资源失败: %w", err)
+		}
+		updates["meta"] = string(meta)
+	}
 	if req.UpdatedBy != nil {
 		updates["updated_by"] = *req.UpdatedBy
 	}
 
 	// 执行更新
-	if err := s.resourceRepo.Update(id, updates); err != nil {
+	if err := s.resourceRepo.Update(ctx, id, updates); err != nil {
 		return nil, fmt.Errorf("更新资源失败: %w", err)
 	}
+	s.permCache.Invalidate()
 
 	// 返回更新后的资源
-	return s.GetResourceByID(id)
+	return s.GetResourceByID(ctx, id)
 }
 
-func (s *resourceService) DeleteResource(id int64) error {
+func (s *resourceService) DeleteResource(ctx context.Context, id int64) error {
 	// 检查资源是否存在
-	_, err := s.resourceRepo.GetByID(id)
+	_, err := s.resourceRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("资源不存在")
@@ -145,14 +176,15 @@ func (s *resourceService) DeleteResource(id int64) error {
 	}
 
 	// 执行删除
-	if err := s.resourceRepo.Delete(id); err != nil {
+	if err := s.resourceRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("删除资源失败: %w", err)
 	}
+	s.permCache.Invalidate()
 
 	return nil
 }
 
-func (s *resourceService) ListResources(req *dto.ResourceQueryRequest) (*dto.PagedResponse, error) {
+func (s *resourceService) ListResources(ctx context.Context, req *dto.ResourceQueryRequest) (*dto.PagedResponse, error) {
 	// 设置默认分页参数
 	if req.Page <= 0 {
 		req.Page = 1
@@ -175,7 +207,7 @@ func (s *resourceService) ListResources(req *dto.ResourceQueryRequest) (*dto.Pag
 		PageSize:       req.PageSize,
 	}
 
-	resources, total, err := s.resourceRepo.List(query)
+	resources, total, err := s.resourceRepo.List(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("查询资源失败: %w", err)
 	}
@@ -201,7 +233,7 @@ func (s *resourceService) ListResources(req *dto.ResourceQueryRequest) (*dto.Pag
 	}, nil
 }
 
-func (s *resourceService) ResourcesTree(req *dto.ResourceQueryRequest) ([]dto.ResourceResponse, error) {
+func (s *resourceService) ResourcesTree(ctx context.Context, req *dto.ResourceQueryRequest) ([]dto.ResourceResponse, error) {
 	query := &repository.ResourceQuery{
 		ID:             req.ID,
 		ResourceName:   req.ResourceName,
@@ -216,7 +248,7 @@ func (s *resourceService) ResourcesTree(req *dto.ResourceQueryRequest) ([]dto.Re
 		PageSize:       req.PageSize,
 	}
 
-	resources, err := s.resourceRepo.QueryAll(query)
+	resources, err := s.resourceRepo.QueryAll(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("查询资源失败: %w", err)
 	}
@@ -231,22 +263,41 @@ func (s *resourceService) ResourcesTree(req *dto.ResourceQueryRequest) ([]dto.Re
 		return data, nil
 	}
 
-	var roots []dto.ResourceResponse
-	menuMap := make(map[int64]*dto.ResourceResponse)
-	for _, m := range roots {
-		menuMap[m.ID] = &m
-	}
-	for _, m := range data {
-		if m.ParentID != nil {
-			if parent, ok := menuMap[*m.ParentID]; ok {
-				parent.Children = append(parent.Children, m)
+	return buildResourceTree(data), nil
+}
+
+// buildResourceTree 按 ParentID 组装资源树，父节点不在结果中的资源作为根节点
+func buildResourceTree(data []dto.ResourceResponse) []dto.ResourceResponse {
+	index := make(map[int64]int, len(data))
+	for i := range data {
+		index[data[i].ID] = i
+	}
+	children := make(map[int64][]int)
+	var roots []int
+	for i := range data {
+		if data[i].ParentID != nil {
+			if _, ok := index[*data[i].ParentID]; ok {
+				children[*data[i].ParentID] = append(children[*data[i].ParentID], i)
+				continue
 			}
-		} else {
-			roots = append(roots, m)
 		}
+		roots = append(roots, i)
 	}
 
-	return roots, nil
+	var build func(i int) dto.ResourceResponse
+	build = func(i int) dto.ResourceResponse {
+		node := data[i]
+		for _, c := range children[node.ID] {
+			node.Children = append(node.Children, build(c))
+		}
+		node.HasChildren = len(node.Children) > 0
+		return node
+	}
+	result := make([]dto.ResourceResponse, 0, len(roots))
+	for _, i := range roots {
+		result = append(result, build(i))
+	}
+	return result
 }
 
 func (s *resourceService) modelToResponse(resource *model.Resource) *dto.ResourceResponse {
@@ -263,6 +314,11 @@ func (s *resourceService) modelToResponse(resource *model.Resource) *dto.Resourc
 		Status:         resource.Status,
 		RequiresAuth:   resource.RequiresAuth,
 		Remark:         resource.Remark,
+		Name:           resource.Name,
+		Component:      resource.Component,
+		Redirect:       resource.Redirect,
+		Visible:        resource.Visible,
+		Meta:           resource.Meta,
 		CreatedBy:      resource.CreatedBy,
 		CreatedAt:      resource.CreatedAt,
 		UpdatedBy:      resource.UpdatedBy,
@@ -270,3 +326,20 @@ func (s *resourceService) modelToResponse(resource *model.Resource) *dto.Resourc
 		HasChildren:    true,
 	}
 }
+
+// insertPublicAware 创建资源并保留 RequiresAuth 为 0 的取值。
+// requires_auth 列带 default:1，GORM 创建时会把零值替换为默认值，因此创建后再显式写入 0
+func insertPublicAware(ctx context.Context, repo repository.ResourceRepository, resource *model.Resource) error {
+	public := resource.RequiresAuth == 0
+	if err := repo.Create(ctx, resource); err != nil {
+		return err
+	}
+	if !public {
+		return nil
+	}
+	if err := repo.Update(ctx, resource.ID, map[string]interface{}{"requires_auth": 0}); err != nil {
+		return err
+	}
+	resource.RequiresAuth = 0
+	return nil
+}
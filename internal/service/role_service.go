@@ -2,46 +2,118 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"template-backend/internal/model"
 	"template-backend/internal/repository"
+	"template-backend/pkg/datascope"
 )
 
-type RoleService struct {
-	roleRepo *repository.RoleRepository
+type RoleService interface {
+	GetList(ctx context.Context, page, size int, filters map[string]interface{}) ([]model.Role, int64, error)
+	Create(ctx context.Context, role *model.Role) error
+	Update(ctx context.Context, role *model.Role) error
+	Delete(ctx context.Context, id uint) error
+	BatchDelete(ctx context.Context, ids []uint) error
+	GetByID(ctx context.Context, id uint) (*model.Role, error)
+	GetPermissions(ctx context.Context, roleID uint) ([]model.Resource, error)
+	UpdatePermissions(ctx context.Context, roleID uint, permissionIds []uint) error
+	UpdateDataScope(ctx context.Context, roleID uint, scope string, rules []datascope.Rule) error
 }
 
-func NewRoleService(roleRepo *repository.RoleRepository) *RoleService {
-	return &RoleService{roleRepo: roleRepo}
+type roleService struct {
+	roleRepo  repository.RoleRepository
+	permCache *PermissionCache
 }
 
-func (s *RoleService) GetList(page, size int, filters map[string]interface{}) ([]model.Role, int64, error) {
-	return s.roleRepo.GetList(page, size, filters)
+func NewRoleService(roleRepo repository.RoleRepository, permCache *PermissionCache) RoleService {
+	return &roleService{roleRepo: roleRepo, permCache: permCache}
 }
 
-func (s *RoleService) Create(role *model.Role) error {
-	return s.roleRepo.Create(role)
+func (s *roleService) GetList(ctx context.Context, page, size int, filters map[string]interface{}) ([]model.Role, int64, error) {
+	return s.roleRepo.GetList(ctx, page, size, filters)
 }
 
-func (s *RoleService) Update(role *model.Role) error {
-	return s.roleRepo.Update(role)
+func (s *roleService) Create(ctx context.Context, role *model.Role) error {
+	if err := validateDataScope(role.DataScope, role.DataScopeRules); err != nil {
+		return err
+	}
+	return s.roleRepo.Create(ctx, role)
 }
 
-func (s *RoleService) Delete(id uint) error {
-	return s.roleRepo.Delete(id)
+func (s *roleService) Update(ctx context.Context, role *model.Role) error {
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return err
+	}
+	s.permCache.Invalidate()
+	return nil
 }
 
-func (s *RoleService) BatchDelete(ids []uint) error {
-	return s.roleRepo.BatchDelete(ids)
+func (s *roleService) Delete(ctx context.Context, id uint) error {
+	if err := s.roleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.permCache.Invalidate()
+	return nil
 }
 
-func (s *RoleService) GetByID(id uint) (*model.Role, error) {
-	return s.roleRepo.GetByID(id)
+func (s *roleService) BatchDelete(ctx context.Context, ids []uint) error {
+	if err := s.roleRepo.BatchDelete(ctx, ids); err != nil {
+		return err
+	}
+	s.permCache.Invalidate()
+	return nil
 }
 
-func (s *RoleService) GetPermissions(roleID uint) ([]model.Resource, error) {
-	return s.roleRepo.GetPermissions(roleID)
+func (s *roleService) GetByID(ctx context.Context, id uint) (*model.Role, error) {
+	return s.roleRepo.GetByID(ctx, id)
 }
 
-func (s *RoleService) UpdatePermissions(roleID uint, permissionIds []uint) error {
-	return s.roleRepo.UpdatePermissions(roleID, permissionIds)
+func (s *roleService) GetPermissions(ctx context.Context, roleID uint) ([]model.Resource, error) {
+	return s.roleRepo.GetPermissions(ctx, roleID)
+}
+
+func (s *roleService) UpdatePermissions(ctx context.Context, roleID uint, permissionIds []uint) error {
+	if err := s.roleRepo.UpdatePermissions(ctx, roleID, permissionIds); err != nil {
+		return err
+	}
+	s.permCache.Invalidate()
+	return nil
+}
+
+// UpdateDataScope 更新角色的数据范围
+func (s *roleService) UpdateDataScope(ctx context.Context, roleID uint, scope string, rules []datascope.Rule) error {
+	if err := validateDataScope(scope, rules); err != nil {
+		return err
+	}
+	role, err := s.roleRepo.GetByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	role.DataScope = scope
+	role.DataScopeRules = rules
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return err
+	}
+	s.permCache.Invalidate()
+	return nil
+}
+
+func validateDataScope(scope string, rules []datascope.Rule) error {
+	switch scope {
+	case "", datascope.All, datascope.Own:
+		return nil
+	case datascope.Custom:
+		if len(rules) == 0 {
+			return errors.New("自定义数据范围至少需要一条规则")
+		}
+		for _, rule := range rules {
+			if rule.Table == "" || rule.Column == "" {
+				return errors.New("数据范围规则缺少表名或字段")
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("不支持的数据范围: %s", scope)
 }
@@ -1,17 +1,19 @@
 package service
 
 import (
+	"context"
 	"template-backend/internal/dto"
 	"template-backend/internal/model"
 	"template-backend/internal/repository"
+	"template-backend/pkg/tracing"
 )
 
 type SchoolAdmissionService interface {
-	Create(info *model.SchoolAdmissionInfo) error
-	GetByID(id int) (*model.SchoolAdmissionInfo, error)
-	List(req *dto.SchoolAdmissionQueryRequest) ([]model.SchoolAdmissionInfo, int64, error)
-	Update(info *model.SchoolAdmissionInfo) error
-	Delete(id int) error
+	Create(ctx context.Context, info *model.SchoolAdmissionInfo) error
+	GetByID(ctx context.Context, id int) (*model.SchoolAdmissionInfo, error)
+	List(ctx context.Context, req *dto.SchoolAdmissionQueryRequest) ([]model.SchoolAdmissionInfo, int64, error)
+	Update(ctx context.Context, info *model.SchoolAdmissionInfo) error
+	Delete(ctx context.Context, id int) error
 }
 
 type schoolAdmissionService struct {
@@ -22,22 +24,40 @@ func NewSchoolAdmissionService(repo repository.SchoolAdmissionRepository) School
 	return &schoolAdmissionService{repo: repo}
 }
 
-func (s *schoolAdmissionService) Create(info *model.SchoolAdmissionInfo) error {
-	return s.repo.Create(info)
+func (s *schoolAdmissionService) Create(ctx context.Context, info *model.SchoolAdmissionInfo) error {
+	ctx, span := tracing.Start(ctx, "SchoolAdmissionService.Create")
+	defer span.End()
+	info.CreatedBy = 0 // 由数据范围插件填充为当前用户
+	return s.repo.Create(ctx, info)
 }
 
-func (s *schoolAdmissionService) GetByID(id int) (*model.SchoolAdmissionInfo, error) {
-	return s.repo.GetByID(id)
+func (s *schoolAdmissionService) GetByID(ctx context.Context, id int) (*model.SchoolAdmissionInfo, error) {
+	ctx, span := tracing.Start(ctx, "SchoolAdmissionService.GetByID")
+	defer span.End()
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *schoolAdmissionService) List(req *dto.SchoolAdmissionQueryRequest) ([]model.SchoolAdmissionInfo, int64, error) {
-	return s.repo.List(req)
+func (s *schoolAdmissionService) List(ctx context.Context, req *dto.SchoolAdmissionQueryRequest) ([]model.SchoolAdmissionInfo, int64, error) {
+	ctx, span := tracing.Start(ctx, "SchoolAdmissionService.List")
+	defer span.End()
+	return s.repo.List(ctx, req)
 }
 
-func (s *schoolAdmissionService) Update(info *model.SchoolAdmissionInfo) error {
-	return s.repo.Update(info)
+func (s *schoolAdmissionService) Update(ctx context.Context, info *model.SchoolAdmissionInfo) error {
+	ctx, span := tracing.Start(ctx, "SchoolAdmissionService.Update")
+	defer span.End()
+	// 先在数据范围内查询，范围外的记录按不存在处理；Save 会写入全部字段，需保留创建信息
+	existing, err := s.repo.GetByID(ctx, info.ID)
+	if err != nil {
+		return err
+	}
+	info.CreatedBy = existing.CreatedBy
+	info.CreatedAt = existing.CreatedAt
+	return s.repo.Update(ctx, info)
 }
 
-func (s *schoolAdmissionService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *schoolAdmissionService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "SchoolAdmissionService.Delete")
+	defer span.End()
+	return s.repo.Delete(ctx, id)
 }
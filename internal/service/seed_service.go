@@ -0,0 +1,318 @@
+// internal/service/seed_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// SeedData 初始化数据文件（YAML 或 JSON）
+type SeedData struct {
+	Roles     []SeedRole     `yaml:"roles" json:"roles"`
+	Users     []SeedUser     `yaml:"users" json:"users"`
+	Resources []SeedResource `yaml:"resources" json:"resources"` // 目录、菜单、按钮组成的资源树
+	APIs      SeedAPIs       `yaml:"apis" json:"apis"`
+}
+
+type SeedRole struct {
+	Code        string   `yaml:"code" json:"code"`
+	Name        string   `yaml:"name" json:"name"`
+	Desc        string   `yaml:"desc" json:"desc"`
+	DataScope   string   `yaml:"data_scope" json:"data_scope"`
+	Permissions []string `yaml:"permissions" json:"permissions"` // 授予的权限标识
+}
+
+type SeedUser struct {
+	Username string   `yaml:"username" json:"username"`
+	Nickname string   `yaml:"nickname" json:"nickname"`
+	Email    string   `yaml:"email" json:"email"`
+	Password string   `yaml:"password" json:"password"` // 为空时生成临时密码
+	Roles    []string `yaml:"roles" json:"roles"`
+	// MustChangePassword 为空时默认要求首次登录修改密码
+	MustChangePassword *bool `yaml:"must_change_password" json:"must_change_password"`
+}
+
+type SeedResource struct {
+	Code      string         `yaml:"code" json:"code"`
+	Name      string         `yaml:"name" json:"name"`
+	Type      string         `yaml:"type" json:"type"` // DIRECTORY | MENU | BUTTON
+	Path      string         `yaml:"path" json:"path"`
+	RouteName string         `yaml:"route_name" json:"route_name"`
+	Component string         `yaml:"component" json:"component"`
+	Redirect  string         `yaml:"redirect" json:"redirect"`
+	Hidden    bool           `yaml:"hidden" json:"hidden"`
+	Sort      int            `yaml:"sort" json:"sort"`
+	Public    bool           `yaml:"public" json:"public"` // 所有登录用户可见
+	Meta      *model.Meta    `yaml:"meta" json:"meta"`
+	Children  []SeedResource `yaml:"children" json:"children"`
+}
+
+// SeedAPIs 按已注册的 gin 路由生成接口资源
+type SeedAPIs struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Public 路径前缀，匹配的接口只要求登录、不校验角色授权
+	Public []string `yaml:"public" json:"public"`
+}
+
+// SeedReport 初始化结果，只统计本次新建的数据
+type SeedReport struct {
+	Roles        int               `json:"roles"`
+	Users        int               `json:"users"`
+	Resources    int               `json:"resources"`
+	APIs         int               `json:"apis"`
+	Grants       int               `json:"grants"`
+	TempPassword map[string]string `json:"-"` // 用户名 -> 生成的临时密码
+}
+
+// LoadSeedFile 读取初始化数据文件，按扩展名区分 JSON 和 YAML
+func LoadSeedFile(path string) (*SeedData, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var data SeedData
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(content, &data)
+	} else {
+		err = yaml.Unmarshal(content, &data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析初始化数据失败: %w", err)
+	}
+	return &data, nil
+}
+
+// SeedService 初始化角色、用户和资源，可重复执行：
+// 已存在的数据（按角色编码、用户名、权限标识判断）保持原样，不覆盖管理员的修改
+type SeedService struct {
+	roleRepo     repository.RoleRepository
+	resourceRepo repository.ResourceRepository
+	userRepo     repository.UserRepository
+	userService  UserService
+	apiSync      APISyncService
+	permCache    *PermissionCache
+}
+
+func NewSeedService(roleRepo repository.RoleRepository, resourceRepo repository.ResourceRepository,
+	userRepo repository.UserRepository, userService UserService, apiSync APISyncService, permCache *PermissionCache) *SeedService {
+	return &SeedService{
+		roleRepo:     roleRepo,
+		resourceRepo: resourceRepo,
+		userRepo:     userRepo,
+		userService:  userService,
+		apiSync:      apiSync,
+		permCache:    permCache,
+	}
+}
+
+// Seed 依次初始化资源、接口、角色和用户。
+// 角色授权只在角色本次新建或资源本次新建时写入，管理员撤销过的授权不会被恢复
+func (s *SeedService) Seed(ctx context.Context, data *SeedData, routes gin.RoutesInfo) (*SeedReport, error) {
+	report := &SeedReport{TempPassword: make(map[string]string)}
+	created := make(map[string]bool) // 本次新建的权限标识
+
+	for i := range data.Resources {
+		if err := s.seedResource(ctx, &data.Resources[i], nil, created, report); err != nil {
+			return nil, err
+		}
+	}
+	if data.APIs.Enabled {
+		sync, err := s.apiSync.Sync(ctx, routes, data.APIs.Public)
+		if err != nil {
+			return nil, fmt.Errorf("初始化接口资源失败: %w", err)
+		}
+		for _, resource := range sync.Created {
+			created[resource.PermissionCode] = true
+		}
+		report.APIs = len(sync.Created)
+	}
+	for _, role := range data.Roles {
+		if err := s.seedRole(ctx, role, created, report); err != nil {
+			return nil, err
+		}
+	}
+	for _, user := range data.Users {
+		if err := s.seedUser(ctx, user, report); err != nil {
+			return nil, err
+		}
+	}
+
+	s.permCache.Invalidate()
+	return report, nil
+}
+
+func (s *SeedService) seedResource(ctx context.Context, item *SeedResource, parentID *int64, created map[string]bool, report *SeedReport) error {
+	if item.Code == "" {
+		return fmt.Errorf("资源 %q 缺少权限标识", item.Name)
+	}
+	resource, err := s.resourceRepo.GetByPermissionCode(ctx, item.Code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		resource, err = s.createResource(ctx, item, parentID)
+		if err == nil {
+			created[item.Code] = true
+			report.Resources++
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("初始化资源 %s 失败: %w", item.Code, err)
+	}
+
+	for i := range item.Children {
+		if err := s.seedResource(ctx, &item.Children[i], &resource.ID, created, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SeedService) createResource(ctx context.Context, item *SeedResource, parentID *int64) (*model.Resource, error) {
+	resourceType := strings.ToUpper(item.Type)
+	if _, ok := resourceTypeToMenuType(resourceType); !ok {
+		return nil, fmt.Errorf("不支持的资源类型: %s", item.Type)
+	}
+
+	meta := model.Meta{Title: item.Name}
+	if item.Meta != nil {
+		meta = *item.Meta
+		if meta.Title == "" {
+			meta.Title = item.Name
+		}
+	}
+	visible := !item.Hidden
+	resource := &model.Resource{
+		ResourceName:   item.Name,
+		PermissionCode: item.Code,
+		Type:           resourceType,
+		ParentID:       parentID,
+		Sort:           item.Sort,
+		Status:         1,
+		RequiresAuth:   1,
+		Name:           item.RouteName,
+		Redirect:       item.Redirect,
+		Visible:        &visible,
+		Meta:           &meta,
+	}
+	if item.Public {
+		resource.RequiresAuth = 0
+	}
+	if item.Path != "" {
+		resource.ResourcePath = &item.Path
+	}
+	if item.Component != "" {
+		resource.Component = &item.Component
+	}
+	if err := insertPublicAware(ctx, s.resourceRepo, resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+func (s *SeedService) seedRole(ctx context.Context, item SeedRole, created map[string]bool, report *SeedReport) error {
+	role, err := s.roleRepo.GetByCode(ctx, item.Code)
+	newRole := false
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		role = &model.Role{RoleName: item.Name, RoleCode: item.Code, RoleDesc: item.Desc, DataScope: item.DataScope, Status: 1}
+		if role.RoleName == "" {
+			role.RoleName = item.Code
+		}
+		if err = validateDataScope(role.DataScope, nil); err == nil {
+			err = s.roleRepo.Create(ctx, role)
+		}
+		newRole = err == nil
+	}
+	if err != nil {
+		return fmt.Errorf("初始化角色 %s 失败: %w", item.Code, err)
+	}
+	if newRole {
+		report.Roles++
+	}
+
+	var grant []string
+	for _, code := range item.Permissions {
+		if newRole || created[code] {
+			grant = append(grant, code)
+		}
+	}
+	if len(grant) == 0 {
+		return nil
+	}
+
+	granted, err := s.roleRepo.GetPermissions(ctx, role.ID)
+	if err != nil {
+		return err
+	}
+	ids := make([]uint, 0, len(granted)+len(grant))
+	seen := make(map[uint]bool, len(granted))
+	for _, resource := range granted {
+		seen[uint(resource.ID)] = true
+		ids = append(ids, uint(resource.ID))
+	}
+	for _, code := range grant {
+		resource, err := s.resourceRepo.GetByPermissionCode(ctx, code)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("角色 %s 的权限标识 %s 不存在", item.Code, code)
+			}
+			return err
+		}
+		if !seen[uint(resource.ID)] {
+			seen[uint(resource.ID)] = true
+			ids = append(ids, uint(resource.ID))
+			report.Grants++
+		}
+	}
+	return s.roleRepo.UpdatePermissions(ctx, role.ID, ids)
+}
+
+func (s *SeedService) seedUser(ctx context.Context, item SeedUser, report *SeedReport) error {
+	if _, err := s.userRepo.GetByUsername(ctx, item.Username); err == nil {
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	roleIDs := make([]uint, 0, len(item.Roles))
+	for _, code := range item.Roles {
+		role, err := s.roleRepo.GetByCode(ctx, code)
+		if err != nil {
+			return fmt.Errorf("用户 %s 的角色 %s 不存在: %w", item.Username, code, err)
+		}
+		roleIDs = append(roleIDs, role.ID)
+	}
+
+	user := &model.User{Username: item.Username, Nickname: item.Nickname, Email: item.Email, Status: 1}
+	if user.Nickname == "" {
+		user.Nickname = user.Username
+	}
+	var err error
+	if item.Password == "" {
+		var password string
+		if password, err = s.userService.CreateWithTempPassword(ctx, user); err == nil {
+			report.TempPassword[user.Username] = password
+		}
+	} else {
+		user.Password = item.Password
+		user.MustChangePassword = item.MustChangePassword == nil || *item.MustChangePassword
+		err = s.userService.Create(ctx, user)
+	}
+	if err != nil {
+		return fmt.Errorf("初始化用户 %s 失败: %w", item.Username, err)
+	}
+	report.Users++
+
+	if len(roleIDs) > 0 {
+		return s.userService.AssignRoles(ctx, user.ID, roleIDs)
+	}
+	return nil
+}
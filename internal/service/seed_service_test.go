@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/internal/testdb"
+	"testing"
+)
+
+func TestSeedPersistsPublicResource(t *testing.T) {
+	db := testdb.Open(t)
+	svc := NewSeedService(repository.NewRoleRepository(db), repository.NewResourceRepository(db), repository.NewUserRepository(db), nil, nil, NewPermissionCache())
+
+	data := &SeedData{Resources: []SeedResource{{
+		Code: "dashboard", Name: "首页", Type: "MENU", Path: "/dashboard", Public: true,
+		Children: []SeedResource{{Code: "dashboard:export", Name: "导出", Type: "BUTTON"}},
+	}}}
+	report, err := svc.Seed(context.Background(), data, nil)
+	if err != nil {
+		t.Fatalf("Seed: %v", err)
+	}
+	if report.Resources != 2 {
+		t.Fatalf("created %d resources, want 2", report.Resources)
+	}
+
+	tests := []struct {
+		code         string
+		requiresAuth int8
+	}{
+		{"dashboard", 0},
+		{"dashboard:export", 1},
+	}
+	for _, tt := range tests {
+		// 直接从数据库读取，确认写入的是 0 而不是列默认值
+		var resource model.Resource
+		if err := db.Where("permission_code = ?", tt.code).First(&resource).Error; err != nil {
+			t.Fatalf("%s: %v", tt.code, err)
+		}
+		if resource.RequiresAuth != tt.requiresAuth {
+			t.Errorf("%s requires_auth = %d, want %d", tt.code, resource.RequiresAuth, tt.requiresAuth)
+		}
+	}
+}
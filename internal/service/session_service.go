@@ -0,0 +1,137 @@
+// internal/service/session_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval 同一会话最后活跃时间的最小更新间隔，避免每个请求都写库
+const sessionTouchInterval = time.Minute
+
+var (
+	touchMu     sync.Mutex
+	lastTouched = make(map[string]time.Time)
+)
+
+var ErrSessionNotFound = errors.New("会话不存在")
+
+type SessionService interface {
+	Start(ctx context.Context, user *model.User, pair *TokenPair, client ClientInfo) error
+	Refreshed(ctx context.Context, pair *TokenPair, client ClientInfo) error
+	Touch(ctx context.Context, sessionID, ip string)
+	List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.Session, int64, error)
+	ListUserSessions(ctx context.Context, userID uint, currentID string) ([]model.Session, error)
+	RevokeSession(ctx context.Context, userID uint, sessionID string) error
+	RevokeUserSessions(ctx context.Context, userID uint) error
+}
+
+type sessionService struct {
+	repo         repository.SessionRepository
+	tokenService TokenService
+}
+
+func NewSessionService(repo repository.SessionRepository, tokenService TokenService) SessionService {
+	return &sessionService{repo: repo, tokenService: tokenService}
+}
+
+// Start 记录一次新的登录会话
+func (s *sessionService) Start(ctx context.Context, user *model.User, pair *TokenPair, client ClientInfo) error {
+	now := time.Now()
+	return s.repo.Create(ctx, &model.Session{
+		ID:         pair.FamilyID,
+		UserID:     user.ID,
+		Username:   user.Username,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		Device:     client.Device,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Duration(pair.RefreshExpiresIn) * time.Second),
+	})
+}
+
+// Refreshed 刷新令牌轮换后更新会话的活跃时间和有效期
+func (s *sessionService) Refreshed(ctx context.Context, pair *TokenPair, client ClientInfo) error {
+	now := time.Now()
+	return s.repo.Extend(ctx, pair.FamilyID, client.IP, now, now.Add(time.Duration(pair.RefreshExpiresIn)*time.Second))
+}
+
+// Touch 记录会话活跃，同一会话在 sessionTouchInterval 内只更新一次
+func (s *sessionService) Touch(ctx context.Context, sessionID, ip string) {
+	if sessionID == "" {
+		return
+	}
+	now := time.Now()
+	touchMu.Lock()
+	if now.Sub(lastTouched[sessionID]) < sessionTouchInterval {
+		touchMu.Unlock()
+		return
+	}
+	for id, t := range lastTouched {
+		if now.Sub(t) >= sessionTouchInterval {
+			delete(lastTouched, id)
+		}
+	}
+	lastTouched[sessionID] = now
+	touchMu.Unlock()
+
+	if err := s.repo.Touch(ctx, sessionID, ip, now); err != nil {
+		logger.FromContext(ctx).Error("touch session failed", zap.String("sessionId", sessionID), zap.Error(err))
+	}
+}
+
+// List 分页查询全部有效会话（管理员）
+func (s *sessionService) List(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.Session, int64, error) {
+	return s.repo.List(ctx, page, pageSize, filters)
+}
+
+// ListUserSessions 查询用户的有效会话，并标记当前请求所属的会话
+func (s *sessionService) ListUserSessions(ctx context.Context, userID uint, currentID string) ([]model.Session, error) {
+	sessions, err := s.repo.ListActiveByUser(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession 结束指定会话；userID 不为 0 时只允许结束该用户自己的会话
+func (s *sessionService) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if userID != 0 && session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.tokenService.RevokeFamily(ctx, session.ID)
+}
+
+// RevokeUserSessions 结束用户的全部会话，用于强制下线、禁用用户和修改密码
+func (s *sessionService) RevokeUserSessions(ctx context.Context, userID uint) error {
+	sessions, err := s.repo.ListActiveByUser(ctx, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := s.tokenService.RevokeFamily(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+	logger.FromContext(ctx).Info("revoked user sessions", zap.Uint("userId", userID), zap.Int("count", len(sessions)))
+	return nil
+}
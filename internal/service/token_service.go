@@ -0,0 +1,450 @@
+// internal/service/token_service.go
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/pkg/logger"
+	"template-backend/pkg/token"
+	"template-backend/pkg/tracing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	// TokenTypeChallenge 密码校验通过、等待两步验证的挑战令牌
+	TokenTypeChallenge = "2fa_challenge"
+
+	defaultAccessTTL    = 15 * time.Minute
+	defaultRefreshTTL   = 7 * 24 * time.Hour
+	defaultChallengeTTL = 5 * time.Minute
+
+	// denylistSyncInterval 吊销列表从数据库同步的间隔，保证多实例部署时吊销最终一致
+	denylistSyncInterval = 30 * time.Second
+)
+
+var (
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrTokenRevoked       = errors.New("token revoked")
+	ErrRefreshTokenReused = errors.New("refresh token reused, all sessions of this login have been revoked")
+)
+
+// ClientInfo 签发令牌时记录的客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	Device    string
+}
+
+// TokenPair 一次签发的访问令牌和刷新令牌
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresIn  int
+	RefreshExpiresIn int
+	FamilyID         string
+}
+
+// tokenDenylist 已吊销访问令牌的内存缓存
+type tokenDenylist struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+type TokenService interface {
+	IssueTokenPair(ctx context.Context, user *model.User, client ClientInfo) (*TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error)
+	IssueChallengeToken(ctx context.Context, user *model.User, setup bool) (string, error)
+	ParseChallengeToken(ctx context.Context, tokenStr string, consume bool) (uint, bool, error)
+	ParseAccessToken(ctx context.Context, tokenStr string) (jwt.MapClaims, error)
+	Revoke(ctx context.Context, userID uint, accessJTI string, accessExpiresAt time.Time, familyID string) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	Start() error
+	Stop(ctx context.Context) error
+	JWKS() token.JWKSet
+}
+
+type tokenService struct {
+	tokens       token.Signer
+	repo         repository.TokenRepository
+	userRepo     repository.UserRepository
+	accessTTL    time.Duration
+	refreshTTL   time.Duration
+	challengeTTL time.Duration
+
+	log      *zap.Logger
+	denylist *tokenDenylist
+	stop     chan struct{} // 关闭后停止吊销列表的后台同步
+	done     chan struct{}
+}
+
+func NewTokenService(appConfig *config.AppConfig, tokens token.Signer, repo repository.TokenRepository,
+	userRepo repository.UserRepository, log *zap.Logger) TokenService {
+	jwtConfig := appConfig.JWT
+	accessTTL := time.Duration(jwtConfig.Expires) * time.Second
+	if accessTTL <= 0 {
+		accessTTL = defaultAccessTTL
+	}
+	refreshTTL := time.Duration(jwtConfig.RefreshExpires) * time.Second
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTTL
+	}
+	challengeTTL := time.Duration(appConfig.TwoFactor.ChallengeExpires) * time.Second
+	if challengeTTL <= 0 {
+		challengeTTL = defaultChallengeTTL
+	}
+	return &tokenService{tokens: tokens, repo: repo, userRepo: userRepo, accessTTL: accessTTL, refreshTTL: refreshTTL, challengeTTL: challengeTTL,
+		log: log, denylist: &tokenDenylist{entries: make(map[string]time.Time)}}
+}
+
+// IssueTokenPair 为一次新的登录签发令牌对
+func (s *tokenService) IssueTokenPair(ctx context.Context, user *model.User, client ClientInfo) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.IssueTokenPair")
+	defer span.End()
+	return s.issue(ctx, user, newTokenID(), newTokenID(), client)
+}
+
+// Refresh 使用刷新令牌轮换出新的令牌对；已使用过的刷新令牌再次出现时吊销整个令牌家族
+func (s *tokenService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.Refresh")
+	defer span.End()
+	claims, err := s.parseToken(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	jti, _ := claims["jti"].(string)
+
+	record, err := s.repo.GetRefreshToken(ctx, jti)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if record.RevokedAt != nil {
+		if record.ReplacedBy == "" {
+			// 登出或被管理员下线的会话
+			return nil, ErrTokenRevoked
+		}
+		return nil, s.handleReuse(ctx, record)
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, record.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Status == 0 {
+		return nil, errors.New("user disabled")
+	}
+
+	if client.Device == "" {
+		client.Device = record.Device
+	}
+	newJTI := newTokenID()
+	ok, err := s.repo.ReplaceRefreshToken(ctx, record.JTI, newJTI, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// 并发请求已抢先使用了该刷新令牌
+		return nil, s.handleReuse(ctx, record)
+	}
+	return s.issue(ctx, user, record.FamilyID, newJTI, client)
+}
+
+// IssueChallengeToken 签发两步验证挑战令牌，setup 表示需要先绑定认证器
+func (s *tokenService) IssueChallengeToken(ctx context.Context, user *model.User, setup bool) (string, error) {
+	_, span := tracing.Start(ctx, "TokenService.IssueChallengeToken")
+	defer span.End()
+	now := time.Now()
+	return s.signToken(jwt.MapClaims{
+		"userId": user.ID,
+		"jti":    newTokenID(),
+		"typ":    TokenTypeChallenge,
+		"setup":  setup,
+		"iat":    now.Unix(),
+		"exp":    now.Add(s.challengeTTL).Unix(),
+	})
+}
+
+// ParseChallengeToken 校验挑战令牌，返回用户 ID 以及是否处于绑定流程。
+// consume 为 true 时同时消耗令牌：jti 写入吊销表，同一挑战令牌只能提交一次验证码，
+// 验证失败也需要重新输入密码；绑定流程中获取密钥不消耗令牌
+func (s *tokenService) ParseChallengeToken(ctx context.Context, tokenStr string, consume bool) (uint, bool, error) {
+	ctx, span := tracing.Start(ctx, "TokenService.ParseChallengeToken")
+	defer span.End()
+	claims, err := s.parseToken(tokenStr, TokenTypeChallenge)
+	if err != nil {
+		return 0, false, err
+	}
+	userID, ok := claims["userId"].(float64)
+	if !ok || userID <= 0 {
+		return 0, false, ErrInvalidToken
+	}
+	jti, _ := claims["jti"].(string)
+	if s.isRevoked(jti) {
+		return 0, false, ErrTokenRevoked
+	}
+	if consume {
+		expiresAt, err := claims.GetExpirationTime()
+		if err != nil || expiresAt == nil {
+			return 0, false, ErrInvalidToken
+		}
+		claimed, err := s.repo.ClaimToken(ctx, &model.RevokedToken{JTI: jti, UserID: uint(userID), ExpiresAt: expiresAt.Time})
+		if err != nil {
+			return 0, false, err
+		}
+		if !claimed {
+			return 0, false, ErrTokenRevoked
+		}
+	}
+	setup, _ := claims["setup"].(bool)
+	return uint(userID), setup, nil
+}
+
+// ParseAccessToken 校验访问令牌的签名、类型和吊销状态
+func (s *tokenService) ParseAccessToken(ctx context.Context, tokenStr string) (jwt.MapClaims, error) {
+	_, span := tracing.Start(ctx, "TokenService.ParseAccessToken")
+	defer span.End()
+	claims, err := s.parseToken(tokenStr, TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+	jti, _ := claims["jti"].(string)
+	if s.isRevoked(jti) {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// Revoke 吊销访问令牌以及其所属登录的全部刷新令牌
+func (s *tokenService) Revoke(ctx context.Context, userID uint, accessJTI string, accessExpiresAt time.Time, familyID string) error {
+	ctx, span := tracing.Start(ctx, "TokenService.Revoke")
+	defer span.End()
+	revoked := []model.RevokedToken{{JTI: accessJTI, UserID: userID, ExpiresAt: accessExpiresAt}}
+	if familyID != "" {
+		tokens, err := s.repo.RevokeFamily(ctx, familyID, time.Now())
+		if err != nil {
+			return err
+		}
+		revoked = append(revoked, s.accessTokensOf(tokens)...)
+	}
+	return s.addRevoked(ctx, revoked)
+}
+
+// RevokeFamily 吊销一次登录（令牌家族）下的全部令牌
+func (s *tokenService) RevokeFamily(ctx context.Context, familyID string) error {
+	ctx, span := tracing.Start(ctx, "TokenService.RevokeFamily")
+	defer span.End()
+	tokens, err := s.repo.RevokeFamily(ctx, familyID, time.Now())
+	if err != nil {
+		return err
+	}
+	return s.addRevoked(ctx, s.accessTokensOf(tokens))
+}
+
+func (s *tokenService) handleReuse(ctx context.Context, record *model.RefreshToken) error {
+	logger.FromContext(ctx).Warn("refresh token reuse detected",
+		zap.Uint("userId", record.UserID), zap.String("familyId", record.FamilyID), zap.String("jti", record.JTI))
+	if err := s.RevokeFamily(ctx, record.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// accessTokensOf 返回令牌家族中仍未过期的访问令牌
+func (s *tokenService) accessTokensOf(tokens []model.RefreshToken) []model.RevokedToken {
+	revoked := make([]model.RevokedToken, 0, len(tokens))
+	for _, t := range tokens {
+		expiresAt := t.CreatedAt.Add(s.accessTTL)
+		if t.AccessJTI == "" || time.Now().After(expiresAt) {
+			continue
+		}
+		revoked = append(revoked, model.RevokedToken{JTI: t.AccessJTI, UserID: t.UserID, ExpiresAt: expiresAt})
+	}
+	return revoked
+}
+
+func (s *tokenService) addRevoked(ctx context.Context, tokens []model.RevokedToken) error {
+	if err := s.repo.AddRevokedTokens(ctx, tokens); err != nil {
+		return err
+	}
+	s.denylist.mu.Lock()
+	for _, t := range tokens {
+		s.denylist.entries[t.JTI] = t.ExpiresAt
+	}
+	s.denylist.mu.Unlock()
+	return nil
+}
+
+func (s *tokenService) isRevoked(jti string) bool {
+	s.denylist.mu.RLock()
+	defer s.denylist.mu.RUnlock()
+	_, revoked := s.denylist.entries[jti]
+	return revoked
+}
+
+// Start 加载吊销列表并启动后台同步，由 Lifecycle 在接收请求前调用；
+// 鉴权时只查内存，不在请求路径上访问数据库
+func (s *tokenService) Start() error {
+	ctx := logger.WithContext(context.Background(), s.log)
+	if err := s.syncDenylist(ctx); err != nil {
+		return err
+	}
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(denylistSyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				if err := s.syncDenylist(ctx); err != nil {
+					s.log.Error("sync revoked tokens failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop 停止后台同步
+func (s *tokenService) Stop(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+	close(s.stop)
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// syncDenylist 从数据库重新加载吊销列表，并顺带清理过期记录；加载失败时保留原列表
+func (s *tokenService) syncDenylist(ctx context.Context) error {
+	now := time.Now()
+	if err := s.repo.DeleteExpired(ctx, now); err != nil {
+		logger.FromContext(ctx).Error("delete expired tokens failed", zap.Error(err))
+	}
+	tokens, err := s.repo.ListRevokedTokens(ctx, now)
+	if err != nil {
+		return fmt.Errorf("加载吊销列表失败: %w", err)
+	}
+
+	entries := make(map[string]time.Time, len(tokens))
+	for _, t := range tokens {
+		entries[t.JTI] = t.ExpiresAt
+	}
+	s.denylist.mu.Lock()
+	// 保留本实例在查询期间新吊销的令牌，吊销只会在令牌过期后移除
+	for jti, expiresAt := range s.denylist.entries {
+		if expiresAt.After(now) {
+			entries[jti] = expiresAt
+		}
+	}
+	s.denylist.entries = entries
+	s.denylist.mu.Unlock()
+	return nil
+}
+
+func (s *tokenService) issue(ctx context.Context, user *model.User, familyID, refreshJTI string, client ClientInfo) (*TokenPair, error) {
+	now := time.Now()
+	accessJTI := newTokenID()
+
+	accessToken, err := s.signToken(jwt.MapClaims{
+		"userId":   user.ID,
+		"username": user.Username,
+		"jti":      accessJTI,
+		"fid":      familyID,
+		"typ":      TokenTypeAccess,
+		"iat":      now.Unix(),
+		"exp":      now.Add(s.accessTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	refreshExpiresAt := now.Add(s.refreshTTL)
+	refreshToken, err := s.signToken(jwt.MapClaims{
+		"userId": user.ID,
+		"jti":    refreshJTI,
+		"fid":    familyID,
+		"typ":    TokenTypeRefresh,
+		"iat":    now.Unix(),
+		"exp":    refreshExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	record := &model.RefreshToken{
+		JTI:       refreshJTI,
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		AccessJTI: accessJTI,
+		Device:    client.Device,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		ExpiresAt: refreshExpiresAt,
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateRefreshToken(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		AccessExpiresIn:  int(s.accessTTL.Seconds()),
+		RefreshExpiresIn: int(s.refreshTTL.Seconds()),
+		FamilyID:         familyID,
+	}, nil
+}
+
+// JWKS 当前可用于验签的公钥集合
+func (s *tokenService) JWKS() token.JWKSet {
+	return s.tokens.JWKS()
+}
+
+func (s *tokenService) signToken(claims jwt.MapClaims) (string, error) {
+	return s.tokens.Sign(claims)
+}
+
+// parseToken 校验令牌并检查令牌类型
+func (s *tokenService) parseToken(tokenStr, tokenType string) (jwt.MapClaims, error) {
+	claims, err := s.tokens.Parse(tokenStr)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if typ, _ := claims["typ"].(string); typ != tokenType {
+		return nil, ErrInvalidToken
+	}
+	if jti, _ := claims["jti"].(string); jti == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func newTokenID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/internal/testdb"
+	"template-backend/pkg/token"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newTestTokenService 基于内存数据库创建令牌服务和一个已启用的用户
+func newTestTokenService(t *testing.T) (TokenService, *model.User) {
+	t.Helper()
+	db := testdb.Open(t)
+	cfg := &config.AppConfig{}
+	cfg.JWT.Secret = "test"
+	tokens, err := token.NewManager(cfg.JWT)
+	if err != nil {
+		t.Fatalf("new manager: %v", err)
+	}
+	users := repository.NewUserRepository(db)
+	user := &model.User{Username: "alice"}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return NewTokenService(cfg, tokens, repository.NewTokenRepository(db), users, zap.NewNop()), user
+}
+
+func TestRefreshRotationAndReuse(t *testing.T) {
+	ctx := context.Background()
+	svc, user := newTestTokenService(t)
+
+	first, err := svc.IssueTokenPair(ctx, user, ClientInfo{Device: "web"})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	other, err := svc.IssueTokenPair(ctx, user, ClientInfo{Device: "app"})
+	if err != nil {
+		t.Fatalf("issue other: %v", err)
+	}
+	second, err := svc.Refresh(ctx, first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second.FamilyID != first.FamilyID || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("refresh did not rotate within the family: %+v -> %+v", first, second)
+	}
+
+	// 按顺序执行：旧刷新令牌再次出现时吊销整个家族，其他登录不受影响
+	tests := []struct {
+		name    string
+		refresh func() error
+		wantErr error
+	}{
+		{"访问令牌不能用于刷新", func() error { _, err := svc.Refresh(ctx, second.AccessToken, ClientInfo{}); return err }, ErrInvalidToken},
+		{"重放已轮换的刷新令牌", func() error { _, err := svc.Refresh(ctx, first.RefreshToken, ClientInfo{}); return err }, ErrRefreshTokenReused},
+		{"家族内最新的刷新令牌已吊销", func() error { _, err := svc.Refresh(ctx, second.RefreshToken, ClientInfo{}); return err }, ErrTokenRevoked},
+		{"家族内的访问令牌已吊销", func() error { _, err := svc.ParseAccessToken(ctx, second.AccessToken); return err }, ErrTokenRevoked},
+		{"其他登录的访问令牌仍有效", func() error { _, err := svc.ParseAccessToken(ctx, other.AccessToken); return err }, nil},
+		{"其他登录仍可刷新", func() error { _, err := svc.Refresh(ctx, other.RefreshToken, ClientInfo{}); return err }, nil},
+	}
+	for _, tt := range tests {
+		if err := tt.refresh(); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestChallengeTokenSingleUse(t *testing.T) {
+	ctx := context.Background()
+	svc, user := newTestTokenService(t)
+
+	challenge, err := svc.IssueChallengeToken(ctx, user, true)
+	if err != nil {
+		t.Fatalf("issue challenge: %v", err)
+	}
+	pair, err := svc.IssueTokenPair(ctx, user, ClientInfo{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	// 按顺序执行：不消耗时可重复解析，消耗后同一挑战令牌不能再次使用
+	tests := []struct {
+		name    string
+		token   string
+		consume bool
+		wantErr error
+	}{
+		{"获取绑定密钥不消耗令牌", challenge, false, nil},
+		{"再次获取绑定密钥", challenge, false, nil},
+		{"提交验证码消耗令牌", challenge, true, nil},
+		{"重复提交验证码", challenge, true, ErrTokenRevoked},
+		{"访问令牌不能作为挑战令牌", pair.AccessToken, false, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		userID, setup, err := svc.ParseChallengeToken(ctx, tt.token, tt.consume)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && (userID != user.ID || !setup) {
+			t.Fatalf("%s: got user %d setup %v, want user %d setup true", tt.name, userID, setup, user.ID)
+		}
+	}
+}
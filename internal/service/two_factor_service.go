@@ -0,0 +1,255 @@
+// internal/service/two_factor_service.go
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/pkg/totp"
+	"time"
+)
+
+const (
+	recoveryCodeCount = 10
+	// recoveryCodeRetries 消耗恢复码时遇到并发修改的最大尝试次数
+	recoveryCodeRetries = 3
+	// totpSkew 允许前后各一个时间步（30 秒）的时钟偏差
+	totpSkew = 1
+)
+
+var (
+	ErrTwoFactorNotEnabled     = errors.New("未启用两步验证")
+	ErrTwoFactorAlreadyEnabled = errors.New("已启用两步验证")
+	ErrTwoFactorNotSetup       = errors.New("请先绑定认证器")
+	ErrTwoFactorInvalidCode    = errors.New("验证码错误")
+	ErrTwoFactorEnforced       = errors.New("所属角色要求必须启用两步验证")
+)
+
+type TwoFactorService interface {
+	IsEnabled(ctx context.Context, userID uint) (bool, error)
+	Enforced(roles []model.Role) bool
+	BeginSetup(ctx context.Context, user *model.User) (*model.TwoFactorSetup, error)
+	Enable(ctx context.Context, userID uint, code string) ([]string, error)
+	Verify(ctx context.Context, userID uint, code string) error
+	VerifyRecoveryCode(ctx context.Context, userID uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	Disable(ctx context.Context, userID uint, code string) error
+	Reset(ctx context.Context, userID uint) error
+}
+
+type twoFactorService struct {
+	repo          repository.TwoFactorRepository
+	issuer        string
+	enforcedRoles map[string]bool
+}
+
+func NewTwoFactorService(appConfig *config.AppConfig, repo repository.TwoFactorRepository) TwoFactorService {
+	cfg := appConfig.TwoFactor
+	issuer := cfg.Issuer
+	if issuer == "" {
+		issuer = appConfig.App.Name
+	}
+	roles := make(map[string]bool, len(cfg.EnforcedRoles))
+	for _, code := range cfg.EnforcedRoles {
+		roles[code] = true
+	}
+	return &twoFactorService{repo: repo, issuer: issuer, enforcedRoles: roles}
+}
+
+// IsEnabled 用户是否已启用两步验证
+func (s *twoFactorService) IsEnabled(ctx context.Context, userID uint) (bool, error) {
+	tf, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return tf != nil && tf.Enabled, nil
+}
+
+// Enforced 用户的有效角色中是否有要求强制启用两步验证的角色
+func (s *twoFactorService) Enforced(roles []model.Role) bool {
+	for _, role := range roles {
+		if role.Status == 1 && s.enforcedRoles[role.RoleCode] {
+			return true
+		}
+	}
+	return false
+}
+
+// BeginSetup 生成新的密钥，等待用户用验证码确认后启用
+func (s *twoFactorService) BeginSetup(ctx context.Context, user *model.User) (*model.TwoFactorSetup, error) {
+	tf, err := s.repo.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf != nil && tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		tf = &model.UserTwoFactor{UserID: user.ID}
+	}
+	tf.Secret = secret
+	tf.LastUsedStep = 0
+	if err := s.repo.Save(ctx, tf); err != nil {
+		return nil, err
+	}
+	return &model.TwoFactorSetup{
+		Secret:     secret,
+		OtpauthURI: totp.URI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// Enable 校验绑定时的验证码并启用两步验证，返回一次性恢复码
+func (s *twoFactorService) Enable(ctx context.Context, userID uint, code string) ([]string, error) {
+	tf, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, ErrTwoFactorNotSetup
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	step, ok := totp.Validate(tf.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrTwoFactorInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tf.Enabled = true
+	tf.EnabledAt = &now
+	tf.RecoveryCodes = hashes
+	tf.LastUsedStep = step
+	if err := s.repo.Save(ctx, tf); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify 校验已启用用户的验证码，同一时间步的验证码只能使用一次
+func (s *twoFactorService) Verify(ctx context.Context, userID uint, code string) error {
+	tf, err := s.enabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Validate(tf.Secret, code, time.Now(), totpSkew)
+	if !ok || step <= tf.LastUsedStep {
+		return ErrTwoFactorInvalidCode
+	}
+	updated, err := s.repo.UpdateLastUsedStep(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrTwoFactorInvalidCode
+	}
+	return nil
+}
+
+// VerifyRecoveryCode 校验并消耗一个恢复码；以读取时的恢复码列表为条件更新，
+// 同一恢复码并发提交时只有一个请求成功，其他恢复码被并发消耗时重新读取后再试
+func (s *twoFactorService) VerifyRecoveryCode(ctx context.Context, userID uint, code string) error {
+	hash := hashRecoveryCode(code)
+	for attempt := 0; attempt < recoveryCodeRetries; attempt++ {
+		tf, err := s.enabled(ctx, userID)
+		if err != nil {
+			return err
+		}
+		i := slices.Index(tf.RecoveryCodes, hash)
+		if i < 0 {
+			return ErrTwoFactorInvalidCode
+		}
+		remaining := slices.Delete(slices.Clone(tf.RecoveryCodes), i, i+1)
+		updated, err := s.repo.ReplaceRecoveryCodes(ctx, userID, tf.RecoveryCodes, remaining)
+		if err != nil {
+			return err
+		}
+		if updated {
+			return nil
+		}
+	}
+	return ErrTwoFactorInvalidCode
+}
+
+// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，旧恢复码全部作废
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	tf, err := s.enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	tf.RecoveryCodes = hashes
+	if err := s.repo.Save(ctx, tf); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable 用户校验验证码后关闭两步验证
+func (s *twoFactorService) Disable(ctx context.Context, userID uint, code string) error {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, userID)
+}
+
+// Reset 管理员重置用户的两步验证（例如用户丢失设备）
+func (s *twoFactorService) Reset(ctx context.Context, userID uint) error {
+	return s.repo.Delete(ctx, userID)
+}
+
+func (s *twoFactorService) enabled(ctx context.Context, userID uint) (*model.UserTwoFactor, error) {
+	tf, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || !tf.Enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return tf, nil
+}
+
+// generateRecoveryCodes 生成恢复码（xxxxx-xxxxx）及其摘要
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
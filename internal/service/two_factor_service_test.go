@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"template-backend/config"
+	"template-backend/internal/model"
+	"template-backend/internal/repository"
+	"template-backend/internal/testdb"
+	"template-backend/pkg/totp"
+	"testing"
+	"time"
+)
+
+// enableTwoFactor 为用户绑定并启用两步验证，返回密钥、启用时使用的时间步和恢复码
+func enableTwoFactor(t *testing.T, svc TwoFactorService, user *model.User) (string, int64, []string) {
+	t.Helper()
+	ctx := context.Background()
+	setup, err := svc.BeginSetup(ctx, user)
+	if err != nil {
+		t.Fatalf("begin setup: %v", err)
+	}
+	step := totp.Step(time.Now())
+	code, err := totp.CodeAt(setup.Secret, step)
+	if err != nil {
+		t.Fatalf("code: %v", err)
+	}
+	codes, err := svc.Enable(ctx, user.ID, code)
+	if err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+	return setup.Secret, step, codes
+}
+
+func TestVerifyRejectsReplayedSteps(t *testing.T) {
+	ctx := context.Background()
+	svc := NewTwoFactorService(&config.AppConfig{}, repository.NewTwoFactorRepository(testdb.Open(t)))
+	user := &model.User{ID: 1, Username: "alice"}
+	secret, step, _ := enableTwoFactor(t, svc, user)
+	code := func(step int64) string {
+		c, err := totp.CodeAt(secret, step)
+		if err != nil {
+			t.Fatalf("code: %v", err)
+		}
+		return c
+	}
+
+	// 按顺序执行：每个时间步只能使用一次，也不能退回到已使用过的时间步
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"启用时使用过的验证码", code(step), ErrTwoFactorInvalidCode},
+		{"下一个时间步", code(step + 1), nil},
+		{"重放下一个时间步", code(step + 1), ErrTwoFactorInvalidCode},
+		{"退回启用时的时间步", code(step), ErrTwoFactorInvalidCode},
+		{"错误的验证码", "000000", ErrTwoFactorInvalidCode},
+	}
+	for _, tt := range tests {
+		if err := svc.Verify(ctx, user.ID, tt.code); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRecoveryCodesSingleUse(t *testing.T) {
+	ctx := context.Background()
+	svc := NewTwoFactorService(&config.AppConfig{}, repository.NewTwoFactorRepository(testdb.Open(t)))
+	user := &model.User{ID: 1, Username: "alice"}
+	_, _, codes := enableTwoFactor(t, svc, user)
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	// 按顺序执行：每个恢复码只能使用一次，输入时忽略大小写和连字符
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"第一个恢复码", codes[0], nil},
+		{"重复使用", codes[0], ErrTwoFactorInvalidCode},
+		{"大写且不带连字符", strings.ToUpper(strings.ReplaceAll(codes[1], "-", "")), nil},
+		{"不存在的恢复码", "aaaaa-bbbbb", ErrTwoFactorInvalidCode},
+		{"其余恢复码不受影响", codes[2], nil},
+	}
+	for _, tt := range tests {
+		if err := svc.VerifyRecoveryCode(ctx, user.ID, tt.code); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -2,46 +2,152 @@
 package service
 
 import (
+	"context"
 	"template-backend/internal/repository"
+	"template-backend/pkg/utils"
+	"time"
 
 	"template-backend/internal/model"
 )
 
-type UserService struct {
-	userDAO *repository.UserRepository
+type UserService interface {
+	GetList(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.User, int64, error)
+	GetByID(ctx context.Context, id uint) (*model.User, error)
+	Create(ctx context.Context, user *model.User) error
+	CreateWithTempPassword(ctx context.Context, user *model.User) (string, error)
+	Update(ctx context.Context, user *model.User) error
+	Delete(ctx context.Context, id uint) error
+	ForceLogout(ctx context.Context, userID uint) error
+	GetUserSessions(ctx context.Context, userID uint) ([]model.Session, error)
+	ResetTwoFactor(ctx context.Context, userID uint) error
+	ResetPassword(ctx context.Context, userID uint) (string, error)
+	AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error
+	GetUserRoles(ctx context.Context, userID uint) ([]model.Role, error)
 }
 
-func NewUserService(userDAO *repository.UserRepository) *UserService {
-	return &UserService{userDAO: userDAO}
+type userService struct {
+	userDAO        repository.UserRepository
+	sessionService SessionService
+	twoFactor      TwoFactorService
+	passwords      PasswordService
+	permCache      *PermissionCache
 }
 
-func (s *UserService) GetList(page, pageSize int, filters map[string]interface{}) ([]model.User, int64, error) {
+func NewUserService(userDAO repository.UserRepository, sessionService SessionService, twoFactor TwoFactorService,
+	passwords PasswordService, permCache *PermissionCache) UserService {
+	return &userService{userDAO: userDAO, sessionService: sessionService, twoFactor: twoFactor, passwords: passwords,
+		permCache: permCache}
+}
+
+func (s *userService) GetList(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]model.User, int64, error) {
+
+	return s.userDAO.GetList(ctx, page, pageSize, filters)
+}
+
+func (s *userService) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	return s.userDAO.GetByID(ctx, id)
+}
+
+// Create 创建用户，user.Password 为明文密码，按密码策略校验后加密保存
+func (s *userService) Create(ctx context.Context, user *model.User) error {
+	if err := s.passwords.Validate(user.Username, user.Password); err != nil {
+		return err
+	}
+	hashed, err := utils.HashPassword(user.Password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	user.Password = hashed
+	user.PasswordChangedAt = &now
+	if err := s.userDAO.Create(ctx, user); err != nil {
+		return err
+	}
+	s.passwords.Remember(ctx, user)
+	return nil
+}
+
+// CreateWithTempPassword 使用一次性临时密码创建用户，用户首次登录后必须修改密码
+func (s *userService) CreateWithTempPassword(ctx context.Context, user *model.User) (string, error) {
+	password, err := generateTempPassword()
+	if err != nil {
+		return "", err
+	}
+	user.Password = password
+	user.MustChangePassword = true
+	if err := s.Create(ctx, user); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+func (s *userService) Update(ctx context.Context, user *model.User) error {
+	if err := s.userDAO.Update(ctx, user); err != nil {
+		return err
+	}
+	s.permCache.Invalidate()
+	// 禁用用户时立即结束其全部会话
+	if user.Status == 0 {
+		return s.sessionService.RevokeUserSessions(ctx, user.ID)
+	}
+	return nil
+}
 
-	return s.userDAO.GetList(page, pageSize, filters)
+func (s *userService) Delete(ctx context.Context, id uint) error {
+	if err := s.userDAO.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.permCache.Invalidate()
+	if err := s.twoFactor.Reset(ctx, id); err != nil {
+		return err
+	}
+	if err := s.passwords.DeleteHistory(ctx, id); err != nil {
+		return err
+	}
+	return s.sessionService.RevokeUserSessions(ctx, id)
 }
 
-func (s *UserService) GetByID(id uint) (*model.User, error) {
-	return s.userDAO.GetByID(id)
+// ForceLogout 强制用户下线
+func (s *userService) ForceLogout(ctx context.Context, userID uint) error {
+	return s.sessionService.RevokeUserSessions(ctx, userID)
 }
 
-func (s *UserService) Create(user *model.User) error {
-	return s.userDAO.Create(user)
+// GetUserSessions 获取用户的有效会话
+func (s *userService) GetUserSessions(ctx context.Context, userID uint) ([]model.Session, error) {
+	return s.sessionService.ListUserSessions(ctx, userID, "")
 }
 
-func (s *UserService) Update(user *model.User) error {
-	return s.userDAO.Update(user)
+// ResetTwoFactor 管理员重置用户的两步验证，用户下次登录时重新绑定
+func (s *userService) ResetTwoFactor(ctx context.Context, userID uint) error {
+	return s.twoFactor.Reset(ctx, userID)
 }
 
-func (s *UserService) Delete(id uint) error {
-	return s.userDAO.Delete(id)
+// ResetPassword 管理员重置密码，返回一次性临时密码并结束用户的全部会话
+func (s *userService) ResetPassword(ctx context.Context, userID uint) (string, error) {
+	user, err := s.userDAO.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	password, err := s.passwords.Reset(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	if err := s.sessionService.RevokeUserSessions(ctx, userID); err != nil {
+		return "", err
+	}
+	return password, nil
 }
 
 // 为用户分配角色
-func (s *UserService) AssignRoles(userID uint, roleIDs []uint) error {
-	return s.userDAO.AssignRoles(userID, roleIDs)
+func (s *userService) AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	if err := s.userDAO.AssignRoles(ctx, userID, roleIDs); err != nil {
+		return err
+	}
+	s.permCache.Invalidate()
+	return nil
 }
 
 // 获取用户的角色
-func (s *UserService) GetUserRoles(userID uint) ([]model.Role, error) {
-	return s.userDAO.GetUserRoles(userID)
+func (s *userService) GetUserRoles(ctx context.Context, userID uint) ([]model.Role, error) {
+	return s.userDAO.GetUserRoles(ctx, userID)
 }
@@ -0,0 +1,34 @@
+// Package testdb 为集成测试创建已执行全部迁移的内存 SQLite 数据库
+package testdb
+
+import (
+	"template-backend/config"
+	"template-backend/pkg/migrate"
+	"testing"
+
+	"gorm.io/gorm/logger"
+
+	"gorm.io/gorm"
+
+	// 迁移在 init 中注册
+	_ "template-backend/internal/migrations"
+)
+
+// Open 创建独立的内存数据库并执行全部迁移，测试结束时关闭
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := config.OpenDB(config.DatabaseConfig{Driver: config.DriverSQLite, Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	db.Logger = logger.Discard
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if _, err := migrate.New(db).Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
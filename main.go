@@ -1,6 +1,9 @@
 package main
 
-import "template-backend/cmd/server"
+import (
+	"os"
+	"template-backend/cmd/cli"
+)
 
 // @title template-backend API
 // @version 1.0
@@ -8,5 +11,6 @@ import "template-backend/cmd/server"
 // @host localhost:8080
 // @BasePath /api
 func main() {
-	server.ServerMain()
+	// 子命令见 cli.Run，不带参数时启动服务
+	cli.Run(os.Args[1:])
 }
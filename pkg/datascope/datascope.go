@@ -0,0 +1,91 @@
+// Package datascope 实现基于角色的数据范围（行级权限）。
+//
+// 请求进入时由中间件解析当前用户的数据范围并放入 context，
+// 仓储层通过 db.WithContext(ctx) 查询时由 GORM 插件自动追加过滤条件。
+package datascope
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// 数据范围类型
+const (
+	All    = "all"    // 全部数据
+	Custom = "custom" // 按字段取值过滤，例如只能查看指定区属或学校代码
+	Own    = "own"    // 只能查看自己创建的数据
+)
+
+// ErrOutOfScope 写入的数据超出当前用户的数据范围
+var ErrOutOfScope = errors.New("数据超出权限范围")
+
+// Rule 自定义数据范围规则：Table 表的 Column 字段只能取 Values 中的值
+type Rule struct {
+	Table  string   `json:"table"`
+	Column string   `json:"column"`
+	Values []string `json:"values"`
+}
+
+// Scope 当前用户的数据范围，多个角色的范围取并集
+type Scope struct {
+	UserID uint
+	All    bool
+	Own    bool
+	// Rules 表名 -> 字段 -> 允许的取值
+	Rules map[string]map[string][]string
+}
+
+// AddRule 合并一条自定义规则
+func (s *Scope) AddRule(r Rule) {
+	if r.Table == "" || r.Column == "" {
+		return
+	}
+	if s.Rules == nil {
+		s.Rules = make(map[string]map[string][]string)
+	}
+	if s.Rules[r.Table] == nil {
+		s.Rules[r.Table] = make(map[string][]string)
+	}
+	s.Rules[r.Table][r.Column] = append(s.Rules[r.Table][r.Column], r.Values...)
+}
+
+type scopeKey struct{}
+
+// WithScope 将数据范围放入 context
+func WithScope(ctx context.Context, scope *Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// FromContext 获取 context 中的数据范围，未设置时返回 nil（不限制）
+func FromContext(ctx context.Context) *Scope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(scopeKey{}).(*Scope)
+	return scope
+}
+
+// tableConfig 受数据范围约束的表
+type tableConfig struct {
+	ownerColumn string // 记录创建人的字段，为空时不支持 own 范围
+}
+
+var (
+	tablesMu sync.RWMutex
+	tables   = make(map[string]tableConfig)
+)
+
+// RegisterTable 声明表受数据范围约束，ownerColumn 为记录创建人 ID 的字段
+func RegisterTable(table, ownerColumn string) {
+	tablesMu.Lock()
+	defer tablesMu.Unlock()
+	tables[table] = tableConfig{ownerColumn: ownerColumn}
+}
+
+func lookupTable(table string) (tableConfig, bool) {
+	tablesMu.RLock()
+	defer tablesMu.RUnlock()
+	cfg, ok := tables[table]
+	return cfg, ok
+}
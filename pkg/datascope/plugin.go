@@ -0,0 +1,232 @@
+package datascope
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Plugin GORM 插件，按 context 中的数据范围过滤查询、更新和删除，并校验写入的数据
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "datascope"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Query().Before("gorm:query").Register("datascope:query", filter); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("datascope:row", filter); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("datascope:update", func(db *gorm.DB) {
+		filter(db)
+		checkUpdate(db)
+	}); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("datascope:delete", filter); err != nil {
+		return err
+	}
+	return cb.Create().Before("gorm:create").Register("datascope:create", func(db *gorm.DB) {
+		setOwner(db)
+		checkCreate(db)
+	})
+}
+
+// active 返回需要应用的数据范围和表配置
+func active(db *gorm.DB) (*Scope, tableConfig, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, tableConfig{}, false
+	}
+	cfg, ok := lookupTable(db.Statement.Schema.Table)
+	if !ok {
+		return nil, tableConfig{}, false
+	}
+	scope := FromContext(db.Statement.Context)
+	if scope == nil || scope.All {
+		return nil, tableConfig{}, false
+	}
+	return scope, cfg, true
+}
+
+// filter 追加 (创建人 = 当前用户 OR 字段 IN 允许值 ...) 条件，没有任何可用条件时不返回数据
+func filter(db *gorm.DB) {
+	scope, cfg, ok := active(db)
+	if !ok {
+		return
+	}
+
+	var exprs []clause.Expression
+	if scope.Own && cfg.ownerColumn != "" {
+		exprs = append(exprs, clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: cfg.ownerColumn},
+			Value:  scope.UserID,
+		})
+	}
+	for column, values := range scope.Rules[db.Statement.Schema.Table] {
+		field := db.Statement.Schema.LookUpField(column)
+		if field == nil || len(values) == 0 {
+			continue
+		}
+		in := make([]interface{}, len(values))
+		for i, v := range values {
+			in[i] = v
+		}
+		exprs = append(exprs, clause.IN{
+			Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+			Values: in,
+		})
+	}
+
+	// 已有条件整体加括号，避免其中的 OR 绕过数据范围
+	if c, ok := db.Statement.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 1 {
+			c.Expression = clause.Where{Exprs: []clause.Expression{clause.And(where.Exprs...)}}
+			db.Statement.Clauses["WHERE"] = c
+		}
+	}
+
+	switch len(exprs) {
+	case 0:
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "1 = 0"}}})
+	case 1:
+		// 单个条件的 OrConditions 会被拼接为 "OR ..."，必须直接追加
+		db.Statement.AddClause(clause.Where{Exprs: exprs})
+	default:
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.Or(exprs...)}})
+	}
+}
+
+// setOwner 创建记录时自动填充创建人
+func setOwner(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	cfg, ok := lookupTable(db.Statement.Schema.Table)
+	if !ok || cfg.ownerColumn == "" {
+		return
+	}
+	scope := FromContext(db.Statement.Context)
+	if scope == nil || scope.UserID == 0 {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(cfg.ownerColumn)
+	if field == nil {
+		return
+	}
+	eachRow(db, func(rv reflect.Value) {
+		if _, zero := field.ValueOf(db.Statement.Context, rv); zero {
+			if err := field.Set(db.Statement.Context, rv, scope.UserID); err != nil {
+				_ = db.AddError(err)
+			}
+		}
+	})
+}
+
+// checkCreate 新建的数据必须落在自定义范围内；拥有 own 范围时新建的记录总归属于自己
+func checkCreate(db *gorm.DB) {
+	scope, _, ok := active(db)
+	if !ok || scope.Own {
+		return
+	}
+	rules := scope.Rules[db.Statement.Schema.Table]
+	if len(rules) == 0 {
+		_ = db.AddError(ErrOutOfScope)
+		return
+	}
+	for column, values := range rules {
+		field := db.Statement.Schema.LookUpField(column)
+		if field == nil {
+			continue
+		}
+		eachRow(db, func(rv reflect.Value) {
+			value, _ := field.ValueOf(db.Statement.Context, rv)
+			if !contains(values, value) {
+				_ = db.AddError(fmt.Errorf("%w: %s", ErrOutOfScope, field.DBName))
+			}
+		})
+	}
+}
+
+// checkUpdate 不允许把数据修改到自定义范围之外，零值字段不会被 Updates 更新因此跳过
+func checkUpdate(db *gorm.DB) {
+	scope, _, ok := active(db)
+	if !ok {
+		return
+	}
+	rules := scope.Rules[db.Statement.Schema.Table]
+	if len(rules) == 0 {
+		return
+	}
+
+	dest := reflect.Indirect(reflect.ValueOf(db.Statement.Dest))
+	for column, values := range rules {
+		field := db.Statement.Schema.LookUpField(column)
+		if field == nil {
+			continue
+		}
+		var value interface{}
+		switch dest.Kind() {
+		case reflect.Map:
+			if dest.Type().Key().Kind() != reflect.String {
+				continue
+			}
+			v := dest.MapIndex(reflect.ValueOf(field.DBName))
+			if !v.IsValid() {
+				v = dest.MapIndex(reflect.ValueOf(field.Name))
+			}
+			if !v.IsValid() {
+				continue
+			}
+			value = v.Interface()
+		case reflect.Struct:
+			if dest.Type() != db.Statement.Schema.ModelType {
+				continue
+			}
+			v, zero := field.ValueOf(db.Statement.Context, dest)
+			if zero {
+				continue
+			}
+			value = v
+		default:
+			continue
+		}
+		if !contains(values, value) {
+			_ = db.AddError(fmt.Errorf("%w: %s", ErrOutOfScope, field.DBName))
+		}
+	}
+}
+
+// eachRow 遍历语句中的模型（单个结构体或切片）
+func eachRow(db *gorm.DB, fn func(rv reflect.Value)) {
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			fn(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		fn(rv)
+	}
+}
+
+func contains(values []string, value interface{}) bool {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if !rv.IsValid() {
+		return false
+	}
+	s := fmt.Sprint(rv.Interface())
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+var _ gorm.Plugin = Plugin{}
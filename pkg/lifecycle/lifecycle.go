@@ -0,0 +1,94 @@
+// Package lifecycle 按注册顺序启动组件、逆序停止组件，停止时每个组件有独立的超时
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultStopTimeout = 10 * time.Second
+
+// Hook 一个组件的启动和停止函数，两者都可以为空
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+	// StopTimeout 停止超时，为 0 时使用 Manager 的默认值
+	StopTimeout time.Duration
+}
+
+// Manager 组件生命周期管理。先注册的组件先启动、后停止，
+// 例如先注册数据库、再注册日志写入、最后注册 HTTP 服务，
+// 停止时 HTTP 服务先停止接收请求，日志写完后再关闭数据库
+type Manager struct {
+	mu          sync.Mutex
+	hooks       []Hook
+	started     int // 已启动的组件数，Stop 只停止这些组件
+	stopTimeout time.Duration
+}
+
+// New stopTimeout 为组件默认停止超时，<= 0 时为 10 秒
+func New(stopTimeout time.Duration) *Manager {
+	if stopTimeout <= 0 {
+		stopTimeout = defaultStopTimeout
+	}
+	return &Manager{stopTimeout: stopTimeout}
+}
+
+// Append 注册组件，只能在 Start 之前调用
+func (m *Manager) Append(hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Start 按注册顺序启动组件；某个组件启动失败时逆序停止已启动的组件并返回错误
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	var err error
+	for m.started < len(m.hooks) {
+		hook := m.hooks[m.started]
+		if hook.Start != nil {
+			if err = hook.Start(ctx); err != nil {
+				err = fmt.Errorf("启动 %s 失败: %w", hook.Name, err)
+				break
+			}
+		}
+		m.started++
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		if stopErr := m.Stop(context.Background()); stopErr != nil {
+			return errors.Join(err, stopErr)
+		}
+	}
+	return err
+}
+
+// Stop 逆序停止已启动的组件，单个组件超时或出错不影响后续组件停止，返回全部错误
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	for ; m.started > 0; m.started-- {
+		hook := m.hooks[m.started-1]
+		if hook.Stop == nil {
+			continue
+		}
+		timeout := hook.StopTimeout
+		if timeout <= 0 {
+			timeout = m.stopTimeout
+		}
+		stopCtx, cancel := context.WithTimeout(ctx, timeout)
+		if err := hook.Stop(stopCtx); err != nil {
+			errs = append(errs, fmt.Errorf("停止 %s 失败: %w", hook.Name, err))
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,24 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type ctxKey struct{}
+
+// WithContext 将请求级 logger 放入 context
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext 获取 context 中的请求级 logger（带请求 ID 等字段），没有时返回全局 logger
+func FromContext(ctx context.Context) *zap.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*zap.Logger); ok {
+			return l
+		}
+	}
+	return Logger()
+}
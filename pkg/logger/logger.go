@@ -1,36 +1,33 @@
 package logger
 
 import (
+	"sync/atomic"
+
 	"go.uber.org/zap"
-	"sync"
-	"template-backend/config"
 )
 
 var (
-	log  *zap.Logger
-	once sync.Once
+	log atomic.Pointer[zap.Logger]
+	nop = zap.NewNop()
 )
 
-// Init 初始化全局 logger
-func Init() {
-	once.Do(func() {
-		var err error
-		appConfig := config.GetConfig()
-		if appConfig.App.Env == "dev" {
-			log, err = zap.NewDevelopment()
-		} else {
-			log, err = zap.NewProduction()
-		}
-		if err != nil {
-			panic(err)
-		}
-	})
+// New 按运行环境创建 logger，dev 环境使用开发模式输出
+func New(env string) (*zap.Logger, error) {
+	if env == "dev" {
+		return zap.NewDevelopment()
+	}
+	return zap.NewProduction()
+}
+
+// Set 设置全局 logger，由应用容器在启动时调用
+func Set(l *zap.Logger) {
+	log.Store(l)
 }
 
-// Logger 获取全局 logger
+// Logger 获取全局 logger，未设置时返回不输出任何内容的 logger，便于在测试中直接使用各组件
 func Logger() *zap.Logger {
-	if log == nil {
-		panic("logger 未初始化，请先调用 logger.Init()")
+	if l := log.Load(); l != nil {
+		return l
 	}
-	return log
+	return nop
 }
@@ -0,0 +1,55 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var nameSanitizer = regexp.MustCompile(`[^a-z0-9]+`)
+
+var migrationTemplate = template.Must(template.New("migration").Parse(`package migrations
+
+import (
+	"template-backend/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	migrate.Register(migrate.Migration{
+		Version: "{{.Version}}",
+		Name:    "{{.Name}}",
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`))
+
+// Create 在 dir 目录下生成新的迁移文件，返回文件路径
+func Create(dir, name string) (string, error) {
+	name = strings.Trim(nameSanitizer.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", fmt.Errorf("迁移名称不能为空")
+	}
+	version := time.Now().Format("20060102150405")
+	path := filepath.Join(dir, version+"_"+name+".go")
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := migrationTemplate.Execute(f, map[string]string{"Version": version, "Name": name}); err != nil {
+		return "", err
+	}
+	return path, nil
+}
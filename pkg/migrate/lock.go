@@ -0,0 +1,118 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultLockTimeout = time.Minute
+	lockRetryInterval  = time.Second
+	// lockHeartbeat 持有锁期间刷新 locked_at 的间隔，迁移耗时再长锁也不会过期
+	lockHeartbeat = 30 * time.Second
+	// lockStaleAfter 持有锁的实例异常退出、停止刷新后，超过该时间的锁可被抢占
+	lockStaleAfter = 5 * time.Minute
+)
+
+var (
+	ErrLocked   = errors.New("其他实例正在执行迁移")
+	ErrLockLost = errors.New("迁移锁在执行期间被其他实例抢占，请检查数据库状态")
+)
+
+// schemaMigrationLock 迁移锁，表中只有 ID 为 1 的一行。
+// 使用普通表加条件更新实现，不依赖特定数据库的锁函数
+type schemaMigrationLock struct {
+	ID       int    `gorm:"primaryKey;autoIncrement:false"`
+	Locked   bool   `gorm:"not null;default:false"`
+	Owner    string `gorm:"type:varchar(100)"`
+	LockedAt *time.Time
+}
+
+func (schemaMigrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// withLock 创建迁移相关的表并在持有锁期间执行 fn
+func (m *Migrator) withLock(fn func() error) error {
+	if err := m.db.AutoMigrate(&SchemaMigration{}, &schemaMigrationLock{}); err != nil {
+		return fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+	if err := m.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&schemaMigrationLock{ID: 1}).Error; err != nil {
+		return fmt.Errorf("初始化迁移锁失败: %w", err)
+	}
+
+	owner := lockOwner()
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		acquired, err := m.tryLock(owner)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return ErrLocked
+		}
+		time.Sleep(lockRetryInterval)
+	}
+	defer m.unlock(owner)
+
+	stop := make(chan struct{})
+	lost := make(chan bool, 1)
+	go func() {
+		lost <- m.heartbeat(owner, stop)
+	}()
+	err := fn()
+	close(stop)
+	if <-lost && err == nil {
+		err = ErrLockLost
+	}
+	return err
+}
+
+// heartbeat 定时刷新 locked_at 直到 stop 关闭，返回锁是否已被其他实例抢占。
+// 刷新失败（例如 sqlite 写事务未结束）时等待下一次刷新
+func (m *Migrator) heartbeat(owner string, stop <-chan struct{}) bool {
+	ticker := time.NewTicker(lockHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return false
+		case <-ticker.C:
+			result := m.db.Model(&schemaMigrationLock{}).
+				Where("id = ? AND owner = ?", 1, owner).
+				Update("locked_at", time.Now())
+			if result.Error == nil && result.RowsAffected == 0 {
+				return true
+			}
+		}
+	}
+}
+
+func (m *Migrator) tryLock(owner string) (bool, error) {
+	now := time.Now()
+	result := m.db.Model(&schemaMigrationLock{}).
+		Where("id = ? AND (locked = ? OR locked_at < ?)", 1, false, now.Add(-lockStaleAfter)).
+		Updates(map[string]interface{}{"locked": true, "owner": owner, "locked_at": now})
+	if result.Error != nil {
+		return false, fmt.Errorf("获取迁移锁失败: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (m *Migrator) unlock(owner string) {
+	m.db.Model(&schemaMigrationLock{}).
+		Where("id = ? AND owner = ?", 1, owner).
+		Updates(map[string]interface{}{"locked": false, "owner": "", "locked_at": nil})
+}
+
+func lockOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano())
+}
@@ -0,0 +1,213 @@
+// Package migrate 实现带版本号的数据库迁移。
+//
+// 迁移以 Go 代码编写，在 init 中通过 Register 注册，按版本号顺序执行，
+// 已执行的版本记录在 schema_migrations 表中。执行迁移前会获取 schema_migrations_lock
+// 表中的锁，避免多个实例同时迁移。
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoDown           = errors.New("迁移未提供回滚")
+	ErrUnknownVersion   = errors.New("数据库中存在未知的迁移版本")
+	ErrDuplicateVersion = errors.New("迁移版本重复")
+)
+
+// Migration 一次迁移，Version 为 yyyyMMddHHmmss 格式的时间戳
+type Migration struct {
+	Version string
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;type:varchar(20)"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 迁移状态，AppliedAt 为空表示尚未执行
+type Status struct {
+	Version   string
+	Name      string
+	AppliedAt *time.Time
+	Unknown   bool // 数据库中已执行但代码中不存在的版本
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Migration)
+)
+
+// Register 注册迁移，版本号重复时 panic
+func Register(m Migration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[m.Version]; ok {
+		panic(fmt.Sprintf("%v: %s", ErrDuplicateVersion, m.Version))
+	}
+	registry[m.Version] = m
+}
+
+// Migrations 返回按版本号升序排列的全部已注册迁移
+func Migrations() []Migration {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	list := make([]Migration, 0, len(registry))
+	for _, m := range registry {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+type Migrator struct {
+	db          *gorm.DB
+	lockTimeout time.Duration
+}
+
+func New(db *gorm.DB) *Migrator {
+	return &Migrator{db: db, lockTimeout: defaultLockTimeout}
+}
+
+// Up 按顺序执行全部未执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up() ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func() error {
+		pending, err := m.Pending()
+		if err != nil {
+			return err
+		}
+		for _, migration := range pending {
+			if err := m.apply(migration); err != nil {
+				return fmt.Errorf("执行迁移 %s_%s 失败: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按执行顺序倒序回滚最近 n 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(n int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		known := make(map[string]Migration)
+		for _, migration := range Migrations() {
+			known[migration.Version] = migration
+		}
+		for i := len(applied) - 1; i >= 0 && len(done) < n; i-- {
+			migration, ok := known[applied[i].Version]
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrUnknownVersion, applied[i].Version)
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("%w: %s_%s", ErrNoDown, migration.Version, migration.Name)
+			}
+			if err := m.revert(migration); err != nil {
+				return fmt.Errorf("回滚迁移 %s_%s 失败: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 返回全部迁移的执行情况，包括数据库中存在但代码中未注册的版本
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[string]time.Time, len(applied))
+	for _, a := range applied {
+		appliedAt[a.Version] = a.AppliedAt
+	}
+
+	var list []Status
+	for _, migration := range Migrations() {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if t, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &t
+			delete(appliedAt, migration.Version)
+		}
+		list = append(list, status)
+	}
+	for _, a := range applied {
+		if _, ok := appliedAt[a.Version]; ok {
+			t := a.AppliedAt
+			list = append(list, Status{Version: a.Version, Name: a.Name, AppliedAt: &t, Unknown: true})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Pending 返回尚未执行的迁移
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(applied))
+	for _, a := range applied {
+		done[a.Version] = true
+	}
+	var pending []Migration
+	for _, migration := range Migrations() {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// applied 查询已执行的迁移，schema_migrations 表不存在时视为全部未执行
+func (m *Migrator) applied() ([]SchemaMigration, error) {
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil, nil
+	}
+	var list []SchemaMigration
+	err := m.db.Order("version ASC").Find(&list).Error
+	return list, err
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if migration.Up != nil {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+	})
+}
+
+func (m *Migrator) revert(migration Migration) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+	})
+}
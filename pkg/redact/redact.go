@@ -0,0 +1,275 @@
+// Package redact 请求日志脱敏：按键名和 JSON 路径屏蔽密码、令牌、手机号等字段，支持按路由配置
+package redact
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMask 整个值被替换成的文本
+const DefaultMask = "******"
+
+// DefaultKeys 未配置 keys 时整值屏蔽的键名
+var DefaultKeys = []string{
+	"password", "oldPassword", "newPassword", "confirmPassword",
+	"token", "accessToken", "refreshToken", "challengeToken",
+	"secret", "authorization", "cookie",
+	"otpauthUri", "recoveryCode", "recoveryCodes",
+}
+
+// DefaultPartialKeys 未配置 partial_keys 时部分屏蔽的键名
+var DefaultPartialKeys = []string{"phone", "email"}
+
+// Config 脱敏配置。键名不区分大小写并忽略 _ 和 -，匹配任意层级；
+// 路径从 JSON 根开始，以 . 分隔，* 匹配任意键或数组元素，例如 data.list.*.idCard
+type Config struct {
+	Mask        string      // 替换文本，默认 ******
+	Keys        []string    // 整值屏蔽的键名，未配置时使用 DefaultKeys
+	PartialKeys []string    `mapstructure:"partial_keys"` // 保留首尾部分字符的键名，未配置时使用 DefaultPartialKeys
+	Paths       []string    // 整值屏蔽的 JSON 路径
+	Routes      []RouteRule // 按路由追加的规则
+}
+
+// RouteRule 单个路由的脱敏规则，Path 为 gin 路由模板，例如 /api/users/:id
+type RouteRule struct {
+	Method       string   // 为空时匹配全部方法
+	Path         string   // gin 路由模板
+	SkipRequest  bool     `mapstructure:"skip_request"`  // 不记录请求体
+	SkipResponse bool     `mapstructure:"skip_response"` // 不记录响应体
+	Keys         []string // 追加的整值屏蔽键名
+	Paths        []string // 追加的 JSON 路径
+}
+
+// Redactor 按路由选择脱敏策略，创建后只读，可以并发使用
+type Redactor struct {
+	fallback *Policy
+	routes   map[string]*Policy
+}
+
+// Policy 一个路由生效的脱敏规则
+type Policy struct {
+	SkipRequest  bool
+	SkipResponse bool
+
+	mask    string
+	keys    map[string]bool
+	partial map[string]bool
+	paths   [][]string
+}
+
+// textPattern 无法解析为 JSON 的文本中的 "key": value（第 1、2 组）和 key=value（第 3、4、5 组）。
+// 值没有结束引号时（请求体被截断）匹配到文本末尾；对象和数组不在这里匹配，其中的字段单独匹配
+var textPattern = regexp.MustCompile(`"([\w\-]+)"\s*:\s*("(?:[^"\\]|\\.)*"|"[^"]*$|[^\s,{}\[\]"]+)|(^|[?&;\s])([\w\-]+)=([^&;\s]*)`)
+
+func New(cfg Config) *Redactor {
+	if cfg.Mask == "" {
+		cfg.Mask = DefaultMask
+	}
+	if cfg.Keys == nil {
+		cfg.Keys = DefaultKeys
+	}
+	if cfg.PartialKeys == nil {
+		cfg.PartialKeys = DefaultPartialKeys
+	}
+
+	r := &Redactor{
+		fallback: newPolicy(cfg, RouteRule{}),
+		routes:   make(map[string]*Policy, len(cfg.Routes)),
+	}
+	for _, rule := range cfg.Routes {
+		r.routes[routeKey(rule.Method, rule.Path)] = newPolicy(cfg, rule)
+	}
+	return r
+}
+
+// For 返回路由的脱敏策略，route 为 gin 的 FullPath；先按方法和路由匹配，再匹配不限方法的规则
+func (r *Redactor) For(method, route string) *Policy {
+	if p, ok := r.routes[routeKey(method, route)]; ok {
+		return p
+	}
+	if p, ok := r.routes[routeKey("", route)]; ok {
+		return p
+	}
+	return r.fallback
+}
+
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+func newPolicy(cfg Config, rule RouteRule) *Policy {
+	p := &Policy{
+		SkipRequest:  rule.SkipRequest,
+		SkipResponse: rule.SkipResponse,
+		mask:         cfg.Mask,
+		keys:         make(map[string]bool),
+		partial:      make(map[string]bool),
+	}
+	for _, keys := range [][]string{cfg.Keys, rule.Keys} {
+		for _, key := range keys {
+			p.keys[normalize(key)] = true
+		}
+	}
+	for _, key := range cfg.PartialKeys {
+		p.partial[normalize(key)] = true
+	}
+	for _, paths := range [][]string{cfg.Paths, rule.Paths} {
+		for _, path := range paths {
+			if path != "" {
+				p.paths = append(p.paths, strings.Split(path, "."))
+			}
+		}
+	}
+
+	return p
+}
+
+// Value 屏蔽 json.Unmarshal 得到的值，map 和切片原地修改，字符串按文本处理
+func (p *Policy) Value(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return p.Text(s)
+	}
+	v = p.walk(v)
+	for _, path := range p.paths {
+		v = p.applyPath(v, path)
+	}
+	return v
+}
+
+// Text 屏蔽无法解析为 JSON 的文本，例如被截断的请求体、表单和查询字符串
+func (p *Policy) Text(s string) string {
+	if s == "" {
+		return s
+	}
+	return replaceSubmatch(textPattern, s, func(groups []string) string {
+		if groups[1] != "" {
+			key, value := groups[1], groups[2]
+			if !p.sensitive(key) {
+				return groups[0]
+			}
+			prefix := strings.TrimSuffix(groups[0], value)
+			if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+				return prefix + `"` + p.maskText(key, value[1:len(value)-1]) + `"`
+			}
+			return prefix + `"` + p.maskText(key, strings.TrimPrefix(value, `"`)) + `"`
+		}
+		key, value := groups[4], groups[5]
+		if !p.sensitive(key) {
+			return groups[0]
+		}
+		return groups[3] + key + "=" + p.maskText(key, value)
+	})
+}
+
+// replaceSubmatch 与 ReplaceAllStringFunc 相同，但回调可以拿到分组
+func replaceSubmatch(re *regexp.Regexp, s string, fn func(groups []string) string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		groups := make([]string, len(m)/2)
+		for i := range groups {
+			if m[2*i] >= 0 {
+				groups[i] = s[m[2*i]:m[2*i+1]]
+			}
+		}
+		b.WriteString(s[last:m[0]])
+		b.WriteString(fn(groups))
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (p *Policy) sensitive(key string) bool {
+	name := normalize(key)
+	return p.keys[name] || p.partial[name]
+}
+
+func (p *Policy) walk(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			name := normalize(key)
+			switch {
+			case p.keys[name]:
+				val[key] = p.mask
+			case p.partial[name]:
+				val[key] = p.partialValue(item)
+			default:
+				val[key] = p.walk(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = p.walk(item)
+		}
+	}
+	return v
+}
+
+func (p *Policy) applyPath(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return p.mask
+	}
+	seg, rest := path[0], path[1:]
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			if seg == "*" || seg == key {
+				val[key] = p.applyPath(item, rest)
+			}
+		}
+	case []interface{}:
+		index, err := strconv.Atoi(seg)
+		for i, item := range val {
+			if seg == "*" || (err == nil && index == i) {
+				val[i] = p.applyPath(item, rest)
+			}
+		}
+	}
+	return v
+}
+
+func (p *Policy) maskText(key, value string) string {
+	if p.keys[normalize(key)] {
+		return p.mask
+	}
+	return partial(value, p.mask)
+}
+
+func (p *Policy) partialValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return partial(val, p.mask)
+	case float64:
+		return partial(strconv.FormatFloat(val, 'f', -1, 64), p.mask)
+	default:
+		return p.mask
+	}
+}
+
+// partial 邮箱保留用户名首字符和域名，其他值保留前 3 位和后 4 位，过短时整值屏蔽
+func partial(s, mask string) string {
+	if at := strings.LastIndex(s, "@"); at > 0 {
+		first, _ := utf8.DecodeRuneInString(s)
+		return string(first) + "***" + s[at:]
+	}
+	if utf8.RuneCountInString(s) <= 7 {
+		return mask
+	}
+	runes := []rune(s)
+	return string(runes[:3]) + "****" + string(runes[len(runes)-4:])
+}
+
+// normalize 键名不区分大小写并忽略 _ 和 -，new_password、newPassword 和 NEW-PASSWORD 视为相同
+func normalize(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
@@ -0,0 +1,85 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDefaultKeysMaskTwoFactorPayloads(t *testing.T) {
+	policy := New(Config{}).For("POST", "/api/auth/2fa/setup")
+
+	tests := []struct {
+		name   string
+		body   string
+		secret []string // 脱敏后不能出现的内容
+	}{
+		{
+			name:   "绑定认证器",
+			body:   `{"code":200,"data":{"secret":"JBSWY3DPEHPK3PXP","otpauthUri":"otpauth://totp/app:alice?secret=JBSWY3DPEHPK3PXP"}}`,
+			secret: []string{"JBSWY3DPEHPK3PXP", "otpauth://"},
+		},
+		{
+			name:   "启用后返回恢复码",
+			body:   `{"code":200,"data":{"recoveryCodes":["a1b2-c3d4","e5f6-g7h8"]}}`,
+			secret: []string{"a1b2-c3d4", "e5f6-g7h8"},
+		},
+		{
+			name:   "使用恢复码登录",
+			body:   `{"challengeToken":"eyJhbGciOi","recoveryCode":"a1b2-c3d4"}`,
+			secret: []string{"eyJhbGciOi", "a1b2-c3d4"},
+		},
+	}
+	for _, tt := range tests {
+		var v interface{}
+		if err := json.Unmarshal([]byte(tt.body), &v); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		out, err := json.Marshal(policy.Value(v))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for _, s := range tt.secret {
+			if strings.Contains(string(out), s) {
+				t.Errorf("%s: %s leaked in %s", tt.name, s, out)
+			}
+		}
+	}
+}
+
+func TestTextMasksTruncatedTwoFactorPayloads(t *testing.T) {
+	policy := New(Config{}).For("POST", "/api/auth/2fa/login")
+
+	tests := []struct {
+		text   string
+		secret string
+	}{
+		{`{"data":{"otpauthUri":"otpauth://totp/app:alice?secret=JBSWY3DP`, "JBSWY3DP"},
+		{`{"challengeToken":"x","recoveryCode":"a1b2-c3`, "a1b2-c3"},
+		{`recoveryCode=a1b2-c3d4&x=1`, "a1b2-c3d4"},
+	}
+	for _, tt := range tests {
+		if out := policy.Text(tt.text); strings.Contains(out, tt.secret) {
+			t.Errorf("Text(%q) = %q, %s leaked", tt.text, out, tt.secret)
+		}
+	}
+}
+
+func TestRouteSkipResponse(t *testing.T) {
+	r := New(Config{Routes: []RouteRule{{Method: "POST", Path: "/api/auth/2fa/enable", SkipResponse: true}}})
+
+	tests := []struct {
+		method, route string
+		skip          bool
+	}{
+		{"POST", "/api/auth/2fa/enable", true},
+		{"post", "/api/auth/2fa/enable", true},
+		{"GET", "/api/auth/2fa/enable", false},
+		{"POST", "/api/auth/2fa/disable", false},
+	}
+	for _, tt := range tests {
+		if got := r.For(tt.method, tt.route).SkipResponse; got != tt.skip {
+			t.Errorf("For(%s, %s).SkipResponse = %v, want %v", tt.method, tt.route, got, tt.skip)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"template-backend/config"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	// defaultKid 仅配置 secret 时使用的 key 标识
+	defaultKid = "default"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrUnknownKey   = errors.New("unknown signing key")
+	ErrKeyRetired   = errors.New("signing key retired")
+)
+
+// Key 一个签名/验签密钥
+type Key struct {
+	Kid         string
+	Algorithm   string
+	VerifyUntil time.Time // 零值表示不限制
+
+	method    jwt.SigningMethod
+	signKey   interface{} // 为 nil 时仅能验签
+	verifyKey interface{}
+}
+
+// Signer 签发、校验令牌并公开验签公钥，Manager 是基于配置密钥的实现，测试中可以替换
+type Signer interface {
+	Sign(claims jwt.MapClaims) (string, error)
+	Parse(tokenStr string) (jwt.MapClaims, error)
+	JWKS() JWKSet
+}
+
+// Manager 管理签发令牌的当前密钥和轮换期内的全部验签密钥
+type Manager struct {
+	issuer  string
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewManager 根据 JWT 配置加载密钥
+func NewManager(cfg config.JWTConfig) (*Manager, error) {
+	m := &Manager{issuer: cfg.Issuer, keys: make(map[string]*Key)}
+
+	keyConfigs := cfg.Keys
+	signingKid := cfg.SigningKey
+	if len(keyConfigs) == 0 {
+		if cfg.Secret == "" {
+			return nil, errors.New("jwt.secret 和 jwt.keys 不能同时为空")
+		}
+		keyConfigs = []config.JWTKey{{Kid: defaultKid, Algorithm: AlgHS256, Secret: cfg.Secret}}
+		signingKid = defaultKid
+	}
+
+	for _, kc := range keyConfigs {
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("加载 key %q 失败: %w", kc.Kid, err)
+		}
+		if _, exists := m.keys[key.Kid]; exists {
+			return nil, fmt.Errorf("重复的 kid: %s", key.Kid)
+		}
+		m.keys[key.Kid] = key
+	}
+
+	if signingKid == "" {
+		signingKid = keyConfigs[0].Kid
+	}
+	signing, ok := m.keys[signingKid]
+	if !ok {
+		return nil, fmt.Errorf("signing_key %q 不存在", signingKid)
+	}
+	if signing.signKey == nil {
+		return nil, fmt.Errorf("signing_key %q 缺少私钥", signingKid)
+	}
+	m.signing = signing
+	return m, nil
+}
+
+// Sign 使用当前签名密钥签发令牌，并在头部写入 kid
+func (m *Manager) Sign(claims jwt.MapClaims) (string, error) {
+	if m.issuer != "" {
+		claims["iss"] = m.issuer
+	}
+	tok := jwt.NewWithClaims(m.signing.method, claims)
+	tok.Header["kid"] = m.signing.Kid
+	return tok.SignedString(m.signing.signKey)
+}
+
+// Parse 校验令牌签名、有效期和签发者，根据 kid 选择验签密钥
+func (m *Manager) Parse(tokenStr string) (jwt.MapClaims, error) {
+	opts := []jwt.ParserOption{jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA})}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+
+	tok, err := jwt.Parse(tokenStr, m.keyFunc, opts...)
+	if err != nil || !tok.Valid {
+		return nil, ErrInvalidToken
+	}
+	claims, ok := tok.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (m *Manager) keyFunc(t *jwt.Token) (interface{}, error) {
+	key := m.signing
+	if kid, ok := t.Header["kid"].(string); ok {
+		if key, ok = m.keys[kid]; !ok {
+			return nil, ErrUnknownKey
+		}
+	}
+	if !key.VerifyUntil.IsZero() && time.Now().After(key.VerifyUntil) {
+		return nil, ErrKeyRetired
+	}
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
+}
+
+func loadKey(kc config.JWTKey) (*Key, error) {
+	if kc.Kid == "" {
+		return nil, errors.New("kid 不能为空")
+	}
+	key := &Key{Kid: kc.Kid, Algorithm: kc.Algorithm}
+	if kc.VerifyUntil != "" {
+		t, err := time.Parse(time.RFC3339, kc.VerifyUntil)
+		if err != nil {
+			return nil, fmt.Errorf("verify_until 格式错误: %w", err)
+		}
+		key.VerifyUntil = t
+	}
+
+	switch kc.Algorithm {
+	case AlgHS256:
+		if kc.Secret == "" {
+			return nil, errors.New("HS256 需要配置 secret")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.signKey = []byte(kc.Secret)
+		key.verifyKey = []byte(kc.Secret)
+
+	case AlgRS256:
+		key.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = private
+			key.verifyKey = &private.PublicKey
+		}
+		if kc.PublicKeyFile != "" {
+			pem, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = public
+		}
+
+	case AlgEdDSA:
+		key.method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = private
+			key.verifyKey = private.(ed25519.PrivateKey).Public()
+		}
+		if kc.PublicKeyFile != "" {
+			pem, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseEdPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = public
+		}
+
+	default:
+		return nil, fmt.Errorf("不支持的算法: %s", kc.Algorithm)
+	}
+
+	if key.verifyKey == nil {
+		return nil, errors.New("缺少 private_key_file 或 public_key_file")
+	}
+	return key, nil
+}
+
+// publicKey 返回非对称密钥的公钥，HS256 返回 nil
+func (k *Key) publicKey() crypto.PublicKey {
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return pub
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"template-backend/config"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKeys 把密钥对写入 PEM 文件，返回私钥和公钥文件路径
+func writeKeys(t *testing.T, name string, private, public interface{}) (string, string) {
+	t.Helper()
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("marshal private key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	dir := t.TempDir()
+	privateFile, publicFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+".pub.pem")
+	if err := os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600); err != nil {
+		t.Fatalf("write private key: %v", err)
+	}
+	if err := os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600); err != nil {
+		t.Fatalf("write public key: %v", err)
+	}
+	return privateFile, publicFile
+}
+
+func newManager(t *testing.T, cfg config.JWTConfig) *Manager {
+	t.Helper()
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("new manager: %v", err)
+	}
+	return m
+}
+
+func sign(t *testing.T, m *Manager) string {
+	t.Helper()
+	tok, err := m.Sign(jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return tok
+}
+
+func TestParseSelectsKeyByKid(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retiredKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsPrivate, rsPublic := writeKeys(t, "rs-old", rsaKey, &rsaKey.PublicKey)
+	edPrivateFile, _ := writeKeys(t, "ed-new", edPrivate, edPublic)
+	retiredPrivate, retiredPublic := writeKeys(t, "rs-retired", retiredKey, &retiredKey.PublicKey)
+
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	// 轮换后：使用新的 EdDSA key 签发，旧 RSA key 只保留公钥用于验签，另一把旧 key 已过验签期
+	m := newManager(t, config.JWTConfig{
+		Issuer:     "template-backend",
+		SigningKey: "ed-new",
+		Keys: []config.JWTKey{
+			{Kid: "ed-new", Algorithm: AlgEdDSA, PrivateKeyFile: edPrivateFile},
+			{Kid: "rs-old", Algorithm: AlgRS256, PublicKeyFile: rsPublic, VerifyUntil: future},
+			{Kid: "rs-retired", Algorithm: AlgRS256, PublicKeyFile: retiredPublic, VerifyUntil: past},
+			{Kid: "hs-internal", Algorithm: AlgHS256, Secret: "internal"},
+		},
+	})
+	old := newManager(t, config.JWTConfig{Issuer: "template-backend", SigningKey: "rs-old",
+		Keys: []config.JWTKey{{Kid: "rs-old", Algorithm: AlgRS256, PrivateKeyFile: rsPrivate}}})
+	retired := newManager(t, config.JWTConfig{Issuer: "template-backend", SigningKey: "rs-retired",
+		Keys: []config.JWTKey{{Kid: "rs-retired", Algorithm: AlgRS256, PrivateKeyFile: retiredPrivate}}})
+	unknown := newManager(t, config.JWTConfig{Issuer: "template-backend", SigningKey: "hs-unknown",
+		Keys: []config.JWTKey{{Kid: "hs-unknown", Algorithm: AlgHS256, Secret: "unknown"}}})
+	otherIssuer := newManager(t, config.JWTConfig{Issuer: "other", SigningKey: "rs-old",
+		Keys: []config.JWTKey{{Kid: "rs-old", Algorithm: AlgRS256, PrivateKeyFile: rsPrivate}}})
+
+	// 用 RSA 公钥作为 HS256 密钥伪造令牌，kid 指向 RSA key
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice", "iss": "template-backend"})
+	forged.Header["kid"] = "rs-old"
+	forgedStr, err := forged.SignedString(x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		valid  bool
+		keyErr error // keyFunc 返回的错误
+	}{
+		{"当前签名 key", sign(t, m), true, nil},
+		{"轮换前签发的令牌", sign(t, old), true, nil},
+		{"已过验签期的 key", sign(t, retired), false, ErrKeyRetired},
+		{"未配置的 kid", sign(t, unknown), false, ErrUnknownKey},
+		{"签发者不匹配", sign(t, otherIssuer), false, nil},
+		{"算法与 kid 不一致", forgedStr, false, nil},
+	}
+	for _, tt := range tests {
+		claims, err := m.Parse(tt.token)
+		if tt.valid {
+			if err != nil || claims["sub"] != "alice" {
+				t.Errorf("%s: Parse = %v, %v, want valid claims", tt.name, claims, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Parse err = %v, want %v", tt.name, err, ErrInvalidToken)
+		}
+		if tt.keyErr != nil {
+			if _, err := jwt.Parse(tt.token, m.keyFunc); !errors.Is(err, tt.keyErr) {
+				t.Errorf("%s: keyFunc err = %v, want %v", tt.name, err, tt.keyErr)
+			}
+		}
+	}
+
+	// JWKS 只公开仍在验签期内的非对称公钥
+	set := m.JWKS()
+	if len(set.Keys) != 2 || set.Keys[0].Kid != "ed-new" || set.Keys[1].Kid != "rs-old" {
+		t.Fatalf("JWKS kids = %+v, want [ed-new rs-old]", set.Keys)
+	}
+	if x, _ := base64.RawURLEncoding.DecodeString(set.Keys[0].X); string(x) != string(edPublic) {
+		t.Errorf("ed-new x does not match the public key")
+	}
+	if n, _ := base64.RawURLEncoding.DecodeString(set.Keys[1].N); string(n) != string(rsaKey.PublicKey.N.Bytes()) {
+		t.Errorf("rs-old n does not match the public key")
+	}
+}
+
+func TestNewManagerRejectsInvalidKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rsPublic := writeKeys(t, "rs", rsaKey, &rsaKey.PublicKey)
+
+	tests := []struct {
+		name string
+		cfg  config.JWTConfig
+	}{
+		{"未配置密钥", config.JWTConfig{}},
+		{"重复的 kid", config.JWTConfig{Keys: []config.JWTKey{
+			{Kid: "a", Algorithm: AlgHS256, Secret: "1"}, {Kid: "a", Algorithm: AlgHS256, Secret: "2"}}}},
+		{"签名 key 不存在", config.JWTConfig{SigningKey: "b", Keys: []config.JWTKey{{Kid: "a", Algorithm: AlgHS256, Secret: "1"}}}},
+		{"签名 key 只有公钥", config.JWTConfig{Keys: []config.JWTKey{{Kid: "rs", Algorithm: AlgRS256, PublicKeyFile: rsPublic}}}},
+		{"不支持的算法", config.JWTConfig{Keys: []config.JWTKey{{Kid: "a", Algorithm: "none"}}}},
+		{"验签期格式错误", config.JWTConfig{Keys: []config.JWTKey{{Kid: "a", Algorithm: AlgHS256, Secret: "1", VerifyUntil: "2026-01-01"}}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewManager(tt.cfg); err == nil {
+			t.Errorf("%s: NewManager succeeded, want error", tt.name)
+		}
+	}
+}
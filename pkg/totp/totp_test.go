@@ -0,0 +1,69 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录 B 中 SHA1 测试向量的密钥 "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeAtRFC6238Vectors(t *testing.T) {
+	// RFC 给出的是 8 位验证码，这里取后 6 位
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := CodeAt(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("CodeAt(%d): %v", tt.unix, err)
+		}
+		if got != tt.code {
+			t.Errorf("CodeAt(%d) = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	code := func(step int64) string {
+		c, err := CodeAt(rfcSecret, step)
+		if err != nil {
+			t.Fatalf("CodeAt(%d): %v", step, err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		skew   int
+		step   int64
+		ok     bool
+	}{
+		{"当前时间步", rfcSecret, code(current), 1, current, true},
+		{"前一个时间步", rfcSecret, code(current - 1), 1, current - 1, true},
+		{"后一个时间步", rfcSecret, code(current + 1), 1, current + 1, true},
+		{"超出偏差窗口", rfcSecret, code(current - 2), 1, 0, false},
+		{"不允许偏差", rfcSecret, code(current - 1), 0, 0, false},
+		{"前后空白", rfcSecret, " " + code(current) + " ", 1, current, true},
+		{"小写密钥", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code(current), 1, current, true},
+		{"长度不对", rfcSecret, code(current)[:5], 1, 0, false},
+		{"密钥不是 base32", "not-base32!", code(current), 1, 0, false},
+	}
+	for _, tt := range tests {
+		step, ok := Validate(tt.secret, tt.code, now, tt.skew)
+		if ok != tt.ok || step != tt.step {
+			t.Errorf("%s: Validate = %d, %v, want %d, %v", tt.name, step, ok, tt.step, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package tracing OpenTelemetry 链路追踪：初始化导出器、W3C traceparent 传播和 GORM 插件
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "template-backend"
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config 链路追踪配置
+type Config struct {
+	Exporter    string  // none | stdout | otlp，默认 none
+	Endpoint    string  // OTLP HTTP 地址，例如 localhost:4318，为空时使用 OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure    bool    // OTLP 使用 http 而不是 https
+	ServiceName string  `mapstructure:"service_name"` // 为空时使用 app.name
+	SampleRatio float64 `mapstructure:"sample_ratio"` // 采样比例，<= 0 或 >= 1 时全部采样
+	// TraceURL 日志查看页跳转到链路详情的地址，{traceId} 会被替换，例如 http://localhost:16686/trace/{traceId}
+	TraceURL string `mapstructure:"trace_url"`
+}
+
+// Enabled 是否配置了导出器
+func (c Config) Enabled() bool {
+	return c.Exporter != "" && c.Exporter != ExporterNone
+}
+
+// Link 日志关联的链路详情地址，未配置 TraceURL 或没有 traceID 时为空
+func (c Config) Link(traceID string) string {
+	if c.TraceURL == "" || traceID == "" {
+		return ""
+	}
+	return strings.ReplaceAll(c.TraceURL, "{traceId}", traceID)
+}
+
+// Setup 按配置创建导出器并设置为全局 TracerProvider，返回的函数用于退出时导出剩余的 span。
+// 未启用时只设置 W3C traceparent 传播，span 不会被记录
+func Setup(ctx context.Context, cfg Config, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !cfg.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("不支持的链路追踪导出器: %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("创建链路追踪导出器失败: %w", err)
+	}
+
+	if cfg.ServiceName != "" {
+		serviceName = cfg.ServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// 上游已决定采样时沿用上游的决定
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer 项目统一使用的 tracer，Setup 之前获取的 tracer 在 Setup 之后同样生效
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Start 创建子 span，用于服务方法等内部调用
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// TraceID 返回 ctx 中 span 的 trace ID，没有有效 span 时为空
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,24 @@
+package utils
+
+import "strings"
+
+// MatchPath 判断请求路径是否命中规则，返回命中的规则。规则与路径完全相同，
+// 或是路径按 / 分段的前缀时命中，例如 /swagger 命中 /swagger/index.html，但不命中 /swaggerx
+func MatchPath(patterns []string, path string) (string, bool) {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if path == pattern {
+			return pattern, true
+		}
+		prefix := pattern
+		if !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		if strings.HasPrefix(path, prefix) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
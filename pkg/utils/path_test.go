@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+	patterns := []string{"/api/auth/login", "/api/auth/2fa/login", "/swagger", "/public/"}
+
+	tests := []struct {
+		path    string
+		pattern string
+		ok      bool
+	}{
+		{"/api/auth/login", "/api/auth/login", true},
+		{"/api/auth/2fa/login", "/api/auth/2fa/login", true},
+		{"/api/auth/2fa/login/setup", "/api/auth/2fa/login", true},
+		{"/swagger/index.html", "/swagger", true},
+		{"/public/logo.png", "/public/", true},
+		{"/api/auth/login-audits", "", false},
+		{"/swaggerx", "", false},
+		{"/evil/api/auth/login", "", false},
+		{"/api/users", "", false},
+	}
+	for _, tt := range tests {
+		pattern, ok := MatchPath(patterns, tt.path)
+		if ok != tt.ok || pattern != tt.pattern {
+			t.Errorf("MatchPath(%q) = %q, %v, want %q, %v", tt.path, pattern, ok, tt.pattern, tt.ok)
+		}
+	}
+}
@@ -2,12 +2,17 @@ package utils
 
 import "github.com/gin-gonic/gin"
 
+// RequestIDKey 请求 ID 在 gin.Context 中的键，由请求 ID 中间件设置
+const RequestIDKey = "requestID"
+
 // ApiResponse 使用泛型来指定数据类型
 type ApiResponse[T any] struct {
 	Success bool   `json:"success"`
 	Data    T      `json:"data,omitempty"`
 	Message string `json:"message"`
 	Code    int    `json:"code"`
+	// RequestID 只在错误响应中返回，便于按请求 ID 查找日志
+	RequestID string `json:"requestId,omitempty"`
 }
 
 type PageResult[T any] struct {
@@ -18,9 +23,10 @@ type PageResult[T any] struct {
 }
 
 type ErrorResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Code    int    `json:"code"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	Code      int    `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
 }
 
 // Success 使用泛型创建成功的响应
@@ -44,6 +50,9 @@ func Error(message string, code int) *ApiResponse[string] {
 
 // JSON 响应函数，支持泛型
 func JSON[T any](ctx *gin.Context, resp *ApiResponse[T], httpCode ...int) {
+	if !resp.Success && resp.RequestID == "" {
+		resp.RequestID = ctx.GetString(RequestIDKey)
+	}
 	if len(httpCode) > 0 {
 		ctx.JSON(httpCode[0], resp)
 		return